@@ -0,0 +1,68 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// DB is a database handle paired with the dialect of its driver
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open opens a database with database/sql and selects the dialect registered
+// for driverName. Both the database/sql driver and the dbx dialect package
+// must be imported, for example:
+//
+//	import (
+//		_ "github.com/lib/pq"
+//		_ "github.com/swiftcarrot/dbx/postgresql"
+//	)
+//
+//	db, err := dbx.Open("postgres", dsn)
+func Open(driverName, dataSourceName string) (*DB, error) {
+	dialect, err := Lookup(driverName)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// Inspect returns the current schema of the database
+func (db *DB) Inspect() (*schema.Schema, error) {
+	return db.Dialect.Inspect(db.DB)
+}
+
+// InspectContext returns the current schema of the database, using ctx for
+// cancellation and deadlines
+func (db *DB) InspectContext(ctx context.Context) (*schema.Schema, error) {
+	return db.Dialect.InspectContext(ctx, db.DB)
+}
+
+// GenerateSQL converts a schema change to SQL for the database's dialect
+func (db *DB) GenerateSQL(change schema.Change) (string, error) {
+	return db.Dialect.GenerateSQL(change)
+}
+
+// Plan inspects the database and returns the plan that migrates it to target
+func (db *DB) Plan(ctx context.Context, target *schema.Schema, options ...schema.DiffOption) (*Plan, error) {
+	source, err := db.InspectContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlan(db.Dialect, source, target, options...)
+}
+
+// DetectDrift reports how the database differs from the schema it is expected to have
+func (db *DB) DetectDrift(ctx context.Context, expected *schema.Schema) (*schema.DriftReport, error) {
+	return DetectDrift(ctx, db.Dialect, db.DB, expected)
+}
@@ -0,0 +1,142 @@
+// Package dbx ties the dialect packages (postgresql, mysql, sqlite) together
+// behind a common interface so that generic code can work with any of them.
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// Querier is the subset of database/sql used to run queries. It is satisfied
+// by *sql.DB, *sql.Tx and *sql.Conn, so inspection can run inside a
+// transaction or on a pinned connection with session settings applied.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	_ Querier = (*sql.DB)(nil)
+	_ Querier = (*sql.Tx)(nil)
+	_ Querier = (*sql.Conn)(nil)
+)
+
+// Dialect is implemented by every supported database dialect
+type Dialect interface {
+	// Inspect queries the database and returns its schema
+	Inspect(db Querier) (*schema.Schema, error)
+	// InspectContext is like Inspect but honors ctx for cancellation and deadlines
+	InspectContext(ctx context.Context, db Querier) (*schema.Schema, error)
+	// InspectTablesContext returns the names of the tables in the database
+	InspectTablesContext(ctx context.Context, db Querier) ([]string, error)
+	// GenerateSQL converts a schema change to SQL statements for the dialect
+	GenerateSQL(change schema.Change) (string, error)
+	// Placeholder returns the bind parameter placeholder for the nth (1-based) argument of a query
+	Placeholder(n int) string
+}
+
+// NonTransactional is implemented by dialects that generate statements which
+// cannot run inside a transaction, such as PostgreSQL's CREATE INDEX CONCURRENTLY.
+// The migrator runs a migration containing such a change without a transaction.
+type NonTransactional interface {
+	// OutsideTransaction reports whether the SQL for change must run outside a transaction
+	OutsideTransaction(change schema.Change) bool
+}
+
+// ConnPreparer is implemented by dialects whose SQL for some changes relies on
+// session settings that cannot change inside a transaction, such as SQLite's
+// foreign key enforcement, which must be off while a table is rebuilt
+type ConnPreparer interface {
+	// PrepareConn changes the settings of conn that changes need before a
+	// transaction begins on it. It returns check, which verifies the applied
+	// changes before the transaction commits, and restore, which puts the
+	// settings back once the transaction ends.
+	PrepareConn(ctx context.Context, conn Querier, changes []schema.Change) (check func(ctx context.Context, db Querier) error, restore func() error, err error)
+}
+
+// HazardClassifier is implemented by dialects whose way of applying a change
+// makes it more or less hazardous than Diff assumes, such as MySQL copying a
+// table for changes InnoDB cannot make in place
+type HazardClassifier interface {
+	// ClassifyHazards adds hazards to or removes hazards from a change returned by Diff
+	ClassifyHazards(change schema.Change)
+}
+
+// ClassifyHazards lets dialect adjust the hazards of changes returned by Diff
+// when it is a HazardClassifier
+func ClassifyHazards(dialect Dialect, changes []schema.Change) {
+	classifier, ok := dialect.(HazardClassifier)
+	if !ok {
+		return
+	}
+	for _, change := range changes {
+		classifier.ClassifyHazards(change)
+	}
+}
+
+// Timeouter is implemented by dialects that can limit how long a statement
+// waits for locks and how long it runs, so that a migration blocked behind a
+// long running query fails instead of queueing every other query behind it
+type Timeouter interface {
+	// SetTimeoutsSQL returns the statements that set the lock and statement
+	// timeouts of the session, a zero timeout restores the default of the session
+	SetTimeoutsSQL(lockTimeout, statementTimeout time.Duration) []string
+	// ResetTimeoutsSQL returns the statements that restore the default timeouts of the session
+	ResetTimeoutsSQL() []string
+	// IsLockTimeout reports whether err was returned by a statement that timed out waiting for a lock
+	IsLockTimeout(err error) bool
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = make(map[string]Dialect)
+)
+
+// Register makes a dialect available under the given database/sql driver name.
+// Dialect packages call it from init, so importing a dialect package is enough
+// to make dbx.Open recognize its drivers. Register panics if called twice with
+// the same driver name or if dialect is nil.
+func Register(driverName string, dialect Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	if dialect == nil {
+		panic("dbx: Register dialect is nil")
+	}
+	if _, dup := dialects[driverName]; dup {
+		panic("dbx: Register called twice for driver " + driverName)
+	}
+	dialects[driverName] = dialect
+}
+
+// Lookup returns the dialect registered for the given driver name
+func Lookup(driverName string) (Dialect, error) {
+	dialectsMu.RLock()
+	dialect, ok := dialects[driverName]
+	dialectsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("dbx: unknown driver %q (forgotten import?)", driverName)
+	}
+	return dialect, nil
+}
+
+// Drivers returns a sorted list of the driver names with a registered dialect
+func Drivers() []string {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	list := make([]string, 0, len(dialects))
+	for name := range dialects {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,63 @@
+package dbx_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/mysql"
+	"github.com/swiftcarrot/dbx/postgresql"
+	"github.com/swiftcarrot/dbx/sqlite"
+)
+
+func TestDrivers(t *testing.T) {
+	require.Equal(t, []string{"mysql", "pgx", "postgres", "sqlite", "sqlite3"}, dbx.Drivers())
+}
+
+func TestLookup(t *testing.T) {
+	dialect, err := dbx.Lookup("postgres")
+	require.NoError(t, err)
+	require.IsType(t, &postgresql.PostgreSQL{}, dialect)
+
+	dialect, err = dbx.Lookup("mysql")
+	require.NoError(t, err)
+	require.IsType(t, &mysql.MySQL{}, dialect)
+
+	dialect, err = dbx.Lookup("sqlite3")
+	require.NoError(t, err)
+	require.IsType(t, &sqlite.SQLite{}, dialect)
+}
+
+func TestLookupUnknownDriver(t *testing.T) {
+	_, err := dbx.Lookup("oracle")
+	require.EqualError(t, err, `dbx: unknown driver "oracle" (forgotten import?)`)
+}
+
+func TestOpen(t *testing.T) {
+	db, err := dbx.Open("sqlite3", filepath.Join(t.TempDir(), "open.db"))
+	require.NoError(t, err)
+	require.IsType(t, &sqlite.SQLite{}, db.Dialect)
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+
+	s, err := db.Inspect()
+	require.NoError(t, err)
+	require.Len(t, s.Tables, 1)
+	require.Equal(t, "users", s.Tables[0].Name)
+}
+
+func TestOpenInspectContextCanceled(t *testing.T) {
+	db, err := dbx.Open("sqlite3", filepath.Join(t.TempDir(), "canceled.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = db.InspectContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
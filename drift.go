@@ -0,0 +1,18 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// DetectDrift inspects the database and reports how it differs from the
+// schema it is expected to have, such as objects created or altered by hand
+func DetectDrift(ctx context.Context, dialect Dialect, db Querier, expected *schema.Schema) (*schema.DriftReport, error) {
+	actual, err := dialect.InspectContext(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect database: %w", err)
+	}
+	return schema.Drift(expected, actual), nil
+}
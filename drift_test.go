@@ -0,0 +1,32 @@
+package dbx_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+	"github.com/swiftcarrot/dbx/sqlite"
+)
+
+func TestDetectDrift(t *testing.T) {
+	db, err := dbx.Open("sqlite3", filepath.Join(t.TempDir(), "drift.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER NOT NULL, name TEXT, nickname TEXT)`)
+	require.NoError(t, err)
+
+	expected := schema.NewSchema()
+	expected.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &sqlite.IntegerType{})
+		t.Column("name", &sqlite.TextType{})
+	})
+
+	report, err := db.DetectDrift(context.Background(), expected)
+	require.NoError(t, err)
+	require.Equal(t, `extra column users.nickname
+changed column users.name: Nullable expected "false", actual "true"`, report.String())
+}
@@ -0,0 +1,301 @@
+// Package ddl splits SQL files into statements and tokenizes them for the DDL
+// parsers of the dialect packages
+package ddl
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options selects the lexical rules of a dialect
+type Options struct {
+	FoldCase      bool // Unquoted identifiers are folded to lower case (PostgreSQL)
+	DollarQuotes  bool // $tag$ quoted strings (PostgreSQL)
+	MetaCommands  bool // psql meta-commands such as \connect, skipped up to the end of the line (PostgreSQL)
+	HashComments  bool // # comments (MySQL)
+	Backslashes   bool // Backslash escapes in strings (MySQL)
+	Executable    bool // /*!50001 ... */ comments holding statements (MySQL)
+	Delimiters    bool // DELIMITER commands of the mysql client (MySQL)
+	Brackets      bool // [identifier] quoting (SQLite)
+	TriggerBodies bool // CREATE TRIGGER bodies holding semicolons between BEGIN and END (SQLite)
+}
+
+// Kind is the kind of a token
+type Kind int
+
+const (
+	EOF        Kind = iota // End of the statement
+	Word                   // Keyword or unquoted identifier
+	Identifier             // Quoted identifier
+	String                 // String literal
+	Number                 // Numeric literal
+	Symbol                 // Punctuation or operator character
+)
+
+// Token is a token of a statement
+type Token struct {
+	Kind Kind
+	Text string // Text of the token as written
+	Pos  int    // Byte offset of the token in the statement
+	End  int    // Byte offset after the token
+	Line int    // Line of the token, counting from 1
+
+	backslashes bool
+	fold        bool
+}
+
+// Value returns the identifier without its quotes or the string literal
+// without its quotes and escapes, and the text of other tokens
+func (t Token) Value() string {
+	switch t.Kind {
+	case Word:
+		if t.fold {
+			return strings.ToLower(t.Text)
+		}
+	case Identifier:
+		quote := t.Text[:1]
+		body := t.Text[1 : len(t.Text)-1]
+		if quote == "[" {
+			return body
+		}
+		return strings.ReplaceAll(body, quote+quote, quote)
+	case String:
+		text := t.Text
+		if strings.HasPrefix(text, "$") {
+			tag := text[:strings.Index(text[1:], "$")+2]
+			return text[len(tag) : len(text)-len(tag)]
+		}
+		backslashes := t.backslashes
+		if text[0] != '\'' {
+			backslashes = backslashes || text[0] == 'E' || text[0] == 'e'
+			text = text[1:]
+		}
+		return unescape(text[1:len(text)-1], backslashes)
+	}
+	return t.Text
+}
+
+func unescape(s string, backslashes bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case backslashes && s[i] == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// lexer scans the tokens of SQL text
+type lexer struct {
+	input      string
+	options    Options
+	pos        int
+	line       int
+	executable int // Number of open /*! comments
+}
+
+func newLexer(input string, options Options) *lexer {
+	return &lexer{input: input, options: options, line: 1}
+}
+
+func (l *lexer) eof() bool {
+	return l.pos >= len(l.input)
+}
+
+func (l *lexer) advance(n int) {
+	l.line += strings.Count(l.input[l.pos:l.pos+n], "\n")
+	l.pos += n
+}
+
+func (l *lexer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(l.input[l.pos:], prefix)
+}
+
+// skip skips whitespace and comments, and the markers of executable comments
+func (l *lexer) skip() {
+	for !l.eof() {
+		switch {
+		case l.input[l.pos] == ' ' || l.input[l.pos] == '\t' || l.input[l.pos] == '\r' || l.input[l.pos] == '\n':
+			l.advance(1)
+		case l.hasPrefix("--") || l.options.HashComments && l.hasPrefix("#"):
+			l.advance(l.lineLength())
+		case l.options.Executable && l.hasPrefix("/*!"):
+			n := 3
+			for l.pos+n < len(l.input) && l.input[l.pos+n] >= '0' && l.input[l.pos+n] <= '9' {
+				n++
+			}
+			l.advance(n)
+			l.executable++
+		case l.executable > 0 && l.hasPrefix("*/"):
+			l.advance(2)
+			l.executable--
+		case l.hasPrefix("/*"):
+			end := strings.Index(l.input[l.pos+2:], "*/")
+			if end < 0 {
+				l.advance(len(l.input) - l.pos)
+			} else {
+				l.advance(end + 4)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// lineLength returns the length of the rest of the current line
+func (l *lexer) lineLength() int {
+	if end := strings.IndexByte(l.input[l.pos:], '\n'); end >= 0 {
+		return end
+	}
+	return len(l.input) - l.pos
+}
+
+// next scans the token at the current position, which is not whitespace
+func (l *lexer) next() Token {
+	start, line := l.pos, l.line
+	kind, n := l.scan()
+	l.advance(n)
+	return Token{
+		Kind:        kind,
+		Text:        l.input[start:l.pos],
+		Pos:         start,
+		End:         l.pos,
+		Line:        line,
+		backslashes: l.options.Backslashes,
+		fold:        l.options.FoldCase,
+	}
+}
+
+// scan returns the kind and length of the token at the current position
+func (l *lexer) scan() (Kind, int) {
+	rest := l.input[l.pos:]
+	c := rest[0]
+	switch {
+	case c == '\'':
+		return String, quotedLength(rest, '\'', l.options.Backslashes)
+	case (c == 'E' || c == 'e' || c == 'N' || c == 'n' || c == 'X' || c == 'x' || c == 'B' || c == 'b') && len(rest) > 1 && rest[1] == '\'':
+		return String, 1 + quotedLength(rest[1:], '\'', l.options.Backslashes || c == 'E' || c == 'e')
+	case c == '"' || c == '`':
+		return Identifier, quotedLength(rest, c, false)
+	case c == '[' && l.options.Brackets:
+		if end := strings.IndexByte(rest, ']'); end >= 0 {
+			return Identifier, end + 1
+		}
+		return Identifier, len(rest)
+	case c == '$' && l.options.DollarQuotes:
+		if n := dollarQuotedLength(rest); n > 0 {
+			return String, n
+		}
+		return Symbol, 1 + wordLength(rest[1:])
+	case c >= '0' && c <= '9' || c == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9':
+		return Number, numberLength(rest)
+	case isWordStart(rest):
+		return Word, wordLength(rest)
+	}
+	_, size := utf8.DecodeRuneInString(rest)
+	return Symbol, size
+}
+
+// quotedLength returns the length of the quoted text at the start of s, in
+// which a doubled quote or, with backslashes, a backslash escapes the next character
+func quotedLength(s string, quote byte, backslashes bool) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case backslashes && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// dollarQuotedLength returns the length of the $tag$ quoted string at the
+// start of s, or 0 when s does not start with a dollar quote
+func dollarQuotedLength(s string) int {
+	end := strings.IndexByte(s[1:], '$')
+	if end < 0 {
+		return 0
+	}
+	tag := s[:end+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return 0
+		}
+	}
+	if len(tag) > 2 && tag[1] >= '0' && tag[1] <= '9' {
+		return 0
+	}
+	closing := strings.Index(s[len(tag):], tag)
+	if closing < 0 {
+		return len(s)
+	}
+	return len(tag) + closing + len(tag)
+}
+
+func numberLength(s string) int {
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			i = j
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+func isWordStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func wordLength(s string) int {
+	for i, r := range s {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// Tokenize returns the tokens of a statement
+func Tokenize(sql string, options Options) []Token {
+	l := newLexer(sql, options)
+	var tokens []Token
+	for l.skip(); !l.eof(); l.skip() {
+		tokens = append(tokens, l.next())
+	}
+	return tokens
+}
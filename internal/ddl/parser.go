@@ -0,0 +1,317 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+)
+
+// Parser reads the tokens of a statement
+type Parser struct {
+	sql     string
+	tokens  []Token
+	pos     int
+	options Options
+}
+
+// NewParser returns a parser for the tokens of a statement
+func NewParser(sql string, options Options) *Parser {
+	return &Parser{sql: sql, tokens: Tokenize(sql, options), options: options}
+}
+
+// Done reports whether every token has been read
+func (p *Parser) Done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// Peek returns the next token without reading it
+func (p *Parser) Peek() Token {
+	return p.peekAt(0)
+}
+
+func (p *Parser) peekAt(offset int) Token {
+	if p.pos+offset >= len(p.tokens) {
+		return Token{Kind: EOF, Pos: len(p.sql), End: len(p.sql)}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+// Next reads the next token
+func (p *Parser) Next() Token {
+	token := p.Peek()
+	if !p.Done() {
+		p.pos++
+	}
+	return token
+}
+
+// matches reports whether a token is the keyword or symbol word
+func matches(token Token, word string) bool {
+	return (token.Kind == Word || token.Kind == Symbol) && strings.EqualFold(token.Text, word)
+}
+
+// Is reports whether the next tokens are the given keywords and symbols
+func (p *Parser) Is(words ...string) bool {
+	for i, word := range words {
+		if !matches(p.peekAt(i), word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Accept reads the next tokens if they are the given keywords and symbols
+func (p *Parser) Accept(words ...string) bool {
+	if !p.Is(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+// AcceptAny reads the next token if it is one of the given keywords and
+// returns it in upper case
+func (p *Parser) AcceptAny(words ...string) (string, bool) {
+	for _, word := range words {
+		if p.Accept(word) {
+			return strings.ToUpper(word), true
+		}
+	}
+	return "", false
+}
+
+// Expect reads the next tokens, which must be the given keywords and symbols
+func (p *Parser) Expect(words ...string) error {
+	if !p.Accept(words...) {
+		return fmt.Errorf("expected %s, found %s", strings.Join(words, " "), describe(p.Peek()))
+	}
+	return nil
+}
+
+// Find reports whether the given keywords and symbols follow each other
+// anywhere in the rest of the statement
+func (p *Parser) Find(words ...string) bool {
+	for i := p.pos; i < len(p.tokens); i++ {
+		q := Parser{tokens: p.tokens, pos: i}
+		if q.Is(words...) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ident reads an identifier, without its quotes
+func (p *Parser) Ident() (string, error) {
+	token := p.Peek()
+	if token.Kind != Word && token.Kind != Identifier {
+		return "", fmt.Errorf("expected identifier, found %s", describe(token))
+	}
+	p.pos++
+	return token.Value(), nil
+}
+
+// Path reads identifiers separated by dots, such as schema.table.column
+func (p *Parser) Path() ([]string, error) {
+	name, err := p.Ident()
+	if err != nil {
+		return nil, err
+	}
+	path := []string{name}
+	for p.Is(".") && (p.peekAt(1).Kind == Word || p.peekAt(1).Kind == Identifier) {
+		p.pos++
+		name, _ = p.Ident()
+		path = append(path, name)
+	}
+	return path, nil
+}
+
+// Name reads a possibly qualified name, and returns the name and the
+// qualifier before it, empty when it has none
+func (p *Parser) Name() (qualifier, name string, err error) {
+	path, err := p.Path()
+	if err != nil {
+		return "", "", err
+	}
+	if len(path) > 1 {
+		qualifier = path[len(path)-2]
+	}
+	return qualifier, path[len(path)-1], nil
+}
+
+// String reads a string literal, without its quotes and escapes
+func (p *Parser) String() (string, error) {
+	token := p.Peek()
+	if token.Kind != String {
+		return "", fmt.Errorf("expected string, found %s", describe(token))
+	}
+	p.pos++
+	return token.Value(), nil
+}
+
+// Int reads an integer, with an optional sign
+func (p *Parser) Int() (int64, error) {
+	sign := int64(1)
+	if p.Accept("-") {
+		sign = -1
+	} else {
+		p.Accept("+")
+	}
+	token := p.Peek()
+	var value int64
+	if _, err := fmt.Sscan(token.Text, &value); err != nil || token.Kind != Number {
+		return 0, fmt.Errorf("expected integer, found %s", describe(token))
+	}
+	p.pos++
+	return sign * value, nil
+}
+
+// Group reads a parenthesized group and returns the text between the parentheses
+func (p *Parser) Group() (string, error) {
+	if err := p.Expect("("); err != nil {
+		return "", err
+	}
+	start := p.pos
+	depth := 1
+	for ; !p.Done(); p.pos++ {
+		switch {
+		case matches(p.tokens[p.pos], "("):
+			depth++
+		case matches(p.tokens[p.pos], ")"):
+			depth--
+		}
+		if depth == 0 {
+			text := p.text(start, p.pos)
+			p.pos++
+			return text, nil
+		}
+	}
+	return "", fmt.Errorf("missing closing parenthesis")
+}
+
+// Items reads a parenthesized list and returns the text of its comma
+// separated items
+func (p *Parser) Items() ([]string, error) {
+	group, err := p.Group()
+	if err != nil {
+		return nil, err
+	}
+	q := NewParser(group, p.options)
+	var items []string
+	for !q.Done() {
+		items = append(items, q.Until(","))
+		q.Accept(",")
+	}
+	return items, nil
+}
+
+// Names reads a parenthesized list of identifiers
+func (p *Parser) Names() ([]string, error) {
+	items, err := p.Items()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		q := NewParser(item, p.options)
+		name, err := q.Ident()
+		if err != nil {
+			return nil, err
+		}
+		if !q.Done() {
+			return nil, fmt.Errorf("expected identifier, found %q", item)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Until reads the tokens up to one of the given stops, outside parentheses,
+// and returns their text. A stop is a keyword or symbol, or several of them
+// separated by spaces.
+func (p *Parser) Until(stops ...string) string {
+	start := p.pos
+	depth := 0
+	for ; !p.Done(); p.pos++ {
+		if depth == 0 {
+			for _, stop := range stops {
+				if p.Is(strings.Fields(stop)...) {
+					return p.text(start, p.pos)
+				}
+			}
+		}
+		switch {
+		case matches(p.tokens[p.pos], "(") || matches(p.tokens[p.pos], "["):
+			depth++
+		case matches(p.tokens[p.pos], ")") || matches(p.tokens[p.pos], "]"):
+			depth--
+		}
+	}
+	return p.text(start, p.pos)
+}
+
+// Rest reads the remaining tokens and returns their text
+func (p *Parser) Rest() string {
+	start := p.pos
+	p.pos = len(p.tokens)
+	return p.text(start, p.pos)
+}
+
+// text returns the text of the tokens from start up to end
+func (p *Parser) text(start, end int) string {
+	if start >= end {
+		return ""
+	}
+	return p.sql[p.tokens[start].Pos:p.tokens[end-1].End]
+}
+
+// Unsupported returns the error for a statement the parser does not support,
+// naming its leading keywords, such as CREATE EVENT
+func (p *Parser) Unsupported() error {
+	q := &Parser{tokens: p.tokens}
+	command, ok := q.AcceptAny("CREATE", "ALTER", "DROP")
+	if !ok {
+		return fmt.Errorf("unsupported statement %s", strings.ToUpper(q.Peek().Text))
+	}
+	words := []string{command}
+	if q.Peek().Kind == Word {
+		if q.Accept("OR", "REPLACE") {
+			words = append(words, "OR", "REPLACE")
+		}
+		words = append(words, strings.ToUpper(q.Next().Text))
+	}
+	return fmt.Errorf("unsupported statement %s", strings.Join(words, " "))
+}
+
+func describe(token Token) string {
+	if token.Kind == EOF {
+		return "end of statement"
+	}
+	return fmt.Sprintf("%q", token.Text)
+}
+
+// Parse splits a SQL file into statements and calls parse for each of them.
+// The statements parse returns an error for, or does not read to the end, are
+// returned in a *dbx.ParseError.
+func Parse(sql string, options Options, parse func(p *Parser) error) error {
+	var unsupported []dbx.UnsupportedStatement
+	for _, statement := range Split(sql, options) {
+		p := NewParser(statement.SQL, options)
+		err := parse(p)
+		if err == nil && !p.Done() {
+			err = fmt.Errorf("unexpected %s", describe(p.Peek()))
+		}
+		if err != nil {
+			unsupported = append(unsupported, dbx.UnsupportedStatement{
+				Line:      statement.Line,
+				Statement: statement.SQL,
+				Reason:    err.Error(),
+			})
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return &dbx.ParseError{Unsupported: unsupported}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package ddl
+
+import (
+	"strings"
+)
+
+// Statement is a statement of a SQL file
+type Statement struct {
+	SQL  string // Text of the statement without comments and its delimiter
+	Line int    // Line of the file the statement starts on, counting from 1
+}
+
+// Split splits a SQL file into statements. Comments are left out of the
+// statements, and the text of executable comments is kept.
+func Split(input string, options Options) []Statement {
+	l := newLexer(input, options)
+	delimiter := ";"
+	var statements []Statement
+	var tokens []Token
+
+	flush := func() {
+		if len(tokens) > 0 {
+			statements = append(statements, Statement{SQL: join(input, tokens), Line: tokens[0].Line})
+			tokens = nil
+		}
+	}
+
+	for l.skip(); !l.eof(); l.skip() {
+		if len(tokens) == 0 && l.atLineStart() {
+			if options.MetaCommands && l.hasPrefix(`\`) {
+				l.advance(l.lineLength())
+				continue
+			}
+			if options.Delimiters && isDelimiterCommand(l.input[l.pos:]) {
+				fields := strings.Fields(l.input[l.pos : l.pos+l.lineLength()])
+				if len(fields) > 1 {
+					delimiter = fields[1]
+				}
+				l.advance(l.lineLength())
+				continue
+			}
+		}
+		if l.hasPrefix(delimiter) && !(options.TriggerBodies && inTriggerBody(tokens)) {
+			l.advance(len(delimiter))
+			flush()
+			continue
+		}
+		tokens = append(tokens, l.next())
+	}
+	flush()
+
+	return statements
+}
+
+// atLineStart reports whether only whitespace precedes the current position on its line
+func (l *lexer) atLineStart() bool {
+	start := strings.LastIndexByte(l.input[:l.pos], '\n') + 1
+	return strings.TrimSpace(l.input[start:l.pos]) == ""
+}
+
+func isDelimiterCommand(s string) bool {
+	return len(s) > len("DELIMITER") && strings.EqualFold(s[:len("DELIMITER")], "DELIMITER") &&
+		(s[len("DELIMITER")] == ' ' || s[len("DELIMITER")] == '\t')
+}
+
+// join returns the text of a statement's tokens, replacing the comments
+// between them with a space
+func join(input string, tokens []Token) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 {
+			gap := input[tokens[i-1].End:token.Pos]
+			if strings.TrimSpace(gap) == "" {
+				b.WriteString(gap)
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(token.Text)
+	}
+	return b.String()
+}
+
+// inTriggerBody reports whether the tokens of a CREATE TRIGGER statement end
+// inside its BEGIN ... END body, where a semicolon does not end the statement
+func inTriggerBody(tokens []Token) bool {
+	p := &Parser{tokens: tokens}
+	if !p.Accept("CREATE") {
+		return false
+	}
+	p.AcceptAny("TEMP", "TEMPORARY")
+	if !p.Accept("TRIGGER") {
+		return false
+	}
+
+	begun, depth := false, 0
+	for _, token := range tokens[p.pos:] {
+		if token.Kind != Word {
+			continue
+		}
+		switch strings.ToUpper(token.Text) {
+		case "BEGIN":
+			begun = true
+		case "CASE":
+			depth++
+		case "END":
+			if depth == 0 {
+				return false
+			}
+			depth--
+		}
+	}
+	return begun
+}
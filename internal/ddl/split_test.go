@@ -0,0 +1,68 @@
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	statements := Split(`-- header
+CREATE TABLE a (id int); /* comment */ CREATE TABLE b (
+  name text DEFAULT ';' -- trailing
+);
+
+SELECT 1`, Options{})
+	require.Equal(t, []Statement{
+		{SQL: "CREATE TABLE a (id int)", Line: 2},
+		{SQL: "CREATE TABLE b (\n  name text DEFAULT ';' )", Line: 2},
+		{SQL: "SELECT 1", Line: 6},
+	}, statements)
+}
+
+func TestSplitDollarQuotes(t *testing.T) {
+	statements := Split(`\connect app
+CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;
+SELECT $1;`, Options{DollarQuotes: true, MetaCommands: true})
+	require.Equal(t, []Statement{
+		{SQL: "CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql", Line: 2},
+		{SQL: "SELECT $1", Line: 3},
+	}, statements)
+}
+
+func TestSplitDelimiters(t *testing.T) {
+	statements := Split(`/*!40101 SET NAMES utf8mb4 */;
+# comment
+DELIMITER ;;
+/*!50003 CREATE*/ /*!50003 TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN
+  SET NEW.name = 'it\'s';
+END */;;
+DELIMITER ;
+DROP TABLE a;`, Options{HashComments: true, Backslashes: true, Executable: true, Delimiters: true})
+	require.Equal(t, []Statement{
+		{SQL: "SET NAMES utf8mb4", Line: 1},
+		{SQL: "CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN\n  SET NEW.name = 'it\\'s';\nEND", Line: 4},
+		{SQL: "DROP TABLE a", Line: 8},
+	}, statements)
+}
+
+func TestSplitTriggerBodies(t *testing.T) {
+	statements := Split(`CREATE TRIGGER t AFTER UPDATE ON a BEGIN
+  UPDATE a SET kind = CASE WHEN new.x THEN 1 ELSE 2 END;
+  DELETE FROM b;
+END;
+CREATE INDEX [a x] ON a (x);`, Options{Brackets: true, TriggerBodies: true})
+	require.Len(t, statements, 2)
+	require.Equal(t, 1, statements[0].Line)
+	require.Equal(t, "CREATE INDEX [a x] ON a (x)", statements[1].SQL)
+	require.Equal(t, 5, statements[1].Line)
+}
+
+func TestTokenValue(t *testing.T) {
+	tokens := Tokenize(`Users "Mixed ""Name""" 'it''s' E'a\nb' $$x$$`, Options{FoldCase: true, DollarQuotes: true})
+	var values []string
+	for _, token := range tokens {
+		values = append(values, token.Value())
+	}
+	require.Equal(t, []string{"users", `Mixed "Name"`, "it's", "a\nb", "x"}, values)
+}
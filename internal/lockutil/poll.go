@@ -0,0 +1,43 @@
+// Package lockutil contains helpers shared by the dialect lock implementations.
+package lockutil
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Interval is the delay between two attempts to acquire a lock
+var Interval = 100 * time.Millisecond
+
+// ErrTimeout is returned by Poll when the lock was not acquired before the timeout
+var ErrTimeout = errors.New("lock timeout")
+
+// Poll calls try until it reports that the lock was acquired, try fails, ctx is
+// done or timeout elapses. A timeout of zero or less waits until ctx is done.
+func Poll(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := try()
+		if err != nil || acquired {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return ErrTimeout
+		case <-ticker.C:
+		}
+	}
+}
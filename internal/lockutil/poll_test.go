@@ -0,0 +1,44 @@
+package lockutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	attempts := 0
+	err := Poll(context.Background(), time.Second, func() (bool, error) {
+		attempts++
+		return attempts == 3, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+}
+
+func TestPollTimeout(t *testing.T) {
+	err := Poll(context.Background(), 10*time.Millisecond, func() (bool, error) {
+		return false, nil
+	})
+	require.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestPollCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Poll(ctx, 0, func() (bool, error) {
+		return false, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPollError(t *testing.T) {
+	err := Poll(context.Background(), time.Second, func() (bool, error) {
+		return false, errors.New("connection lost")
+	})
+	require.EqualError(t, err, "connection lost")
+}
@@ -0,0 +1,34 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Locker is implemented by dialects that provide a lock shared by every
+// process using the same database, such as PostgreSQL advisory locks. It is
+// used to keep concurrent deploys from running migrations at the same time.
+type Locker interface {
+	// Lock blocks until the named lock is acquired, ctx is done or timeout
+	// elapses, a timeout of zero or less waits until ctx is done. The
+	// returned function releases the lock and must always be called.
+	Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (unlock func() error, err error)
+}
+
+// LockTimeoutError is returned by Locker.Lock when the lock could not be
+// acquired before the timeout
+type LockTimeoutError struct {
+	Name    string
+	Timeout time.Duration
+	// Holder describes the session or process holding the lock, if known
+	Holder string
+}
+
+func (e *LockTimeoutError) Error() string {
+	if e.Holder != "" {
+		return fmt.Sprintf("dbx: timed out after %s waiting for lock %q held by %s", e.Timeout, e.Name, e.Holder)
+	}
+	return fmt.Sprintf("dbx: timed out after %s waiting for lock %q", e.Timeout, e.Name)
+}
@@ -1,6 +1,8 @@
 package migration
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/swiftcarrot/dbx/schema"
@@ -21,7 +23,9 @@ type Migration struct {
 	// UpFn defines the schema changes for migrating up
 	UpFn func() *schema.Schema
 
-	// DownFn defines the schema changes for rolling back (migrating down)
+	// DownFn defines the schema changes for rolling back (migrating down).
+	// When it is nil, the migrator reverses the migration to the Up schema of
+	// the previous migration, see schema.Reverse.
 	DownFn func() *schema.Schema
 }
 
@@ -56,3 +60,10 @@ func (m *Migration) Down() *schema.Schema {
 func (m *Migration) FullVersion() string {
 	return m.Version + "_" + m.Name
 }
+
+// Checksum returns a SHA-256 digest of the Up schema, used to detect
+// migrations that were edited after being applied
+func (m *Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up().String()))
+	return hex.EncodeToString(sum[:])
+}
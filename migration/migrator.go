@@ -0,0 +1,689 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// DefaultTableName is the name of the table that records applied migrations
+const DefaultTableName = "schema_migrations"
+
+// DefaultLockTimeout is how long a migrator waits for another process to
+// release the migration lock
+const DefaultLockTimeout = 5 * time.Minute
+
+// Migrator applies migrations to a database and records them in a version table.
+// Each migration is applied by inspecting the current schema, diffing it against
+// the migration's target schema and executing the generated SQL. When the
+// dialect implements dbx.Locker, Up, UpTo, Down, DownTo and Redo hold a lock
+// named after the version table so that concurrent processes run one at a time.
+type Migrator struct {
+	db          *sql.DB
+	dialect     dbx.Dialect
+	migrations  []*Migration
+	tableName   string
+	lockTimeout time.Duration
+	diffOptions []schema.DiffOption
+
+	timeouts       Timeouts
+	changeTimeouts map[schema.ChangeType]Timeouts
+	lockRetries    int
+	retryBackoff   time.Duration
+
+	checkHazards   bool
+	allowedHazards []schema.HazardType
+}
+
+// Timeouts limits how long each statement of a migration waits for locks and
+// how long it runs. A zero timeout keeps the default of the database.
+type Timeouts struct {
+	Lock      time.Duration
+	Statement time.Duration
+}
+
+// MigratorOption configures a Migrator
+type MigratorOption func(*Migrator)
+
+// WithTableName sets the name of the table that records applied migrations
+func WithTableName(name string) MigratorOption {
+	return func(m *Migrator) {
+		m.tableName = name
+	}
+}
+
+// WithLockTimeout sets how long to wait for the migration lock, a timeout of
+// zero or less waits until the context is done
+func WithLockTimeout(timeout time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithDiffOptions sets the options passed to schema.Diff when a migration is
+// applied, such as schema.WithDeferredValidation
+func WithDiffOptions(options ...schema.DiffOption) MigratorOption {
+	return func(m *Migrator) {
+		m.diffOptions = options
+	}
+}
+
+// WithTimeouts sets the lock and statement timeouts of the statements that
+// apply migrations, when the dialect implements dbx.Timeouter
+func WithTimeouts(timeouts Timeouts) MigratorOption {
+	return func(m *Migrator) {
+		m.timeouts = timeouts
+	}
+}
+
+// WithChangeTimeouts sets the lock and statement timeouts of the statements
+// for one type of change, such as a longer statement timeout for AddIndex
+func WithChangeTimeouts(changeType schema.ChangeType, timeouts Timeouts) MigratorOption {
+	return func(m *Migrator) {
+		if m.changeTimeouts == nil {
+			m.changeTimeouts = map[schema.ChangeType]Timeouts{}
+		}
+		m.changeTimeouts[changeType] = timeouts
+	}
+}
+
+// WithLockRetry retries a migration that failed because a statement timed out
+// waiting for a lock up to attempts times. The first retry waits backoff, and
+// the wait doubles after every attempt. The migration is diffed again before
+// each retry, so the changes applied outside a transaction are not repeated.
+func WithLockRetry(attempts int, backoff time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		m.lockRetries = attempts
+		m.retryBackoff = backoff
+	}
+}
+
+// WithAllowedHazards makes the migrator refuse to apply a migration whose
+// changes have hazards of other types, such as schema.HazardDataLoss. The
+// error is a *schema.HazardError and nothing is executed.
+func WithAllowedHazards(hazards ...schema.HazardType) MigratorOption {
+	return func(m *Migrator) {
+		m.checkHazards = true
+		m.allowedHazards = hazards
+	}
+}
+
+// NewMigrator creates a migrator for the given migrations, which are applied in version order
+func NewMigrator(db *sql.DB, dialect dbx.Dialect, migrations []*Migration, options ...MigratorOption) *Migrator {
+	sorted := make([]*Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	m := &Migrator{
+		db:          db,
+		dialect:     dialect,
+		migrations:  sorted,
+		tableName:   DefaultTableName,
+		lockTimeout: DefaultLockTimeout,
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
+}
+
+// MigrationStatus describes a migration and whether it has been applied
+type MigrationStatus struct {
+	Version       string
+	Name          string
+	Applied       bool
+	AppliedAt     time.Time
+	Checksum      string
+	ExecutionTime time.Duration
+	// Modified reports that the migration's Up schema changed after it was applied
+	Modified bool
+}
+
+// appliedMigration is a row of the version table
+type appliedMigration struct {
+	Version       string
+	Name          string
+	AppliedAt     time.Time
+	Checksum      string
+	ExecutionTime time.Duration
+}
+
+// Status returns the status of every migration known to the migrator
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := &MigrationStatus{
+			Version: mig.Version,
+			Name:    mig.Name,
+		}
+		if record, ok := applied[mig.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			status.Checksum = record.Checksum
+			status.ExecutionTime = record.ExecutionTime
+			status.Modified = record.Checksum != mig.Checksum()
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// DetectDrift reports how the database differs from the Up schema of the
+// latest applied migration, ignoring the version table
+func (m *Migrator) DetectDrift(ctx context.Context) (*schema.DriftReport, error) {
+	mig, err := m.latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expected := schema.NewSchema()
+	if mig != nil {
+		expected = mig.Up()
+	}
+
+	actual, err := m.dialect.InspectContext(ctx, m.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect database: %w", err)
+	}
+	actual.Tables = m.withoutVersionTable(actual.Tables)
+	return schema.Drift(expected, actual), nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.up(ctx, mig); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// UpTo applies pending migrations up to and including the given version
+func (m *Migrator) UpTo(ctx context.Context, version string) error {
+	if m.find(version) == nil {
+		return fmt.Errorf("migration %s not found", version)
+	}
+
+	return m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.up(ctx, mig); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		mig, err := m.latest(ctx)
+		if err != nil || mig == nil {
+			return err
+		}
+		return m.down(ctx, mig)
+	})
+}
+
+// DownTo rolls back every applied migration newer than the given version.
+// An empty version rolls back all applied migrations.
+func (m *Migrator) DownTo(ctx context.Context, version string) error {
+	if version != "" && m.find(version) == nil {
+		return fmt.Errorf("migration %s not found", version)
+	}
+
+	return m.withLock(ctx, func() error {
+		for {
+			mig, err := m.latest(ctx)
+			if err != nil {
+				return err
+			}
+			if mig == nil || mig.Version <= version {
+				return nil
+			}
+			if err := m.down(ctx, mig); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// Redo rolls back the most recently applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		mig, err := m.latest(ctx)
+		if err != nil || mig == nil {
+			return err
+		}
+		if err := m.down(ctx, mig); err != nil {
+			return err
+		}
+		return m.up(ctx, mig)
+	})
+}
+
+// withLock runs fn while holding the migration lock. The lock is released
+// when fn returns, fails or panics.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) (err error) {
+	locker, ok := m.dialect.(dbx.Locker)
+	if !ok {
+		return fn()
+	}
+
+	unlock, err := locker.Lock(ctx, m.db, m.tableName, m.lockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	return fn()
+}
+
+// up migrates the database to the migration's Up schema and records it
+func (m *Migrator) up(ctx context.Context, mig *Migration) error {
+	err := m.migrate(ctx, mig.Up(), m.diffOptions, func(db dbx.Querier, elapsed time.Duration) error {
+		query := fmt.Sprintf("INSERT INTO %s (version, name, applied_at, checksum, execution_time_ms) VALUES (%s)",
+			m.tableName, m.placeholders(5))
+		_, err := db.ExecContext(ctx, query, mig.Version, mig.Name, time.Now().UTC(), mig.Checksum(), elapsed.Milliseconds())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", mig.FullVersion(), err)
+	}
+	return nil
+}
+
+// down migrates the database to the migration's Down schema and removes its record
+func (m *Migrator) down(ctx context.Context, mig *Migration) error {
+	target, options := mig.Down(), m.diffOptions
+	if mig.DownFn == nil {
+		// Without a Down schema, the migration is reversed to the schema of
+		// the previous migration
+		target = schema.ReverseSchema(m.previous(mig), mig.Up())
+		options = append(slices.Clip(options), schema.WithReversal())
+	}
+
+	err := m.migrate(ctx, target, options, func(db dbx.Querier, elapsed time.Duration) error {
+		query := fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.tableName, m.dialect.Placeholder(1))
+		_, err := db.ExecContext(ctx, query, mig.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration %s: %w", mig.FullVersion(), err)
+	}
+	return nil
+}
+
+// migrate applies the changes between the current and target schema, and
+// retries when a statement timed out waiting for a lock and WithLockRetry is set
+func (m *Migrator) migrate(ctx context.Context, target *schema.Schema, options []schema.DiffOption, record func(db dbx.Querier, elapsed time.Duration) error) error {
+	backoff := m.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := m.migrateOnce(ctx, target, options, record)
+		timeouter, ok := m.dialect.(dbx.Timeouter)
+		if err == nil || attempt >= m.lockRetries || !ok || !timeouter.IsLockTimeout(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// migrateOnce applies the changes between the current and target schema in a
+// transaction, then calls record with the same transaction before committing.
+// When the dialect reports that one of the changes cannot run in a transaction,
+// such as an index created concurrently, the changes and the record are
+// executed without one, and a failure leaves the changes applied before it.
+func (m *Migrator) migrateOnce(ctx context.Context, target *schema.Schema, options []schema.DiffOption, record func(db dbx.Querier, elapsed time.Duration) error) (err error) {
+	start := time.Now()
+
+	// Session timeouts are set on a dedicated connection and reset before it
+	// goes back to the pool
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer func() {
+		if resetErr := m.resetTimeouts(conn); resetErr != nil && err == nil {
+			err = resetErr
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := m.dialect.InspectContext(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to inspect database: %w", err)
+	}
+	current.Tables = m.withoutVersionTable(current.Tables)
+	changes := schema.Diff(current, target, options...)
+	dbx.ClassifyHazards(m.dialect, changes)
+	if m.checkHazards {
+		if err := schema.CheckHazards(changes, m.allowedHazards...); err != nil {
+			return err
+		}
+	}
+
+	outside := m.outsideTransaction(changes)
+	preparer, prepare := m.dialect.(dbx.ConnPreparer)
+	if outside || prepare {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+	}
+
+	check := func(context.Context, dbx.Querier) error { return nil }
+	if prepare {
+		// The settings cannot change inside a transaction, so the changes run
+		// in a new one once conn is prepared
+		var restore func() error
+		check, restore, err = preparer.PrepareConn(ctx, conn, changes)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if restoreErr := restore(); restoreErr != nil && err == nil {
+				err = restoreErr
+			}
+		}()
+	}
+
+	if outside {
+		return m.apply(ctx, conn, changes, start, check, record)
+	}
+
+	if prepare {
+		tx, err = conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+	if err := m.apply(ctx, tx, changes, start, check, record); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// apply executes the SQL for changes, verifies them with check and records the
+// migration on db
+func (m *Migrator) apply(ctx context.Context, db dbx.Querier, changes []schema.Change, start time.Time, check func(ctx context.Context, db dbx.Querier) error, record func(db dbx.Querier, elapsed time.Duration) error) error {
+	// The session starts with the default timeouts, which zero timeouts keep
+	var current Timeouts
+	for _, change := range changes {
+		stmt, err := m.dialect.GenerateSQL(change)
+		if err != nil {
+			return fmt.Errorf("failed to generate SQL for %s: %w", change.Type(), err)
+		}
+		if stmt == "" {
+			continue
+		}
+		if timeouts, ok := m.timeoutsFor(change); ok && current != timeouts {
+			if err := m.setTimeouts(ctx, db, timeouts); err != nil {
+				return err
+			}
+			current = timeouts
+		}
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to execute %q: %w", stmt, err)
+		}
+	}
+
+	if err := check(ctx, db); err != nil {
+		return err
+	}
+	if err := record(db, time.Since(start)); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return nil
+}
+
+// timeoutsFor returns the timeouts of the statements for a change, and false
+// when no timeouts are configured or the dialect cannot set them
+func (m *Migrator) timeoutsFor(change schema.Change) (Timeouts, bool) {
+	if _, ok := m.dialect.(dbx.Timeouter); !ok {
+		return Timeouts{}, false
+	}
+	if timeouts, ok := m.changeTimeouts[change.Type()]; ok {
+		return timeouts, true
+	}
+	return m.timeouts, m.timeouts != Timeouts{} || len(m.changeTimeouts) > 0
+}
+
+// setTimeouts sets the session timeouts on db
+func (m *Migrator) setTimeouts(ctx context.Context, db dbx.Querier, timeouts Timeouts) error {
+	for _, stmt := range m.dialect.(dbx.Timeouter).SetTimeoutsSQL(timeouts.Lock, timeouts.Statement) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to set timeouts with %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// resetTimeouts restores the default session timeouts of conn if any were
+// configured, even when the migration's context is done
+func (m *Migrator) resetTimeouts(conn *sql.Conn) error {
+	timeouter, ok := m.dialect.(dbx.Timeouter)
+	if !ok || (m.timeouts == Timeouts{} && len(m.changeTimeouts) == 0) {
+		return nil
+	}
+	for _, stmt := range timeouter.ResetTimeoutsSQL() {
+		if _, err := conn.ExecContext(context.Background(), stmt); err != nil {
+			return fmt.Errorf("failed to reset timeouts with %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// outsideTransaction reports whether any of the changes must run outside a transaction
+func (m *Migrator) outsideTransaction(changes []schema.Change) bool {
+	nonTx, ok := m.dialect.(dbx.NonTransactional)
+	if !ok {
+		return false
+	}
+	for _, change := range changes {
+		if nonTx.OutsideTransaction(change) {
+			return true
+		}
+	}
+	return false
+}
+
+// applied creates the version table if needed and returns the applied migrations by version
+func (m *Migrator) applied(ctx context.Context) (map[string]*appliedMigration, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT version, name, applied_at, checksum, execution_time_ms FROM %s", m.tableName)
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]*appliedMigration{}
+	for rows.Next() {
+		var (
+			record    appliedMigration
+			appliedAt interface{}
+			elapsedMS int64
+		)
+		if err := rows.Scan(&record.Version, &record.Name, &appliedAt, &record.Checksum, &elapsedMS); err != nil {
+			return nil, err
+		}
+		record.AppliedAt, err = parseTimestamp(appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse applied_at of migration %s: %w", record.Version, err)
+		}
+		record.ExecutionTime = time.Duration(elapsedMS) * time.Millisecond
+		applied[record.Version] = &record
+	}
+
+	return applied, rows.Err()
+}
+
+// latest returns the most recently applied migration, or nil if none is applied
+func (m *Migrator) latest(ctx context.Context) (*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var version string
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	if version == "" {
+		return nil, nil
+	}
+
+	mig := m.find(version)
+	if mig == nil {
+		return nil, fmt.Errorf("migration %s is applied but not defined", version)
+	}
+	return mig, nil
+}
+
+// find returns the migration with the given version
+func (m *Migrator) find(version string) *Migration {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig
+		}
+	}
+	return nil
+}
+
+// previous returns the Up schema of the migration before mig, or an empty
+// schema for the first migration
+func (m *Migrator) previous(mig *Migration) *schema.Schema {
+	i := slices.Index(m.migrations, mig)
+	if i <= 0 {
+		return schema.NewSchema()
+	}
+	return m.migrations[i-1].Up()
+}
+
+// ensureVersionTable creates the version table if it does not exist. The
+// table is looked up by querying it, since it is created wherever its
+// unqualified name resolves, which may be outside the schemas the dialect
+// inspects.
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s WHERE 1 = 0", m.tableName))
+	if err == nil {
+		return rows.Close()
+	}
+
+	stmt, err := m.dialect.GenerateSQL(schema.CreateTableChange{TableDef: m.versionTable()})
+	if err != nil {
+		return err
+	}
+	if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", m.tableName, err)
+	}
+	return nil
+}
+
+// versionTable returns the definition of the version table
+func (m *Migrator) versionTable() *schema.Table {
+	return schema.NewSchema().CreateTable(m.tableName, func(t *schema.Table) {
+		t.String("version")
+		t.String("name")
+		t.DateTime("applied_at")
+		t.String("checksum")
+		t.BigInt("execution_time_ms")
+		t.SetPrimaryKey(m.tableName+"_pkey", []string{"version"})
+	})
+}
+
+// withoutVersionTable removes the version table from a list of inspected tables
+func (m *Migrator) withoutVersionTable(tables []*schema.Table) []*schema.Table {
+	var result []*schema.Table
+	for _, table := range tables {
+		if table.Name != m.tableName {
+			result = append(result, table)
+		}
+	}
+	return result
+}
+
+// placeholders returns a comma separated list of n bind parameter placeholders
+func (m *Migrator) placeholders(n int) string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = m.dialect.Placeholder(i + 1)
+	}
+	return strings.Join(list, ", ")
+}
+
+// parseTimestamp converts a scanned timestamp into a time.Time, drivers that
+// do not parse timestamps (such as MySQL without parseTime=true) return text
+func parseTimestamp(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case []byte:
+		return time.Parse("2006-01-02 15:04:05", string(t))
+	case string:
+		return time.Parse("2006-01-02 15:04:05", t)
+	default:
+		return time.Time{}, fmt.Errorf("unexpected timestamp type %T", v)
+	}
+}
@@ -0,0 +1,547 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/postgresql"
+	"github.com/swiftcarrot/dbx/schema"
+	"github.com/swiftcarrot/dbx/sqlite"
+)
+
+func createUsersTable(s *schema.Schema) {
+	s.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &sqlite.IntegerType{})
+		t.Column("name", &sqlite.TextType{})
+		t.SetPrimaryKey("users_pkey", []string{"id"})
+	})
+}
+
+func createPostsTable(s *schema.Schema) {
+	s.CreateTable("posts", func(t *schema.Table) {
+		t.Column("id", &sqlite.IntegerType{})
+		t.Column("title", &sqlite.TextType{})
+		t.SetPrimaryKey("posts_pkey", []string{"id"})
+	})
+}
+
+func testMigrations() []*Migration {
+	return []*Migration{
+		NewMigration("20250102000000", "create_posts", func() *schema.Schema {
+			s := schema.NewSchema()
+			createUsersTable(s)
+			createPostsTable(s)
+			return s
+		}, func() *schema.Schema {
+			s := schema.NewSchema()
+			createUsersTable(s)
+			return s
+		}),
+		NewMigration("20250101000000", "create_users", func() *schema.Schema {
+			s := schema.NewSchema()
+			createUsersTable(s)
+			return s
+		}, func() *schema.Schema {
+			return schema.NewSchema()
+		}),
+	}
+}
+
+func getTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrator.db"))
+	require.NoError(t, err)
+	return db
+}
+
+func getTables(t *testing.T, db *sql.DB) []string {
+	tables, err := sqlite.New().InspectTables(db)
+	require.NoError(t, err)
+	return tables
+}
+
+func getStatus(t *testing.T, m *Migrator) []*MigrationStatus {
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	for _, status := range statuses {
+		require.Equal(t, status.Applied, !status.AppliedAt.IsZero())
+		status.AppliedAt = time.Time{}
+		status.ExecutionTime = 0
+	}
+	return statuses
+}
+
+func TestMigratorUp(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	m := NewMigrator(db, sqlite.New(), migrations)
+
+	require.NoError(t, m.Up(context.Background()))
+	require.Equal(t, []string{"posts", "schema_migrations", "users"}, getTables(t, db))
+	require.Equal(t, []*MigrationStatus{
+		{Version: "20250101000000", Name: "create_users", Applied: true, Checksum: migrations[1].Checksum()},
+		{Version: "20250102000000", Name: "create_posts", Applied: true, Checksum: migrations[0].Checksum()},
+	}, getStatus(t, m))
+
+	require.NoError(t, m.Up(context.Background()))
+	require.Equal(t, []string{"posts", "schema_migrations", "users"}, getTables(t, db))
+}
+
+func TestMigratorUpTo(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	m := NewMigrator(db, sqlite.New(), migrations)
+
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+	require.Equal(t, []string{"schema_migrations", "users"}, getTables(t, db))
+	require.Equal(t, []*MigrationStatus{
+		{Version: "20250101000000", Name: "create_users", Applied: true, Checksum: migrations[1].Checksum()},
+		{Version: "20250102000000", Name: "create_posts"},
+	}, getStatus(t, m))
+
+	require.EqualError(t, m.UpTo(context.Background(), "20250103000000"), "migration 20250103000000 not found")
+}
+
+func TestMigratorDown(t *testing.T) {
+	db := getTestDB(t)
+	m := NewMigrator(db, sqlite.New(), testMigrations())
+
+	require.NoError(t, m.Up(context.Background()))
+	require.NoError(t, m.Down(context.Background()))
+	require.Equal(t, []string{"schema_migrations", "users"}, getTables(t, db))
+
+	require.NoError(t, m.Down(context.Background()))
+	require.Equal(t, []string{"schema_migrations"}, getTables(t, db))
+
+	require.NoError(t, m.Down(context.Background()))
+}
+
+func TestMigratorDownTo(t *testing.T) {
+	db := getTestDB(t)
+	m := NewMigrator(db, sqlite.New(), testMigrations())
+
+	require.NoError(t, m.Up(context.Background()))
+	require.NoError(t, m.DownTo(context.Background(), "20250101000000"))
+	require.Equal(t, []string{"schema_migrations", "users"}, getTables(t, db))
+
+	require.NoError(t, m.DownTo(context.Background(), ""))
+	require.Equal(t, []string{"schema_migrations"}, getTables(t, db))
+}
+
+func TestMigratorRedo(t *testing.T) {
+	db := getTestDB(t)
+	m := NewMigrator(db, sqlite.New(), testMigrations())
+
+	require.NoError(t, m.Up(context.Background()))
+	_, err := db.Exec(`INSERT INTO posts (id, title) VALUES (1, 'hello')`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Redo(context.Background()))
+	require.Equal(t, []string{"posts", "schema_migrations", "users"}, getTables(t, db))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM posts`).Scan(&count))
+	require.Equal(t, 0, count)
+}
+
+func TestMigratorStatusModified(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	require.NoError(t, NewMigrator(db, sqlite.New(), migrations).Up(context.Background()))
+
+	checksum := migrations[1].Checksum()
+	migrations[1].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		s.Tables[0].Column("email", &sqlite.TextType{}, schema.Nullable)
+		return s
+	}
+
+	require.Equal(t, []*MigrationStatus{
+		{Version: "20250101000000", Name: "create_users", Applied: true, Checksum: checksum, Modified: true},
+		{Version: "20250102000000", Name: "create_posts", Applied: true, Checksum: migrations[0].Checksum()},
+	}, getStatus(t, NewMigrator(db, sqlite.New(), migrations)))
+}
+
+func TestMigratorDownReversed(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].DownFn = nil
+	migrations[1].DownFn = nil
+	m := NewMigrator(db, sqlite.New(), migrations)
+
+	require.NoError(t, m.Up(context.Background()))
+	require.NoError(t, m.Down(context.Background()))
+	require.Equal(t, []string{"schema_migrations", "users"}, getTables(t, db))
+	require.NoError(t, m.Down(context.Background()))
+	require.Equal(t, []string{"schema_migrations"}, getTables(t, db))
+}
+
+func TestMigratorDownReversedIrreversible(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		s.CreateTable("users", func(t *schema.Table) {
+			t.Column("id", &sqlite.IntegerType{})
+			t.SetPrimaryKey("users_pkey", []string{"id"})
+		})
+		return s
+	}
+	migrations[0].DownFn = nil
+	require.NoError(t, NewMigrator(db, sqlite.New(), migrations).Up(context.Background()))
+
+	m := NewMigrator(db, sqlite.New(), migrations, WithAllowedHazards())
+	err := m.Down(context.Background())
+	var hazardErr *schema.HazardError
+	require.ErrorAs(t, err, &hazardErr)
+	require.Len(t, hazardErr.Hazards, 1)
+	require.Equal(t, schema.Hazard{Type: schema.HazardIrreversible, Reason: "the values of column users.name are not restored"}, hazardErr.Hazards[0].Hazard)
+
+	m = NewMigrator(db, sqlite.New(), migrations, WithAllowedHazards(schema.HazardIrreversible))
+	require.NoError(t, m.Down(context.Background()))
+	s, err := sqlite.New().Inspect(db)
+	require.NoError(t, err)
+	require.Len(t, s.Tables, 2)
+	require.Equal(t, "users", s.Tables[1].Name)
+	require.Len(t, s.Tables[1].Columns, 2)
+	require.Equal(t, "name", s.Tables[1].Columns[1].Name)
+}
+
+func TestMigratorFailedMigrationRollsBack(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createPostsTable(s)
+		createUsersTable(s)
+		s.Tables[1].Column("email", &sqlite.TextType{})
+		return s
+	}
+	m := NewMigrator(db, sqlite.New(), migrations)
+
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+	_, err := db.Exec(`INSERT INTO users (id, name) VALUES (1, 'alice')`)
+	require.NoError(t, err)
+
+	require.Error(t, m.Up(context.Background()))
+	require.Equal(t, []string{"schema_migrations", "users"}, getTables(t, db))
+	require.Equal(t, []*MigrationStatus{
+		{Version: "20250101000000", Name: "create_users", Applied: true, Checksum: migrations[1].Checksum()},
+		{Version: "20250102000000", Name: "create_posts"},
+	}, getStatus(t, m))
+}
+
+func TestMigratorRebuildParentTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "migrator.db")+"?_foreign_keys=1")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		createPostsTable(s)
+		s.Tables[0].Check("users_name_check", "name <> ''")
+		s.Tables[1].Column("user_id", &sqlite.IntegerType{})
+		s.Tables[1].ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"}, schema.OnDelete("CASCADE"))
+		return s
+	}
+	migrations[1].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		createPostsTable(s)
+		s.Tables[1].Column("user_id", &sqlite.IntegerType{})
+		s.Tables[1].ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"}, schema.OnDelete("CASCADE"))
+		return s
+	}
+	m := NewMigrator(db, sqlite.New(), migrations)
+
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+	_, err = db.Exec(`INSERT INTO users (id, name) VALUES (1, 'alice'); INSERT INTO posts (id, title, user_id) VALUES (1, 'hello', 1)`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up(context.Background()))
+
+	var posts, enabled int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM posts`).Scan(&posts))
+	require.Equal(t, 1, posts)
+	require.NoError(t, db.QueryRow(`PRAGMA foreign_keys`).Scan(&enabled))
+	require.Equal(t, 1, enabled)
+}
+
+func TestMigratorLockTimeout(t *testing.T) {
+	db := getTestDB(t)
+	unlock, err := sqlite.New().Lock(context.Background(), db, DefaultTableName, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, unlock())
+	})
+
+	m := NewMigrator(db, sqlite.New(), testMigrations(), WithLockTimeout(10*time.Millisecond))
+	var timeoutErr *dbx.LockTimeoutError
+	require.ErrorAs(t, m.Up(context.Background()), &timeoutErr)
+	require.Empty(t, getTables(t, db))
+}
+
+func TestMigratorReleasesLockOnPanic(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[1].UpFn = func() *schema.Schema {
+		panic("broken migration")
+	}
+	m := NewMigrator(db, sqlite.New(), migrations)
+
+	require.PanicsWithValue(t, "broken migration", func() {
+		m.Up(context.Background())
+	})
+
+	unlock, err := sqlite.New().Lock(context.Background(), db, DefaultTableName, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
+
+// concurrentSQLite runs index changes outside a transaction, like PostgreSQL
+// with concurrent indexes
+type concurrentSQLite struct {
+	*sqlite.SQLite
+}
+
+func (s concurrentSQLite) OutsideTransaction(change schema.Change) bool {
+	_, ok := schema.Indirect(change).(schema.AddIndexChange)
+	return ok
+}
+
+func TestMigratorOutsideTransaction(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		s.Tables[0].Column("email", &sqlite.TextType{}, schema.Nullable)
+		s.Tables[0].Index("users_email_idx", []string{"email"})
+		return s
+	}
+	m := NewMigrator(db, concurrentSQLite{sqlite.New()}, migrations)
+
+	require.NoError(t, m.Up(context.Background()))
+	table := &schema.Table{Name: "users"}
+	require.NoError(t, sqlite.New().InspectIndexes(db, table))
+	require.Equal(t, []*schema.Index{{Name: "users_email_idx", Columns: []string{"email"}}}, table.Indexes)
+	require.Equal(t, []*MigrationStatus{
+		{Version: "20250101000000", Name: "create_users", Applied: true, Checksum: migrations[1].Checksum()},
+		{Version: "20250102000000", Name: "create_posts", Applied: true, Checksum: migrations[0].Checksum()},
+	}, getStatus(t, m))
+}
+
+func TestMigratorOutsideTransactionFailure(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		s.Tables[0].Column("email", &sqlite.TextType{}, schema.Nullable)
+		// Index names share a namespace with tables in SQLite
+		s.Tables[0].Index("users", []string{"email"})
+		return s
+	}
+	m := NewMigrator(db, concurrentSQLite{sqlite.New()}, migrations)
+
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+	require.Error(t, m.Up(context.Background()))
+
+	// Without a transaction the column added before the failing index is kept
+	table := &schema.Table{Name: "users"}
+	require.NoError(t, sqlite.New().InspectColumns(db, table))
+	require.Len(t, table.Columns, 3)
+	require.Equal(t, "email", table.Columns[2].Name)
+	require.False(t, getStatus(t, m)[1].Applied)
+}
+
+// timeoutSQLite records the timeouts set by the migrator, and treats SQLite's
+// busy errors as lock timeouts
+type timeoutSQLite struct {
+	*sqlite.SQLite
+	timeouts      []Timeouts
+	resets        int
+	onLockTimeout func()
+}
+
+func (s *timeoutSQLite) SetTimeoutsSQL(lockTimeout, statementTimeout time.Duration) []string {
+	s.timeouts = append(s.timeouts, Timeouts{Lock: lockTimeout, Statement: statementTimeout})
+	return []string{fmt.Sprintf("PRAGMA busy_timeout = %d", lockTimeout.Milliseconds())}
+}
+
+func (s *timeoutSQLite) ResetTimeoutsSQL() []string {
+	s.resets++
+	return []string{"PRAGMA busy_timeout = 5000"}
+}
+
+func (s *timeoutSQLite) IsLockTimeout(err error) bool {
+	if s.onLockTimeout != nil {
+		s.onLockTimeout()
+		s.onLockTimeout = nil
+	}
+	return strings.Contains(err.Error(), "database is locked")
+}
+
+func TestMigratorTimeouts(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		createPostsTable(s)
+		s.Tables[0].Column("email", &sqlite.TextType{}, schema.Nullable)
+		return s
+	}
+	dialect := &timeoutSQLite{SQLite: sqlite.New()}
+	m := NewMigrator(db, dialect, migrations,
+		WithTimeouts(Timeouts{Lock: 10 * time.Millisecond}),
+		WithChangeTimeouts(schema.CreateTable, Timeouts{Lock: 20 * time.Millisecond, Statement: time.Second}))
+
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+	require.Equal(t, []Timeouts{{Lock: 20 * time.Millisecond, Statement: time.Second}}, dialect.timeouts)
+	require.Equal(t, 1, dialect.resets)
+
+	dialect.timeouts = nil
+	require.NoError(t, m.Up(context.Background()))
+	require.Equal(t, []Timeouts{
+		{Lock: 10 * time.Millisecond},
+		{Lock: 20 * time.Millisecond, Statement: time.Second},
+	}, dialect.timeouts)
+	require.Equal(t, 2, dialect.resets)
+}
+
+func TestMigratorChangeTimeoutsOnly(t *testing.T) {
+	db := getTestDB(t)
+	migrations := testMigrations()
+	migrations[0].UpFn = func() *schema.Schema {
+		s := schema.NewSchema()
+		createUsersTable(s)
+		createPostsTable(s)
+		s.Tables[0].Column("email", &sqlite.TextType{}, schema.Nullable)
+		return s
+	}
+	dialect := &timeoutSQLite{SQLite: sqlite.New()}
+	m := NewMigrator(db, dialect, migrations,
+		WithChangeTimeouts(schema.AddColumn, Timeouts{Lock: 20 * time.Millisecond}))
+
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+	require.Empty(t, dialect.timeouts)
+
+	require.NoError(t, m.Up(context.Background()))
+	require.Equal(t, []Timeouts{{Lock: 20 * time.Millisecond}, {}}, dialect.timeouts)
+}
+
+func TestMigratorLockRetry(t *testing.T) {
+	db := getTestDB(t)
+	dialect := &timeoutSQLite{SQLite: sqlite.New()}
+	m := NewMigrator(db, dialect, testMigrations(),
+		WithTimeouts(Timeouts{Lock: 10 * time.Millisecond}),
+		WithLockRetry(2, time.Millisecond))
+	require.NoError(t, m.UpTo(context.Background(), "20250101000000"))
+
+	// Hold the write lock until the migrator reports the first lock timeout
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+	_, err = conn.ExecContext(context.Background(), "BEGIN IMMEDIATE")
+	require.NoError(t, err)
+	dialect.onLockTimeout = func() {
+		_, err := conn.ExecContext(context.Background(), "COMMIT")
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, m.Up(context.Background()))
+	require.Nil(t, dialect.onLockTimeout)
+	require.Equal(t, []string{"posts", "schema_migrations", "users"}, getTables(t, db))
+}
+
+func TestMigratorAllowedHazards(t *testing.T) {
+	db := getTestDB(t)
+	m := NewMigrator(db, sqlite.New(), testMigrations(), WithAllowedHazards(schema.HazardBreakingChange))
+	require.NoError(t, m.Up(context.Background()))
+
+	err := m.Down(context.Background())
+	var hazardErr *schema.HazardError
+	require.ErrorAs(t, err, &hazardErr)
+	require.Len(t, hazardErr.Hazards, 2)
+	require.Equal(t, schema.HazardDataLoss, hazardErr.Hazards[0].Hazard.Type)
+	require.Equal(t, schema.HazardIrreversible, hazardErr.Hazards[1].Hazard.Type)
+	require.Equal(t, []string{"posts", "schema_migrations", "users"}, getTables(t, db))
+
+	m = NewMigrator(db, sqlite.New(), testMigrations(),
+		WithAllowedHazards(schema.HazardDataLoss, schema.HazardIrreversible, schema.HazardBreakingChange))
+	require.NoError(t, m.Down(context.Background()))
+	require.Equal(t, []string{"schema_migrations", "users"}, getTables(t, db))
+}
+
+func TestMigratorDetectDrift(t *testing.T) {
+	db := getTestDB(t)
+	m := NewMigrator(db, sqlite.New(), testMigrations())
+
+	report, err := m.DetectDrift(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.HasDrift())
+
+	require.NoError(t, m.Up(context.Background()))
+	report, err = m.DetectDrift(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.HasDrift())
+
+	_, err = db.Exec("ALTER TABLE users ADD COLUMN nickname TEXT")
+	require.NoError(t, err)
+	_, err = db.Exec("DROP TABLE posts")
+	require.NoError(t, err)
+
+	report, err = m.DetectDrift(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []schema.DriftObject{{Kind: schema.ObjectTable, Name: "posts"}}, report.Missing)
+	require.Equal(t, []schema.DriftObject{{Kind: schema.ObjectColumn, Table: "users", Name: "nickname"}}, report.Extra)
+	require.Empty(t, report.Changed)
+}
+
+func TestMigratorPostgreSQLSchemas(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`CREATE SCHEMA IF NOT EXISTS test_migrator_app`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DROP SCHEMA IF EXISTS test_migrator_app CASCADE; DROP TABLE IF EXISTS test_migrator_versions`)
+		require.NoError(t, err)
+	})
+
+	migrations := []*Migration{
+		NewMigration("20250101000000", "create_users", func() *schema.Schema {
+			s := schema.NewSchema()
+			s.CreateTable("users", func(t *schema.Table) {
+				t.Schema = "test_migrator_app"
+				t.Column("id", &schema.IntegerType{})
+			})
+			return s
+		}, func() *schema.Schema {
+			return schema.NewSchema()
+		}),
+	}
+	pg := postgresql.New(postgresql.WithSchemas("test_migrator_app"))
+
+	m := NewMigrator(db, pg, migrations, WithTableName("test_migrator_versions"))
+	require.NoError(t, m.Up(context.Background()))
+
+	m = NewMigrator(db, pg, migrations, WithTableName("test_migrator_versions"))
+	require.NoError(t, m.Up(context.Background()))
+	require.Len(t, getStatus(t, m), 1)
+	require.True(t, getStatus(t, m)[0].Applied)
+}
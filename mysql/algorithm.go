@@ -0,0 +1,140 @@
+package mysql
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var _ dbx.HazardClassifier = (*MySQL)(nil)
+
+// Algorithm is the ALGORITHM clause of an ALTER TABLE statement, the way
+// InnoDB applies the change
+type Algorithm string
+
+const (
+	// AlgorithmInstant only changes the table metadata
+	AlgorithmInstant Algorithm = "INSTANT"
+	// AlgorithmInplace builds the change in place, usually allowing concurrent writes
+	AlgorithmInplace Algorithm = "INPLACE"
+	// AlgorithmCopy copies the table, blocking writes until it is done
+	AlgorithmCopy Algorithm = "COPY"
+)
+
+// LockMode is the LOCK clause of an ALTER TABLE statement, the concurrent
+// access allowed while the change is applied
+type LockMode string
+
+const (
+	LockNone      LockMode = "NONE"
+	LockShared    LockMode = "SHARED"
+	LockExclusive LockMode = "EXCLUSIVE"
+)
+
+// alterOptions returns the ALGORITHM and LOCK clauses set by WithAlgorithm and
+// WithLock, each preceded by sep
+func (my *MySQL) alterOptions(sep string) string {
+	var clauses string
+	if my.algorithm != "" {
+		clauses += sep + "ALGORITHM=" + string(my.algorithm)
+	}
+	if my.lock != "" {
+		clauses += sep + "LOCK=" + string(my.lock)
+	}
+	return clauses
+}
+
+// PredictAlgorithm returns the fastest algorithm InnoDB in MySQL 8.0.29 or
+// later supports for a change to an existing table, or "" for changes that do
+// not alter a table. A change predicted to need AlgorithmCopy blocks writes
+// to the table while it is copied.
+//
+// Column changes without changed attributes are predicted as AlgorithmCopy,
+// since the column type may have changed.
+func (my *MySQL) PredictAlgorithm(change schema.Change) Algorithm {
+	switch c := schema.Indirect(change).(type) {
+	case schema.AddColumnChange:
+		if c.Column.AutoIncrement {
+			return AlgorithmCopy
+		}
+		return AlgorithmInstant
+	case schema.DropColumnChange, schema.RenameColumnChange:
+		return AlgorithmInstant
+	case schema.AlterColumnChange:
+		return predictAlterColumn(c)
+	case schema.AddPrimaryKeyChange:
+		return AlgorithmInplace
+	case schema.DropPrimaryKeyChange:
+		return AlgorithmCopy
+	case schema.AddIndexChange, schema.DropIndexChange, schema.RenameIndexChange:
+		return AlgorithmInplace
+	case schema.AddUniqueConstraintChange, schema.DropUniqueConstraintChange:
+		return AlgorithmInplace
+	case schema.AddForeignKeyChange:
+		// INPLACE is only supported with foreign_key_checks disabled
+		return AlgorithmCopy
+	case schema.DropForeignKeyChange, schema.DropCheckChange:
+		return AlgorithmInplace
+	case schema.AddCheckChange:
+		return AlgorithmCopy
+	}
+	return ""
+}
+
+// ClassifyHazards records that a change applied with AlgorithmCopy, as
+// predicted by PredictAlgorithm or set by WithAlgorithm, rewrites the table
+// and blocks writes to it while it is copied
+func (my *MySQL) ClassifyHazards(change schema.Change) {
+	c, ok := change.(schema.HazardRecorder)
+	if !ok {
+		return
+	}
+	algorithm := my.PredictAlgorithm(change)
+	if algorithm != "" && my.algorithm != "" {
+		algorithm = my.algorithm
+	}
+	if algorithm != AlgorithmCopy {
+		return
+	}
+
+	table := schema.TableNameOf(change)
+	if !c.HasHazard(schema.HazardTableRewrite) {
+		c.AddHazard(schema.HazardTableRewrite, fmt.Sprintf("ALGORITHM=COPY copies every row of %s", table))
+	}
+	if !c.HasHazard(schema.HazardLongLock) {
+		c.AddHazard(schema.HazardLongLock, fmt.Sprintf("writes to %s are blocked while it is copied", table))
+	}
+}
+
+// predictAlterColumn returns the algorithm for the attributes changed by an
+// AlterColumnChange
+func predictAlterColumn(c schema.AlterColumnChange) Algorithm {
+	switch {
+	case c.Changed.Only("Default"):
+		return AlgorithmInstant
+	case c.Changed.Only("Type", "Default") && c.Source != nil && isAppendedValues(c.Source.Type, c.Column.Type):
+		return AlgorithmInstant
+	case c.Changed.Only("Nullable", "Default", "Comment"):
+		return AlgorithmInplace
+	}
+	return AlgorithmCopy
+}
+
+// isAppendedValues reports whether target is an ENUM or SET type with the
+// values of source followed by new values
+func isAppendedValues(source, target schema.ColumnType) bool {
+	sourceType, ok := source.(schema.EnumeratedType)
+	if !ok {
+		return false
+	}
+	targetType, ok := target.(schema.EnumeratedType)
+	if !ok || reflect.TypeOf(sourceType) != reflect.TypeOf(targetType) {
+		return false
+	}
+	sourceValues := sourceType.EnumValues()
+	targetValues := targetType.EnumValues()
+	return len(targetValues) > len(sourceValues) && slices.Equal(sourceValues, targetValues[:len(sourceValues)])
+}
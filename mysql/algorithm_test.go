@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestPredictAlgorithm(t *testing.T) {
+	my := New()
+	column := &schema.Column{Name: "email", Type: &schema.VarcharType{Length: 255}}
+
+	require.Equal(t, AlgorithmInstant, my.PredictAlgorithm(&schema.AddColumnChange{TableName: "users", Column: column}))
+	require.Equal(t, AlgorithmInstant, my.PredictAlgorithm(schema.DropColumnChange{TableName: "users", ColumnName: "email"}))
+	require.Equal(t, AlgorithmCopy, my.PredictAlgorithm(&schema.AlterColumnChange{TableName: "users", Column: column}))
+	require.Equal(t, AlgorithmInplace, my.PredictAlgorithm(&schema.AddIndexChange{
+		TableName: "users",
+		Index:     &schema.Index{Name: "idx_users_email", Columns: []string{"email"}},
+	}))
+	require.Equal(t, AlgorithmCopy, my.PredictAlgorithm(&schema.AddForeignKeyChange{
+		TableName:  "posts",
+		ForeignKey: &schema.ForeignKey{Name: "fk_posts_user", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}},
+	}))
+	require.Equal(t, AlgorithmCopy, my.PredictAlgorithm(&schema.AddColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "seq", Type: &IntType{}, AutoIncrement: true},
+	}))
+	require.Equal(t, Algorithm(""), my.PredictAlgorithm(&schema.CreateTableChange{TableDef: &schema.Table{Name: "users"}}))
+}
+
+func TestPredictAlgorithmAlterColumn(t *testing.T) {
+	my := New()
+	source := &schema.Column{Name: "status", Type: &ENUMType{Values: []string{"active", "inactive"}}}
+
+	require.Equal(t, AlgorithmInstant, my.PredictAlgorithm(&schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: source.Type, Default: "'active'"},
+		Source:    source,
+		Changed:   schema.Attributes{"Default"},
+	}))
+	require.Equal(t, AlgorithmInstant, my.PredictAlgorithm(&schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: &ENUMType{Values: []string{"active", "inactive", "banned"}}},
+		Source:    source,
+		Changed:   schema.Attributes{"Type"},
+	}))
+	require.Equal(t, AlgorithmCopy, my.PredictAlgorithm(&schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: &ENUMType{Values: []string{"banned", "active", "inactive"}}},
+		Source:    source,
+		Changed:   schema.Attributes{"Type"},
+	}))
+	require.Equal(t, AlgorithmInplace, my.PredictAlgorithm(&schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: source.Type, Nullable: true},
+		Source:    source,
+		Changed:   schema.Attributes{"Nullable"},
+	}))
+}
+
+func TestClassifyHazards(t *testing.T) {
+	source := schema.NewSchema()
+	source.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntType{})
+		t.Column("age", &IntType{})
+	})
+	target := schema.NewSchema()
+	target.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntType{})
+		t.Column("age", &IntType{})
+		t.Column("bio", &schema.TextType{}, schema.Nullable)
+		t.Check("users_age_check", "age > 0")
+	})
+
+	plan, err := dbx.NewPlan(New(), source, target)
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 2)
+	require.Empty(t, plan.Steps[0].Change.Hazards())
+	require.Equal(t, []schema.Hazard{
+		{Type: schema.HazardLongLock, Reason: "check constraint users_age_check checks the existing rows of users while holding a lock"},
+		{Type: schema.HazardTableRewrite, Reason: "ALGORITHM=COPY copies every row of users"},
+	}, plan.Steps[1].Change.Hazards())
+
+	plan, err = dbx.NewPlan(New(WithAlgorithm(AlgorithmCopy)), source, target)
+	require.NoError(t, err)
+	require.Equal(t, []schema.Hazard{
+		{Type: schema.HazardTableRewrite, Reason: "ALGORITHM=COPY copies every row of users"},
+		{Type: schema.HazardLongLock, Reason: "writes to users are blocked while it is copied"},
+	}, plan.Steps[0].Change.Hazards())
+}
@@ -1,5 +1,28 @@
 package mysql
 
+import (
+	"strings"
+
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var (
+	_ schema.EnumeratedType = (*ENUMType)(nil)
+	_ schema.EnumeratedType = (*SetType)(nil)
+)
+
+func init() {
+	schema.RegisterColumnType("mysql.int", &IntType{})
+	schema.RegisterColumnType("mysql.json", &JSONType{})
+	schema.RegisterColumnType("mysql.enum", &ENUMType{})
+	schema.RegisterColumnType("mysql.set", &SetType{})
+	schema.RegisterColumnType("mysql.tinyint", &TinyIntType{})
+	schema.RegisterColumnType("mysql.mediumint", &MediumIntType{})
+	schema.RegisterColumnType("mysql.tinytext", &TinyTextType{})
+	schema.RegisterColumnType("mysql.mediumtext", &MediumTextType{})
+	schema.RegisterColumnType("mysql.longtext", &LongTextType{})
+}
+
 // IntType represents an INT column type in MySQL (instead of INTEGER)
 type IntType struct{}
 
@@ -20,7 +43,12 @@ type ENUMType struct {
 }
 
 func (t *ENUMType) SQL() string {
-	return "enum"
+	return "enum(" + quoteValues(t.Values) + ")"
+}
+
+// EnumValues returns the values the column accepts
+func (t *ENUMType) EnumValues() []string {
+	return t.Values
 }
 
 // SetType represents a SET column type in MySQL
@@ -29,7 +57,12 @@ type SetType struct {
 }
 
 func (t *SetType) SQL() string {
-	return "set"
+	return "set(" + quoteValues(t.Values) + ")"
+}
+
+// EnumValues returns the values the column accepts
+func (t *SetType) EnumValues() []string {
+	return t.Values
 }
 
 // TinyIntType represents a TINYINT column type in MySQL
@@ -66,3 +99,45 @@ type LongTextType struct{}
 func (t *LongTextType) SQL() string {
 	return "longtext"
 }
+
+// quoteValues quotes the values of an ENUM or SET type as a comma separated list
+func quoteValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteLiteral(strings.ReplaceAll(value, `\`, `\\`))
+	}
+	return strings.Join(quoted, ",")
+}
+
+// parseValues parses the values of an ENUM or SET column type as reported in
+// information_schema.columns.column_type, e.g. enum('a','b')
+func parseValues(columnType string) []string {
+	start := strings.Index(columnType, "(")
+	end := strings.LastIndex(columnType, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+
+	values := []string{}
+	list := columnType[start+1 : end]
+	for i := 0; i < len(list); i++ {
+		if list[i] != '\'' {
+			continue
+		}
+		var value strings.Builder
+		for i++; i < len(list); i++ {
+			if list[i] == '\\' && i+1 < len(list) {
+				i++
+			} else if list[i] == '\'' {
+				if i+1 < len(list) && list[i+1] == '\'' {
+					i++
+				} else {
+					break
+				}
+			}
+			value.WriteByte(list[i])
+		}
+		values = append(values, value.String())
+	}
+	return values
+}
@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestColumnTypesRoundTrip(t *testing.T) {
+	s := schema.NewSchema()
+	s.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntType{}, func(c *schema.Column) { c.AutoIncrement = true })
+		t.Column("status", &ENUMType{Values: []string{"active", "inactive"}}, schema.Default("'active'"))
+		t.Column("roles", &SetType{Values: []string{"admin", "editor"}})
+		t.Column("bio", &LongTextType{})
+	})
+
+	data, err := schema.Marshal(s, schema.FormatJSON)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"type": {
+            "kind": "mysql.enum",
+            "values": [
+              "active",
+              "inactive"
+            ]
+          }`)
+
+	decoded, err := schema.Unmarshal(data, schema.FormatJSON)
+	require.NoError(t, err)
+	require.Equal(t, s, decoded)
+}
@@ -9,7 +9,16 @@ import (
 
 // ConvertDataTypeToColumnType converts a MySQL data type string to a proper ColumnType
 func ConvertDataTypeToColumnType(dataType string) schema.ColumnType {
-	dataType = strings.ToLower(strings.TrimSpace(dataType))
+	dataType = strings.TrimSpace(dataType)
+
+	// ENUM and SET values keep their case
+	if lower := strings.ToLower(dataType); strings.HasPrefix(lower, "enum(") {
+		return &ENUMType{Values: parseValues(dataType)}
+	} else if strings.HasPrefix(lower, "set(") {
+		return &SetType{Values: parseValues(dataType)}
+	}
+
+	dataType = strings.ToLower(dataType)
 
 	// Check for precision/scale in types like numeric(10,2)
 	if strings.Contains(dataType, "(") {
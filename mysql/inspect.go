@@ -1,26 +1,72 @@
 package mysql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
-// MySQL represents the MySQL dialect
-type MySQL struct{}
+func init() {
+	dbx.Register("mysql", New())
+}
+
+// MySQL implements the Dialect interface for MySQL databases
+type MySQL struct {
+	algorithm Algorithm      // ALGORITHM clause of generated ALTER TABLE statements
+	lock      LockMode       // LOCK clause of generated ALTER TABLE statements
+	filter    *schema.Filter // Objects Inspect keeps
+}
+
+// Option configures a MySQL dialect
+type Option func(*MySQL)
+
+// WithAlgorithm appends ALGORITHM=algorithm to the ALTER TABLE, CREATE INDEX
+// and DROP INDEX statements that GenerateSQL emits for changes to existing
+// tables. MySQL then fails a statement it cannot run with that algorithm
+// instead of falling back to one that blocks writes.
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(my *MySQL) {
+		my.algorithm = algorithm
+	}
+}
+
+// WithLock appends LOCK=lock to the same statements as WithAlgorithm
+func WithLock(lock LockMode) Option {
+	return func(my *MySQL) {
+		my.lock = lock
+	}
+}
+
+// WithFilter makes Inspect leave out the objects that filter does not keep,
+// such as tables owned by other tools
+func WithFilter(filter *schema.Filter) Option {
+	return func(my *MySQL) {
+		my.filter = filter
+	}
+}
 
 // New creates a new MySQL inspector
-func New() *MySQL {
-	return &MySQL{}
+func New(options ...Option) *MySQL {
+	my := &MySQL{}
+	for _, option := range options {
+		option(my)
+	}
+	return my
 }
 
 // Inspect inspects the database and returns a schema
-func (my *MySQL) Inspect(db *sql.DB) (*schema.Schema, error) {
+func (my *MySQL) Inspect(db dbx.Querier) (*schema.Schema, error) {
+	return my.InspectContext(context.Background(), db)
+}
+
+// InspectContext inspects the database and returns a schema, using ctx for every catalog query
+func (my *MySQL) InspectContext(ctx context.Context, db dbx.Querier) (*schema.Schema, error) {
 	s := schema.NewSchema()
 
 	// Get tables
-	tables, err := my.InspectTables(db)
+	tables, err := my.InspectTablesContext(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("error inspecting tables: %w", err)
 	}
@@ -28,39 +74,44 @@ func (my *MySQL) Inspect(db *sql.DB) (*schema.Schema, error) {
 		table := s.CreateTable(tableName, nil)
 
 		// Get columns
-		if err := my.InspectColumns(db, table); err != nil {
+		if err := my.InspectColumnsContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get columns for table %s: %w", tableName, err)
 		}
 
 		// Get primary key
-		if err := my.InspectPrimaryKey(db, table); err != nil {
+		if err := my.InspectPrimaryKeyContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get primary key for table %s: %w", tableName, err)
 		}
 
 		// Get indexes
-		if err := my.InspectIndexes(db, table); err != nil {
+		if err := my.InspectIndexesContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get indexes for table %s: %w", tableName, err)
 		}
 
 		// Get foreign keys
-		if err := my.InspectForeignKeys(db, table); err != nil {
+		if err := my.InspectForeignKeysContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get foreign keys for table %s: %w", tableName, err)
 		}
+
+		// Get check constraints
+		if err := my.InspectChecksContext(ctx, db, table); err != nil {
+			return nil, fmt.Errorf("failed to get check constraints for table %s: %w", tableName, err)
+		}
 	}
 
 	// Get views
-	if err := my.InspectViews(db, s); err != nil {
+	if err := my.InspectViewsContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("error inspecting views: %w", err)
 	}
 
 	// Get functions
-	if err := my.InspectFunctions(db, s); err != nil {
+	if err := my.InspectFunctionsContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("error inspecting functions: %w", err)
 	}
 
-	if err := my.InspectTriggers(db, s); err != nil {
+	if err := my.InspectTriggersContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("error inspecting triggers: %w", err)
 	}
 
-	return s, nil
+	return my.filter.Apply(s), nil
 }
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// InspectChecks inspects the check constraints of a table
+func (my *MySQL) InspectChecks(db dbx.Querier, table *schema.Table) error {
+	return my.InspectChecksContext(context.Background(), db, table)
+}
+
+// InspectChecksContext inspects the check constraints of a table, using ctx for the catalog queries.
+// Check constraints are only reported by MySQL 8.0.16 and later.
+func (my *MySQL) InspectChecksContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	query := `
+		SELECT
+			tc.constraint_name,
+			cc.check_clause
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.check_constraints cc
+			ON cc.constraint_schema = tc.constraint_schema
+			AND cc.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = DATABASE()
+		AND tc.table_name = ?
+		AND tc.constraint_type = 'CHECK'
+		ORDER BY tc.constraint_name
+	`
+
+	rows, err := db.QueryContext(ctx, query, table.Name)
+	if err != nil {
+		return fmt.Errorf("error getting check constraints: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, clause string
+		if err := rows.Scan(&name, &clause); err != nil {
+			return err
+		}
+		clause = strings.TrimSpace(clause)
+		if strings.HasPrefix(clause, "(") && strings.HasSuffix(clause, ")") {
+			clause = clause[1 : len(clause)-1]
+		}
+		table.Check(name, clause)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestInspectChecks(t *testing.T) {
+	db, err := testutil.GetMySQLTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE test_check_products (
+			id INT PRIMARY KEY,
+			price INT NOT NULL,
+			CONSTRAINT products_price_check CHECK (price > 0)
+		);
+	`)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS test_check_products;`)
+		require.NoError(t, err)
+	})
+
+	my := New()
+	table := &schema.Table{
+		Name: "test_check_products",
+	}
+	require.NoError(t, my.InspectChecks(db, table))
+	require.Equal(t, []*schema.CheckConstraint{
+		{Name: "products_price_check", Expression: "`price` > 0"},
+	}, table.Checks)
+}
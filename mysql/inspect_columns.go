@@ -1,15 +1,22 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectColumns inspects columns for a table
-func (my *MySQL) InspectColumns(db *sql.DB, table *schema.Table) error {
+func (my *MySQL) InspectColumns(db dbx.Querier, table *schema.Table) error {
+	return my.InspectColumnsContext(context.Background(), db, table)
+}
+
+// InspectColumnsContext inspects columns for a table, using ctx for the catalog queries
+func (my *MySQL) InspectColumnsContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := `
 		SELECT
 			column_name,
@@ -30,7 +37,7 @@ func (my *MySQL) InspectColumns(db *sql.DB, table *schema.Table) error {
 			ordinal_position;
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, table.Name)
 	if err != nil {
 		return err
 	}
@@ -68,49 +75,7 @@ func (my *MySQL) InspectColumns(db *sql.DB, table *schema.Table) error {
 			Name: name,
 		}
 
-		// Handle specific types based on dataType
-		switch strings.ToLower(dataType) {
-		case "varchar", "char", "binary", "varbinary":
-			var length int
-			if charMaxLength.Valid {
-				length = int(charMaxLength.Int64)
-			}
-			column.Type = &schema.VarcharType{
-				Length: length,
-			}
-		case "text", "mediumtext", "longtext", "tinytext":
-			column.Type = &schema.TextType{}
-		case "decimal", "numeric":
-			precision := 0
-			scale := 0
-			if numericPrecision.Valid {
-				precision = int(numericPrecision.Int64)
-			}
-			if numericScale.Valid {
-				scale = int(numericScale.Int64)
-			}
-			column.Type = &schema.DecimalType{
-				Precision: precision,
-				Scale:     scale,
-			}
-		case "int", "integer", "tinyint", "smallint", "mediumint":
-			column.Type = &schema.IntegerType{}
-		case "bigint":
-			column.Type = &schema.BigIntType{}
-		case "float", "double":
-			column.Type = &schema.FloatType{}
-		case "boolean", "bool":
-			column.Type = &schema.BooleanType{}
-		case "date":
-			column.Type = &schema.DateType{}
-		case "time":
-			column.Type = &schema.TimeType{}
-		case "timestamp", "datetime":
-			column.Type = &schema.TimestampType{}
-		default:
-			// Default to text type
-			column.Type = &schema.TextType{}
-		}
+		column.Type = convertColumnType(dataType, columnType, charMaxLength, numericPrecision, numericScale)
 
 		// Set nullable
 		column.Nullable = isNullable == "YES"
@@ -143,3 +108,54 @@ func (my *MySQL) InspectColumns(db *sql.DB, table *schema.Table) error {
 
 	return nil
 }
+
+// convertColumnType returns the column type of a column from its data type,
+// full column type, length, precision and scale
+func convertColumnType(dataType, columnType string, charMaxLength, numericPrecision, numericScale sql.NullInt64) schema.ColumnType {
+	switch strings.ToLower(dataType) {
+	case "varchar", "char", "binary", "varbinary":
+		var length int
+		if charMaxLength.Valid {
+			length = int(charMaxLength.Int64)
+		}
+		return &schema.VarcharType{
+			Length: length,
+		}
+	case "text", "mediumtext", "longtext", "tinytext":
+		return &schema.TextType{}
+	case "decimal", "numeric":
+		precision := 0
+		scale := 0
+		if numericPrecision.Valid {
+			precision = int(numericPrecision.Int64)
+		}
+		if numericScale.Valid {
+			scale = int(numericScale.Int64)
+		}
+		return &schema.DecimalType{
+			Precision: precision,
+			Scale:     scale,
+		}
+	case "int", "integer", "tinyint", "smallint", "mediumint":
+		return &schema.IntegerType{}
+	case "bigint":
+		return &schema.BigIntType{}
+	case "float", "double":
+		return &schema.FloatType{}
+	case "boolean", "bool":
+		return &schema.BooleanType{}
+	case "date":
+		return &schema.DateType{}
+	case "time":
+		return &schema.TimeType{}
+	case "timestamp", "datetime":
+		return &schema.TimestampType{}
+	case "enum":
+		return &ENUMType{Values: parseValues(columnType)}
+	case "set":
+		return &SetType{Values: parseValues(columnType)}
+	default:
+		// Default to text type
+		return &schema.TextType{}
+	}
+}
@@ -19,7 +19,9 @@ func TestInspectColumns(t *testing.T) {
 			description TEXT NULL,
 			age INTEGER DEFAULT 18,
 			rating DECIMAL(3,1) NOT NULL DEFAULT 5.0,
-			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			status ENUM('Draft', 'it''s live') NOT NULL,
+			tags SET('a', 'b', 'c') NULL
 		);
 	`)
 	require.NoError(t, err)
@@ -44,5 +46,7 @@ func TestInspectColumns(t *testing.T) {
 		{Name: "age", Type: &schema.IntegerType{}, Nullable: true, Default: "18"},
 		{Name: "rating", Type: &schema.DecimalType{Precision: 3, Scale: 1}, Nullable: false, Default: "5.0"},
 		{Name: "created_at", Type: &schema.TimestampType{}, Nullable: false, Default: "CURRENT_TIMESTAMP"},
+		{Name: "status", Type: &ENUMType{Values: []string{"Draft", "it's live"}}, Nullable: false},
+		{Name: "tags", Type: &SetType{Values: []string{"a", "b", "c"}}, Nullable: true},
 	}, table.Columns)
 }
@@ -1,14 +1,20 @@
 package mysql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectForeignKeys inspects foreign keys for a table
-func (my *MySQL) InspectForeignKeys(db *sql.DB, table *schema.Table) error {
+func (my *MySQL) InspectForeignKeys(db dbx.Querier, table *schema.Table) error {
+	return my.InspectForeignKeysContext(context.Background(), db, table)
+}
+
+// InspectForeignKeysContext inspects foreign keys for a table, using ctx for the catalog queries
+func (my *MySQL) InspectForeignKeysContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := `
 		SELECT
 			tc.constraint_name,
@@ -35,7 +41,7 @@ func (my *MySQL) InspectForeignKeys(db *sql.DB, table *schema.Table) error {
 			tc.constraint_name;
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, table.Name)
 	if err != nil {
 		return err
 	}
@@ -1,14 +1,21 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectFunctions inspects stored functions in the database
-func (my *MySQL) InspectFunctions(db *sql.DB, s *schema.Schema) error {
+func (my *MySQL) InspectFunctions(db dbx.Querier, s *schema.Schema) error {
+	return my.InspectFunctionsContext(context.Background(), db, s)
+}
+
+// InspectFunctionsContext inspects stored functions in the database, using ctx for the catalog queries
+func (my *MySQL) InspectFunctionsContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	// Query to get stored functions
 	query := `
 		SELECT
@@ -25,12 +32,15 @@ func (my *MySQL) InspectFunctions(db *sql.DB, s *schema.Schema) error {
 			routine_name;
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	// Collect functions before querying their parameters, a transaction or
+	// pinned connection cannot run a second query while rows are still open
+	var functions []*schema.Function
 	for rows.Next() {
 		var (
 			name            string
@@ -62,24 +72,29 @@ func (my *MySQL) InspectFunctions(db *sql.DB, s *schema.Schema) error {
 			function.Volatility = "STABLE"
 		}
 
+		functions = append(functions, function)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating functions: %w", err)
+	}
+	rows.Close()
+
+	for _, function := range functions {
 		// Get function parameters
-		if err := my.inspectFunctionParameters(db, function); err != nil {
-			return fmt.Errorf("error getting parameters for function %s: %w", name, err)
+		if err := my.inspectFunctionParameters(ctx, db, function); err != nil {
+			return fmt.Errorf("error getting parameters for function %s: %w", function.Name, err)
 		}
 
 		// Add the function to the schema
 		s.Functions = append(s.Functions, function)
 	}
 
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating functions: %w", err)
-	}
-
 	return nil
 }
 
 // inspectFunctionParameters gets the parameters for a function
-func (my *MySQL) inspectFunctionParameters(db *sql.DB, function *schema.Function) error {
+func (my *MySQL) inspectFunctionParameters(ctx context.Context, db dbx.Querier, function *schema.Function) error {
 	query := `
 		SELECT
 			parameter_name,
@@ -97,7 +112,7 @@ func (my *MySQL) inspectFunctionParameters(db *sql.DB, function *schema.Function
 			ordinal_position;
 	`
 
-	rows, err := db.Query(query, function.Name)
+	rows, err := db.QueryContext(ctx, query, function.Name)
 	if err != nil {
 		return err
 	}
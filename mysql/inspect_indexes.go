@@ -1,49 +1,61 @@
 package mysql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectIndexes inspects indexes for a table
-func (my *MySQL) InspectIndexes(db *sql.DB, table *schema.Table) error {
+func (my *MySQL) InspectIndexes(db dbx.Querier, table *schema.Table) error {
+	return my.InspectIndexesContext(context.Background(), db, table)
+}
+
+// InspectIndexesContext inspects indexes for a table, using ctx for the catalog queries
+func (my *MySQL) InspectIndexesContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	// One row per key part of each index. EXPRESSION is set for the
+	// functional key parts of MySQL 8.0.13 and later.
 	query := `
 		SELECT
 			i.index_name,
 			i.non_unique,
-			GROUP_CONCAT(i.column_name ORDER BY i.seq_in_index) as column_names
+			i.index_type,
+			COALESCE(i.column_name, ''),
+			COALESCE(i.expression, ''),
+			COALESCE(i.sub_part, 0),
+			COALESCE(i.collation, '')
 		FROM
 			information_schema.statistics i
 		WHERE
 			i.table_schema = DATABASE()
 			AND i.table_name = ?
 			AND i.index_name != 'PRIMARY'  -- Skip primary key
-		GROUP BY
-			i.index_name, i.non_unique
 		ORDER BY
-			i.index_name;
+			i.index_name, i.seq_in_index;
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, table.Name)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	// Map to track indexes we've already seen
-	indexMap := make(map[string]bool)
-
+	var idx *schema.Index
 	for rows.Next() {
 		var (
-			name      string
-			nonUnique int
-			columnStr string
+			name       string
+			nonUnique  int
+			indexType  string
+			column     string
+			expression string
+			subPart    int
+			collation  string
 		)
 
-		if err := rows.Scan(&name, &nonUnique, &columnStr); err != nil {
+		if err := rows.Scan(&name, &nonUnique, &indexType, &column, &expression, &subPart, &collation); err != nil {
 			return err
 		}
 
@@ -53,25 +65,28 @@ func (my *MySQL) InspectIndexes(db *sql.DB, table *schema.Table) error {
 			continue
 		}
 
-		// Skip if we've already handled this index
-		if _, exists := indexMap[name]; exists {
-			continue
+		if idx == nil || idx.Name != name {
+			idx = &schema.Index{
+				Name:   name,
+				Unique: nonUnique == 0,
+			}
+			if indexType != "BTREE" {
+				idx.Method = indexType
+			}
+			table.Indexes = append(table.Indexes, idx)
 		}
 
-		indexMap[name] = true
-
-		// Get all columns for this index
-		columns := splitAndTrim(columnStr, ",")
-
-		// Create the index
-		idx := &schema.Index{
-			Name:    name,
-			Columns: columns,
-			Unique:  nonUnique == 0,
+		if expression != "" {
+			column = "(" + expression + ")"
 		}
+		idx.Columns = append(idx.Columns, column)
 
-		// Add it to the table
-		table.Indexes = append(table.Indexes, idx)
+		if collation == "D" {
+			schema.Desc(column)(idx)
+		}
+		if subPart > 0 {
+			schema.PrefixLength(column, subPart)(idx)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -19,9 +19,11 @@ func TestInspectIndexes(t *testing.T) {
 			first_name VARCHAR(255),
 			last_name VARCHAR(255),
 			created_at TIMESTAMP,
+			bio TEXT,
 			UNIQUE INDEX idx_email (email),
-			INDEX idx_name (first_name, last_name),
-			INDEX idx_created_at (created_at)
+			INDEX idx_name (first_name(10), last_name),
+			INDEX idx_created_at (created_at DESC),
+			FULLTEXT INDEX idx_bio (bio)
 		);
 	`)
 	require.NoError(t, err)
@@ -37,8 +39,17 @@ func TestInspectIndexes(t *testing.T) {
 	err = my.InspectIndexes(db, table)
 	require.NoError(t, err)
 	require.Equal(t, []*schema.Index{
-		{Name: "idx_created_at", Columns: []string{"created_at"}, Unique: false},
+		{Name: "idx_bio", Columns: []string{"bio"}, Method: "FULLTEXT"},
+		{
+			Name:       "idx_created_at",
+			Columns:    []string{"created_at"},
+			KeyOptions: []*schema.IndexKeyOption{{Column: "created_at", Desc: true}},
+		},
 		{Name: "idx_email", Columns: []string{"email"}, Unique: true},
-		{Name: "idx_name", Columns: []string{"first_name", "last_name"}, Unique: false},
+		{
+			Name:       "idx_name",
+			Columns:    []string{"first_name", "last_name"},
+			KeyOptions: []*schema.IndexKeyOption{{Column: "first_name", Length: 10}},
+		},
 	}, table.Indexes)
 }
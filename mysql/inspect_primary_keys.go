@@ -1,15 +1,22 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectPrimaryKey inspects the primary key for a table
-func (my *MySQL) InspectPrimaryKey(db *sql.DB, table *schema.Table) error {
+func (my *MySQL) InspectPrimaryKey(db dbx.Querier, table *schema.Table) error {
+	return my.InspectPrimaryKeyContext(context.Background(), db, table)
+}
+
+// InspectPrimaryKeyContext inspects the primary key for a table, using ctx for the catalog queries
+func (my *MySQL) InspectPrimaryKeyContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := `
 		SELECT
 			index_name,
@@ -27,7 +34,7 @@ func (my *MySQL) InspectPrimaryKey(db *sql.DB, table *schema.Table) error {
 	var name string
 	var columnsStr string
 
-	err := db.QueryRow(query, table.Name).Scan(&name, &columnsStr)
+	err := db.QueryRowContext(ctx, query, table.Name).Scan(&name, &columnsStr)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// No primary key for this table
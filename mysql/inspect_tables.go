@@ -1,12 +1,19 @@
 package mysql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+
+	"github.com/swiftcarrot/dbx"
 )
 
 // InspectTables returns a list of all tables in the database
-func (my *MySQL) InspectTables(db *sql.DB) ([]string, error) {
+func (my *MySQL) InspectTables(db dbx.Querier) ([]string, error) {
+	return my.InspectTablesContext(context.Background(), db)
+}
+
+// InspectTablesContext returns a list of all tables in the database, using ctx for the catalog queries
+func (my *MySQL) InspectTablesContext(ctx context.Context, db dbx.Querier) ([]string, error) {
 	// TODO: in what order?
 	query := `
 		SELECT
@@ -18,7 +25,7 @@ func (my *MySQL) InspectTables(db *sql.DB) ([]string, error) {
 			AND table_type = 'BASE TABLE'
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -1,14 +1,20 @@
 package mysql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectTriggers inspects triggers in the database
-func (my *MySQL) InspectTriggers(db *sql.DB, s *schema.Schema) error {
+func (my *MySQL) InspectTriggers(db dbx.Querier, s *schema.Schema) error {
+	return my.InspectTriggersContext(context.Background(), db, s)
+}
+
+// InspectTriggersContext inspects triggers in the database, using ctx for the catalog queries
+func (my *MySQL) InspectTriggersContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	// Query to get triggers
 	query := `
 		SELECT
@@ -25,7 +31,7 @@ func (my *MySQL) InspectTriggers(db *sql.DB, s *schema.Schema) error {
 			trigger_name;
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -1,14 +1,21 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectViews inspects views in the database
-func (my *MySQL) InspectViews(db *sql.DB, s *schema.Schema) error {
+func (my *MySQL) InspectViews(db dbx.Querier, s *schema.Schema) error {
+	return my.InspectViewsContext(context.Background(), db, s)
+}
+
+// InspectViewsContext inspects views in the database, using ctx for the catalog queries
+func (my *MySQL) InspectViewsContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	// Query to get views and their definitions
 	query := `
 		SELECT
@@ -22,12 +29,15 @@ func (my *MySQL) InspectViews(db *sql.DB, s *schema.Schema) error {
 			table_name;
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	// Collect views before querying their columns, a transaction or pinned
+	// connection cannot run a second query while rows are still open
+	var views []*schema.View
 	for rows.Next() {
 		var (
 			name       string
@@ -49,24 +59,29 @@ func (my *MySQL) InspectViews(db *sql.DB, s *schema.Schema) error {
 			Definition: definition.String,
 		}
 
+		views = append(views, view)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating views: %w", err)
+	}
+	rows.Close()
+
+	for _, view := range views {
 		// Get the column names for this view
-		if err := my.inspectViewColumns(db, view); err != nil {
-			return fmt.Errorf("error getting columns for view %s: %w", name, err)
+		if err := my.inspectViewColumns(ctx, db, view); err != nil {
+			return fmt.Errorf("error getting columns for view %s: %w", view.Name, err)
 		}
 
 		// Add the view to the schema
 		s.Views = append(s.Views, view)
 	}
 
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating views: %w", err)
-	}
-
 	return nil
 }
 
 // inspectViewColumns gets the column names for a view
-func (my *MySQL) inspectViewColumns(db *sql.DB, view *schema.View) error {
+func (my *MySQL) inspectViewColumns(ctx context.Context, db dbx.Querier, view *schema.View) error {
 	query := `
 		SELECT
 			column_name
@@ -79,7 +94,7 @@ func (my *MySQL) inspectViewColumns(db *sql.DB, view *schema.View) error {
 			ordinal_position;
 	`
 
-	rows, err := db.Query(query, view.Name)
+	rows, err := db.QueryContext(ctx, query, view.Name)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/lockutil"
+)
+
+var _ dbx.Locker = (*MySQL)(nil)
+
+// Lock acquires a named lock with GET_LOCK. The lock is held by a dedicated
+// connection which is closed when the lock is released, or discarded if
+// unlocking fails so that the server drops the lock.
+func (my *MySQL) Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockutil.Poll(ctx, timeout, func() (bool, error) {
+		var acquired sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", name).Scan(&acquired); err != nil {
+			return false, err
+		}
+		if !acquired.Valid {
+			return false, fmt.Errorf("failed to acquire lock %q", name)
+		}
+		return acquired.Int64 == 1, nil
+	})
+	if err != nil {
+		if errors.Is(err, lockutil.ErrTimeout) {
+			err = &dbx.LockTimeoutError{Name: name, Timeout: timeout, Holder: namedLockHolder(conn, name)}
+		}
+		conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		var released sql.NullInt64
+		err := conn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", name).Scan(&released)
+		if err == nil && released.Int64 != 1 {
+			err = fmt.Errorf("lock %q was not held", name)
+		}
+		if err != nil {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			return err
+		}
+		return conn.Close()
+	}, nil
+}
+
+// namedLockHolder describes the connection holding a named lock, or returns an
+// empty string if it cannot be determined
+func namedLockHolder(conn *sql.Conn, name string) string {
+	var id sql.NullInt64
+	if err := conn.QueryRowContext(context.Background(), "SELECT IS_USED_LOCK(?)", name).Scan(&id); err != nil || !id.Valid {
+		return ""
+	}
+
+	holder := fmt.Sprintf("connection %d", id.Int64)
+
+	var user, host string
+	query := "SELECT user, host FROM information_schema.processlist WHERE id = ?"
+	if err := conn.QueryRowContext(context.Background(), query, id.Int64).Scan(&user, &host); err == nil {
+		holder += fmt.Sprintf(" (%s@%s)", user, host)
+	}
+	return holder
+}
@@ -0,0 +1,31 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+)
+
+func TestLock(t *testing.T) {
+	db, err := testutil.GetMySQLTestConn()
+	require.NoError(t, err)
+
+	my := New()
+	unlock, err := my.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+
+	_, err = my.Lock(context.Background(), db, "schema_migrations", 10*time.Millisecond)
+	var timeoutErr *dbx.LockTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Regexp(t, `^connection \d+ \(root@`, timeoutErr.Holder)
+
+	require.NoError(t, unlock())
+
+	unlock, err = my.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
@@ -0,0 +1,736 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/ddl"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var _ dbx.Parser = (*MySQL)(nil)
+
+var parseOptions = ddl.Options{HashComments: true, Backslashes: true, Executable: true, Delimiters: true}
+
+// ParseSQL parses the statements of a SQL file, such as the output of
+// mysqldump --no-data, into a schema as Inspect would return it. Tables,
+// indexes, constraints, views, functions and triggers are read, and DROP
+// statements remove the objects created before them. Session settings and
+// table locks are skipped, character sets, collations and table options are
+// not read and other statements are reported in a *dbx.ParseError.
+func (my *MySQL) ParseSQL(sql string) (*schema.Schema, error) {
+	p := &ddlParser{schema: schema.NewSchema()}
+	err := ddl.Parse(sql, parseOptions, p.statement)
+	return my.filter.Apply(p.schema), err
+}
+
+// ddlParser builds a schema from the statements of a SQL file
+type ddlParser struct {
+	schema *schema.Schema
+}
+
+func (p *ddlParser) statement(q *ddl.Parser) error {
+	switch {
+	case q.Is("SET"), q.Is("USE"), q.Is("LOCK", "TABLES"), q.Is("UNLOCK", "TABLES"):
+		// Session settings and locks are not part of the schema
+		q.Rest()
+		return nil
+	case q.Accept("CREATE"):
+		q.Accept("OR", "REPLACE")
+		for q.Accept("ALGORITHM", "=") || q.Accept("SQL", "SECURITY") {
+			q.Next()
+		}
+		if err := skipDefiner(q); err != nil {
+			return err
+		}
+		for q.Accept("SQL", "SECURITY") {
+			q.Next()
+		}
+		switch {
+		case q.Accept("TABLE"):
+			return p.createTable(q)
+		case q.Is("INDEX"), q.Is("UNIQUE"), q.Is("FULLTEXT"), q.Is("SPATIAL"):
+			return p.createIndex(q)
+		case q.Accept("VIEW"):
+			return p.createView(q)
+		case q.Accept("FUNCTION"):
+			return p.createFunction(q)
+		case q.Accept("TRIGGER"):
+			return p.createTrigger(q)
+		}
+	case q.Accept("ALTER", "TABLE"):
+		return p.alterTable(q)
+	case q.Accept("DROP"):
+		return p.drop(q)
+	}
+	return q.Unsupported()
+}
+
+// skipDefiner reads the DEFINER clause of a view, function or trigger, which
+// is not part of the schema
+func skipDefiner(q *ddl.Parser) error {
+	if !q.Accept("DEFINER") {
+		return nil
+	}
+	if err := q.Expect("="); err != nil {
+		return err
+	}
+	q.Next()
+	if q.Accept("@") {
+		q.Next()
+	} else if q.Is("(", ")") {
+		q.Accept("(", ")")
+	}
+	return nil
+}
+
+func (p *ddlParser) findTable(name string) (*schema.Table, error) {
+	for _, table := range p.schema.Tables {
+		if table.Name == name {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("table %s does not exist", name)
+}
+
+// tableName reads a table name, which may be qualified with its database
+func tableName(q *ddl.Parser) (string, error) {
+	_, name, err := q.Name()
+	return name, err
+}
+
+func (p *ddlParser) drop(q *ddl.Parser) error {
+	kind, ok := q.AcceptAny("TABLE", "VIEW", "TRIGGER", "FUNCTION")
+	if !ok {
+		return q.Unsupported()
+	}
+	q.Accept("IF", "EXISTS")
+	for {
+		name, err := tableName(q)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case "TABLE":
+			p.schema.Tables = without(p.schema.Tables, func(t *schema.Table) bool { return t.Name == name })
+		case "VIEW":
+			p.schema.Views = without(p.schema.Views, func(v *schema.View) bool { return v.Name == name })
+		case "TRIGGER":
+			p.schema.Triggers = without(p.schema.Triggers, func(t *schema.Trigger) bool { return t.Name == name })
+		case "FUNCTION":
+			p.schema.Functions = without(p.schema.Functions, func(f *schema.Function) bool { return f.Name == name })
+		}
+		if !q.Accept(",") {
+			break
+		}
+	}
+	q.AcceptAny("RESTRICT", "CASCADE")
+	return nil
+}
+
+// without returns the objects that drop reports false for
+func without[T any](objects []T, drop func(T) bool) []T {
+	result := objects[:0]
+	for _, object := range objects {
+		if !drop(object) {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+func (p *ddlParser) createTable(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	name, err := tableName(q)
+	if err != nil {
+		return err
+	}
+	if _, err := p.findTable(name); err == nil {
+		return fmt.Errorf("table %s already exists", name)
+	}
+	items, err := q.Items()
+	if err != nil {
+		return err
+	}
+	if q.Find("PARTITION", "BY") {
+		return fmt.Errorf("partitioned tables are not supported")
+	}
+	// Table options such as ENGINE and DEFAULT CHARSET are not part of the schema
+	q.Rest()
+
+	table := p.schema.CreateTable(name, nil)
+	for _, item := range items {
+		r := ddl.NewParser(item, parseOptions)
+		if isTableConstraint(r) {
+			err = p.tableConstraint(r, table)
+		} else {
+			err = p.column(r, table)
+		}
+		if err == nil && !r.Done() {
+			err = fmt.Errorf("unexpected %q", r.Peek().Text)
+		}
+		if err != nil {
+			p.schema.Tables = p.schema.Tables[:len(p.schema.Tables)-1]
+			return err
+		}
+	}
+	return nil
+}
+
+func isTableConstraint(q *ddl.Parser) bool {
+	return q.Is("CONSTRAINT") || q.Is("PRIMARY", "KEY") || q.Is("UNIQUE") || q.Is("KEY") || q.Is("INDEX") ||
+		q.Is("FULLTEXT") || q.Is("SPATIAL") || q.Is("FOREIGN", "KEY") || q.Is("CHECK")
+}
+
+// columnStops are the keywords that end the type of a column definition
+var columnStops = []string{
+	"NOT", "NULL", "DEFAULT", "AUTO_INCREMENT", "PRIMARY", "UNIQUE", "KEY", "COMMENT", "COLLATE", "CHARACTER SET",
+	"CHARSET", "ON UPDATE", "GENERATED", "AS", "CHECK", "REFERENCES", "CONSTRAINT", "VISIBLE", "INVISIBLE",
+}
+
+func (p *ddlParser) column(q *ddl.Parser, table *schema.Table) error {
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	columnType, err := parseColumnType(q.Until(columnStops...))
+	if err != nil {
+		return err
+	}
+	column := table.Column(name, columnType, schema.Nullable)
+
+	for !q.Done() {
+		switch {
+		case q.Accept("NOT", "NULL"):
+			column.Nullable = false
+		case q.Accept("NULL"):
+			column.Nullable = true
+		case q.Accept("DEFAULT"):
+			column.Default, err = defaultValue(q)
+		case q.Accept("AUTO_INCREMENT"):
+			column.AutoIncrement = true
+		case q.Accept("PRIMARY", "KEY"), q.Accept("KEY"):
+			table.SetPrimaryKey("PRIMARY", []string{name})
+			column.Nullable = false
+		case q.Accept("UNIQUE"):
+			q.Accept("KEY")
+			table.Index(name, []string{name}, schema.Unique)
+		case q.Accept("COMMENT"):
+			column.Comment, err = q.String()
+		case q.Accept("COLLATE"), q.Accept("CHARACTER", "SET"), q.Accept("CHARSET"):
+			q.Accept("=")
+			_, err = q.Ident()
+		case q.Accept("ON", "UPDATE"):
+			// ON UPDATE CURRENT_TIMESTAMP is not part of the schema
+			q.Next()
+			if q.Is("(") {
+				_, err = q.Group()
+			}
+		case q.Is("CHECK"), q.Is("CONSTRAINT"):
+			err = p.tableConstraint(q, table)
+		case q.Is("GENERATED"), q.Is("AS"):
+			return fmt.Errorf("generated columns are not supported")
+		default:
+			return fmt.Errorf("unexpected %q", q.Peek().Text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseColumnType returns the column type of a type in a column definition,
+// as Inspect reads it
+func parseColumnType(text string) (schema.ColumnType, error) {
+	q := ddl.NewParser(text, parseOptions)
+	dataType, err := q.Ident()
+	if err != nil {
+		return nil, err
+	}
+	dataType = strings.ToLower(dataType)
+	if dataType == "double" {
+		q.Accept("PRECISION")
+	}
+	var params []string
+	if q.Is("(") {
+		if params, err = q.Items(); err != nil {
+			return nil, err
+		}
+	}
+	for !q.Done() {
+		if _, ok := q.AcceptAny("UNSIGNED", "SIGNED", "ZEROFILL"); !ok {
+			return nil, fmt.Errorf("unexpected %q in column type", q.Peek().Text)
+		}
+	}
+
+	var length, precision, scale sql.NullInt64
+	param := func(i int) sql.NullInt64 {
+		if i >= len(params) {
+			return sql.NullInt64{}
+		}
+		value, err := strconv.ParseInt(strings.TrimSpace(params[i]), 10, 64)
+		return sql.NullInt64{Int64: value, Valid: err == nil}
+	}
+	switch dataType {
+	case "varchar", "char", "binary", "varbinary":
+		length = param(0)
+		if !length.Valid && (dataType == "char" || dataType == "binary") {
+			length = sql.NullInt64{Int64: 1, Valid: true}
+		}
+	case "decimal", "numeric":
+		precision, scale = param(0), param(1)
+		if !precision.Valid {
+			precision = sql.NullInt64{Int64: 10, Valid: true}
+		}
+	}
+	return convertColumnType(dataType, text, length, precision, scale), nil
+}
+
+// defaultValue reads the default of a column as Inspect reads it, which is
+// the value of a string literal, CURRENT_TIMESTAMP for the current time and
+// empty for NULL
+func defaultValue(q *ddl.Parser) (string, error) {
+	token := q.Peek()
+	switch {
+	case q.Accept("NULL"):
+		return "", nil
+	case token.Kind == ddl.String:
+		return q.String()
+	case q.Accept("CURRENT_TIMESTAMP"), q.Accept("NOW"):
+		if q.Is("(") {
+			if _, err := q.Group(); err != nil {
+				return "", err
+			}
+		}
+		return "CURRENT_TIMESTAMP", nil
+	case q.Is("("):
+		return q.Group()
+	}
+	return q.Until(columnStops...), nil
+}
+
+// tableConstraint reads an index, key or constraint of a table
+func (p *ddlParser) tableConstraint(q *ddl.Parser, table *schema.Table) error {
+	name := ""
+	if q.Accept("CONSTRAINT") {
+		if !q.Is("PRIMARY") && !q.Is("UNIQUE") && !q.Is("FOREIGN") && !q.Is("CHECK") {
+			var err error
+			if name, err = q.Ident(); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case q.Accept("PRIMARY", "KEY"):
+		index, err := indexDefinition(q, "")
+		if err != nil {
+			return err
+		}
+		for _, key := range index.Columns {
+			for _, column := range table.Columns {
+				if column.Name == key {
+					column.Nullable = false
+				}
+			}
+		}
+		table.SetPrimaryKey("PRIMARY", index.Columns)
+	case q.Accept("UNIQUE"):
+		q.AcceptAny("KEY", "INDEX")
+		index, err := indexDefinition(q, name)
+		if err != nil {
+			return err
+		}
+		index.Unique = true
+		table.Indexes = append(table.Indexes, index)
+	case q.Is("KEY"), q.Is("INDEX"), q.Is("FULLTEXT"), q.Is("SPATIAL"):
+		method, _ := q.AcceptAny("FULLTEXT", "SPATIAL")
+		q.AcceptAny("KEY", "INDEX")
+		index, err := indexDefinition(q, name)
+		if err != nil {
+			return err
+		}
+		if method != "" {
+			index.Method = method
+		}
+		table.Indexes = append(table.Indexes, index)
+	case q.Accept("FOREIGN", "KEY"):
+		if !q.Is("(") {
+			var err error
+			if name, err = q.Ident(); err != nil {
+				return err
+			}
+		}
+		columns, err := q.Names()
+		if err != nil {
+			return err
+		}
+		if err := q.Expect("REFERENCES"); err != nil {
+			return err
+		}
+		refTable, err := tableName(q)
+		if err != nil {
+			return err
+		}
+		refColumns, err := q.Names()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = fmt.Sprintf("%s_ibfk_%d", table.Name, len(table.ForeignKeys)+1)
+		}
+		fk := table.ForeignKey(name, columns, refTable, refColumns)
+		for {
+			switch {
+			case q.Accept("ON", "DELETE"):
+				fk.OnDelete = referentialAction(q)
+				continue
+			case q.Accept("ON", "UPDATE"):
+				fk.OnUpdate = referentialAction(q)
+				continue
+			}
+			break
+		}
+	case q.Accept("CHECK"):
+		expression, err := q.Group()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = fmt.Sprintf("%s_chk_%d", table.Name, len(table.Checks)+1)
+		}
+		table.Check(name, unwrap(expression))
+		if q.Accept("NOT", "ENFORCED") {
+			return fmt.Errorf("check constraints that are not enforced are not supported")
+		}
+		q.Accept("ENFORCED")
+	default:
+		return fmt.Errorf("unsupported table constraint %q", q.Peek().Text)
+	}
+	return nil
+}
+
+// referentialAction reads the action of a foreign key, empty for RESTRICT as Inspect sets it
+func referentialAction(q *ddl.Parser) string {
+	for _, action := range [][]string{{"CASCADE"}, {"SET", "NULL"}, {"SET", "DEFAULT"}, {"NO", "ACTION"}} {
+		if q.Accept(action...) {
+			return strings.Join(action, " ")
+		}
+	}
+	q.Accept("RESTRICT")
+	return ""
+}
+
+// unwrap removes the parentheses around a whole expression
+func unwrap(expression string) string {
+	q := ddl.NewParser(expression, parseOptions)
+	if !q.Is("(") {
+		return expression
+	}
+	inner, err := q.Group()
+	if err != nil || !q.Done() {
+		return expression
+	}
+	return inner
+}
+
+// indexDefinition reads the name, method and key parts of an index
+func indexDefinition(q *ddl.Parser, name string) (*schema.Index, error) {
+	if !q.Is("(") && !q.Is("USING") {
+		var err error
+		if name, err = q.Ident(); err != nil {
+			return nil, err
+		}
+	}
+	index := &schema.Index{Name: name}
+	if err := indexMethod(q, index); err != nil {
+		return nil, err
+	}
+	keys, err := q.Items()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := indexKey(ddl.NewParser(key, parseOptions), index); err != nil {
+			return nil, err
+		}
+	}
+	if err := indexMethod(q, index); err != nil {
+		return nil, err
+	}
+	if q.Accept("COMMENT") {
+		if _, err := q.String(); err != nil {
+			return nil, err
+		}
+	}
+	q.AcceptAny("VISIBLE")
+	if index.Name == "" && len(index.Columns) > 0 {
+		index.Name = index.Columns[0]
+	}
+	return index, nil
+}
+
+func indexMethod(q *ddl.Parser, index *schema.Index) error {
+	if !q.Accept("USING") {
+		return nil
+	}
+	method, ok := q.AcceptAny("BTREE", "HASH")
+	if !ok {
+		return fmt.Errorf("expected BTREE or HASH, found %q", q.Peek().Text)
+	}
+	if method != "BTREE" {
+		index.Method = method
+	}
+	return nil
+}
+
+// indexKey reads a key part of an index with its prefix length and sort order
+func indexKey(q *ddl.Parser, index *schema.Index) error {
+	var key string
+	if q.Is("(") {
+		expression, err := q.Group()
+		if err != nil {
+			return err
+		}
+		key = "(" + expression + ")"
+	} else {
+		var err error
+		if key, err = q.Ident(); err != nil {
+			return err
+		}
+	}
+	index.Columns = append(index.Columns, key)
+
+	if q.Is("(") {
+		length, err := q.Group()
+		if err != nil {
+			return err
+		}
+		prefix, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return fmt.Errorf("invalid prefix length %q", length)
+		}
+		schema.PrefixLength(key, prefix)(index)
+	}
+	if order, ok := q.AcceptAny("ASC", "DESC"); ok && order == "DESC" {
+		schema.Desc(key)(index)
+	}
+	return nil
+}
+
+func (p *ddlParser) createIndex(q *ddl.Parser) error {
+	kind, _ := q.AcceptAny("UNIQUE", "FULLTEXT", "SPATIAL")
+	if err := q.Expect("INDEX"); err != nil {
+		return err
+	}
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	index := &schema.Index{Name: name, Unique: kind == "UNIQUE"}
+	if kind == "FULLTEXT" || kind == "SPATIAL" {
+		index.Method = kind
+	}
+	if err := indexMethod(q, index); err != nil {
+		return err
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	name, err = tableName(q)
+	if err != nil {
+		return err
+	}
+	table, err := p.findTable(name)
+	if err != nil {
+		return err
+	}
+	keys, err := q.Items()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := indexKey(ddl.NewParser(key, parseOptions), index); err != nil {
+			return err
+		}
+	}
+	if err := indexMethod(q, index); err != nil {
+		return err
+	}
+	table.Indexes = append(table.Indexes, index)
+	return nil
+}
+
+func (p *ddlParser) alterTable(q *ddl.Parser) error {
+	name, err := tableName(q)
+	if err != nil {
+		return err
+	}
+	table, err := p.findTable(name)
+	if err != nil {
+		return err
+	}
+	for {
+		switch {
+		case q.Accept("DISABLE", "KEYS"), q.Accept("ENABLE", "KEYS"):
+			// Index maintenance during a data load is not part of the schema
+		case q.Accept("ADD"):
+			if isTableConstraint(q) {
+				err = p.tableConstraint(q, table)
+			} else {
+				q.Accept("COLUMN")
+				r := ddl.NewParser(q.Until(","), parseOptions)
+				if err = p.column(r, table); err == nil && !r.Done() {
+					err = fmt.Errorf("unexpected %q", r.Peek().Text)
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported ALTER TABLE action %q", q.Peek().Text)
+		}
+		if err != nil {
+			return err
+		}
+		if !q.Accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *ddlParser) createView(q *ddl.Parser) error {
+	name, err := tableName(q)
+	if err != nil {
+		return err
+	}
+	view := &schema.View{Name: name}
+	if q.Is("(") {
+		if view.Columns, err = q.Names(); err != nil {
+			return err
+		}
+	}
+	if err := q.Expect("AS"); err != nil {
+		return err
+	}
+	view.Definition = q.Until("WITH CASCADED CHECK OPTION", "WITH LOCAL CHECK OPTION", "WITH CHECK OPTION")
+	if !q.Done() {
+		return fmt.Errorf("view check options are not supported")
+	}
+	p.schema.Views = without(p.schema.Views, func(v *schema.View) bool { return v.Name == name })
+	p.schema.Views = append(p.schema.Views, view)
+	return nil
+}
+
+func (p *ddlParser) createFunction(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	name, err := tableName(q)
+	if err != nil {
+		return err
+	}
+	items, err := q.Items()
+	if err != nil {
+		return err
+	}
+	function := &schema.Function{Name: name, Volatility: "STABLE"}
+	for _, item := range items {
+		r := ddl.NewParser(item, parseOptions)
+		argName, err := r.Ident()
+		if err != nil {
+			return err
+		}
+		function.Arguments = append(function.Arguments, schema.FunctionArg{Name: argName, Type: strings.ToLower(r.Rest())})
+	}
+	if err := q.Expect("RETURNS"); err != nil {
+		return err
+	}
+	returns, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	// Inspect reads the return type without its length and character set
+	function.Returns = strings.ToLower(returns)
+	if q.Is("(") {
+		if _, err := q.Group(); err != nil {
+			return err
+		}
+	}
+	q.AcceptAny("UNSIGNED", "SIGNED")
+	if q.Accept("CHARSET") || q.Accept("CHARACTER", "SET") {
+		if _, err := q.Ident(); err != nil {
+			return err
+		}
+	}
+	if q.Accept("COLLATE") {
+		if _, err := q.Ident(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		switch {
+		case q.Accept("DETERMINISTIC"):
+			function.Volatility = "IMMUTABLE"
+		case q.Accept("NOT", "DETERMINISTIC"):
+			function.Volatility = "STABLE"
+		case q.Accept("CONTAINS", "SQL"), q.Accept("NO", "SQL"), q.Accept("READS", "SQL", "DATA"),
+			q.Accept("MODIFIES", "SQL", "DATA"), q.Accept("LANGUAGE", "SQL"):
+		case q.Accept("SQL", "SECURITY"):
+			// Inspect does not read the security of MySQL functions
+			if _, ok := q.AcceptAny("DEFINER", "INVOKER"); !ok {
+				return fmt.Errorf("expected DEFINER or INVOKER, found %q", q.Peek().Text)
+			}
+		case q.Accept("COMMENT"):
+			if _, err := q.String(); err != nil {
+				return err
+			}
+		default:
+			function.Body = q.Rest()
+			p.schema.Functions = without(p.schema.Functions, func(f *schema.Function) bool { return f.Name == name })
+			p.schema.Functions = append(p.schema.Functions, function)
+			return nil
+		}
+	}
+}
+
+func (p *ddlParser) createTrigger(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	name, err := tableName(q)
+	if err != nil {
+		return err
+	}
+	timing, ok := q.AcceptAny("BEFORE", "AFTER")
+	if !ok {
+		return fmt.Errorf("expected BEFORE or AFTER, found %q", q.Peek().Text)
+	}
+	event, ok := q.AcceptAny("INSERT", "UPDATE", "DELETE")
+	if !ok {
+		return fmt.Errorf("expected trigger event, found %q", q.Peek().Text)
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	table, err := tableName(q)
+	if err != nil {
+		return err
+	}
+	if err := q.Expect("FOR", "EACH", "ROW"); err != nil {
+		return err
+	}
+	if _, ok := q.AcceptAny("FOLLOWS", "PRECEDES"); ok {
+		return fmt.Errorf("trigger order is not supported")
+	}
+
+	// MySQL triggers run their statement instead of calling a function,
+	// Inspect keeps the statement in Function
+	p.schema.Triggers = append(p.schema.Triggers, &schema.Trigger{
+		Name:     name,
+		Table:    table,
+		Events:   []string{event},
+		Timing:   timing,
+		ForEach:  "ROW",
+		Function: q.Rest(),
+	})
+	return nil
+}
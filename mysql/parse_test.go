@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestParseSQL(t *testing.T) {
+	s, err := New().ParseSQL("-- MySQL dump 10.13  Distrib 8.0.36\n" +
+		"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+		"/*!40101 SET NAMES utf8mb4 */;\n" +
+		"DROP TABLE IF EXISTS `users`;\n" +
+		"CREATE TABLE `users` (\n" +
+		"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,\n" +
+		"  `name` varchar(100) DEFAULT 'it''s',\n" +
+		"  `status` enum('active','disabled') NOT NULL DEFAULT 'active',\n" +
+		"  `price` decimal(10,2) unsigned DEFAULT NULL,\n" +
+		"  `bio` text COMMENT 'about me',\n" +
+		"  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `users_email_key` (`email`),\n" +
+		"  KEY `idx_name` (`name`(10) DESC),\n" +
+		"  FULLTEXT KEY `ft_bio` (`bio`),\n" +
+		"  CONSTRAINT `users_chk_1` CHECK ((`price` > 0))\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=3 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;\n" +
+		"CREATE TABLE `posts` (\n" +
+		"  `id` bigint NOT NULL,\n" +
+		"  `user_id` int DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `user_id` (`user_id`),\n" +
+		"  CONSTRAINT `posts_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE\n" +
+		") ENGINE=InnoDB;\n" +
+		"/*!50001 DROP VIEW IF EXISTS `active_users`*/;\n" +
+		"/*!50001 CREATE VIEW `active_users` AS SELECT 1 AS `id`*/;\n" +
+		"/*!50001 DROP VIEW IF EXISTS `active_users`*/;\n" +
+		"/*!50001 CREATE ALGORITHM=UNDEFINED */\n" +
+		"/*!50013 DEFINER=`root`@`localhost` SQL SECURITY DEFINER */\n" +
+		"/*!50001 VIEW `active_users` AS select `users`.`id` AS `id` from `users` where (`users`.`status` = 'active') */;\n" +
+		"DELIMITER ;;\n" +
+		"/*!50003 CREATE*/ /*!50017 DEFINER=`root`@`localhost`*/ /*!50003 TRIGGER `users_lower` BEFORE INSERT ON `users` FOR EACH ROW BEGIN\n" +
+		"  SET NEW.email = LOWER(NEW.email);\n" +
+		"END */;;\n" +
+		"/*!50003 CREATE*/ /*!50020 DEFINER=`root`@`localhost`*/ /*!50003 FUNCTION `add_one`(a INT) RETURNS int\n" +
+		"    DETERMINISTIC\n" +
+		"RETURN a + 1 */;;\n" +
+		"DELIMITER ;\n")
+	require.NoError(t, err)
+
+	require.Len(t, s.Tables, 2)
+	users := s.Tables[0]
+	require.Equal(t, []*schema.Column{
+		{Name: "id", Type: &schema.IntegerType{}, AutoIncrement: true},
+		{Name: "email", Type: &schema.VarcharType{Length: 255}},
+		{Name: "name", Type: &schema.VarcharType{Length: 100}, Nullable: true, Default: "it's"},
+		{Name: "status", Type: &ENUMType{Values: []string{"active", "disabled"}}, Default: "active"},
+		{Name: "price", Type: &schema.DecimalType{Precision: 10, Scale: 2}, Nullable: true},
+		{Name: "bio", Type: &schema.TextType{}, Nullable: true, Comment: "about me"},
+		{Name: "created_at", Type: &schema.TimestampType{}, Nullable: true, Default: "CURRENT_TIMESTAMP"},
+	}, users.Columns)
+	require.Equal(t, &schema.PrimaryKey{Name: "PRIMARY", Columns: []string{"id"}}, users.PrimaryKey)
+	require.Equal(t, []*schema.Index{
+		{Name: "users_email_key", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_name", Columns: []string{"name"}, KeyOptions: []*schema.IndexKeyOption{{Column: "name", Desc: true, Length: 10}}},
+		{Name: "ft_bio", Columns: []string{"bio"}, Method: "FULLTEXT"},
+	}, users.Indexes)
+	require.Equal(t, []*schema.CheckConstraint{{Name: "users_chk_1", Expression: "`price` > 0"}}, users.Checks)
+
+	posts := s.Tables[1]
+	require.Equal(t, []*schema.ForeignKey{
+		{Name: "posts_ibfk_1", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+	}, posts.ForeignKeys)
+
+	require.Equal(t, []*schema.View{
+		{Name: "active_users", Definition: "select `users`.`id` AS `id` from `users` where (`users`.`status` = 'active')"},
+	}, s.Views)
+	require.Equal(t, []*schema.Trigger{
+		{
+			Name:     "users_lower",
+			Table:    "users",
+			Events:   []string{"INSERT"},
+			Timing:   "BEFORE",
+			ForEach:  "ROW",
+			Function: "BEGIN\n  SET NEW.email = LOWER(NEW.email);\nEND",
+		},
+	}, s.Triggers)
+	require.Equal(t, []*schema.Function{
+		{
+			Name:       "add_one",
+			Arguments:  []schema.FunctionArg{{Name: "a", Type: "int"}},
+			Returns:    "int",
+			Body:       "RETURN a + 1",
+			Volatility: "IMMUTABLE",
+		},
+	}, s.Functions)
+}
+
+func TestParseSQLUnsupported(t *testing.T) {
+	s, err := New().ParseSQL(`CREATE TABLE users (id int NOT NULL, PRIMARY KEY (id));
+CREATE EVENT cleanup ON SCHEDULE EVERY 1 DAY DO DELETE FROM users;
+
+CREATE TABLE totals (a int, b int, c int AS (a + b));
+CREATE INDEX users_id ON users (id);
+`)
+
+	var parseErr *dbx.ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, []dbx.UnsupportedStatement{
+		{Line: 2, Statement: "CREATE EVENT cleanup ON SCHEDULE EVERY 1 DAY DO DELETE FROM users", Reason: "unsupported statement CREATE EVENT"},
+		{Line: 4, Statement: "CREATE TABLE totals (a int, b int, c int AS (a + b))", Reason: "generated columns are not supported"},
+	}, parseErr.Unsupported)
+
+	require.Len(t, s.Tables, 1)
+	require.Len(t, s.Tables[0].Indexes, 1)
+}
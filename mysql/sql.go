@@ -9,7 +9,7 @@ import (
 
 // GenerateSQL converts a schema change to a MySQL SQL statement
 func (my *MySQL) GenerateSQL(change schema.Change) (string, error) {
-	switch c := change.(type) {
+	switch c := schema.Indirect(change).(type) {
 	// Schema-related changes - MySQL uses databases instead of schemas
 	case schema.CreateSchemaChange:
 		return my.generateCreateDatabase(c), nil
@@ -27,6 +27,8 @@ func (my *MySQL) GenerateSQL(change schema.Change) (string, error) {
 		return my.generateCreateTable(c), nil
 	case schema.DropTableChange:
 		return my.generateDropTable(c), nil
+	case schema.RenameTableChange:
+		return my.generateRenameTable(c), nil
 
 	// Column-related changes
 	case schema.AddColumnChange:
@@ -35,6 +37,8 @@ func (my *MySQL) GenerateSQL(change schema.Change) (string, error) {
 		return my.generateDropColumn(c), nil
 	case schema.AlterColumnChange:
 		return my.generateAlterColumn(c), nil
+	case schema.RenameColumnChange:
+		return my.generateRenameColumn(c), nil
 
 	// Primary key-related changes
 	case schema.AddPrimaryKeyChange:
@@ -44,16 +48,46 @@ func (my *MySQL) GenerateSQL(change schema.Change) (string, error) {
 
 	// Index-related changes
 	case schema.AddIndexChange:
-		return my.generateAddIndex(c), nil
+		return my.generateAddIndex(c)
 	case schema.DropIndexChange:
 		return my.generateDropIndex(c), nil
+	case schema.RenameIndexChange:
+		return my.generateRenameIndex(c), nil
 
 	// Foreign key-related changes
 	case schema.AddForeignKeyChange:
+		if c.NotValid || c.ForeignKey.NotValid {
+			return "", fmt.Errorf("NOT VALID constraints not supported in MySQL")
+		}
 		return my.generateAddForeignKey(c), nil
 	case schema.DropForeignKeyChange:
 		return my.generateDropForeignKey(c), nil
 
+	// Constraint-related changes
+	case schema.AddCheckChange:
+		if c.NotValid || c.Check.NotValid {
+			return "", fmt.Errorf("NOT VALID constraints not supported in MySQL")
+		}
+		return my.generateAddCheck(c), nil
+	case schema.DropCheckChange:
+		return my.generateDropCheck(c), nil
+	case schema.AddUniqueConstraintChange:
+		return my.generateAddUnique(c), nil
+	case schema.DropUniqueConstraintChange:
+		return my.generateDropUnique(c), nil
+	case schema.ValidateConstraintChange:
+		return "", fmt.Errorf("NOT VALID constraints not supported in MySQL")
+
+	// Enum-related changes - Not supported in MySQL
+	case schema.CreateEnumChange:
+		return "", fmt.Errorf("enum types not supported in MySQL, use an ENUM column type instead")
+	case schema.DropEnumChange:
+		return "", fmt.Errorf("enum types not supported in MySQL, use an ENUM column type instead")
+	case schema.AddEnumValueChange:
+		return "", fmt.Errorf("enum types not supported in MySQL, use an ENUM column type instead")
+	case schema.AlterEnumChange:
+		return "", fmt.Errorf("enum types not supported in MySQL, use an ENUM column type instead")
+
 	// Sequence-related changes - Not directly supported in MySQL
 	case schema.CreateSequenceChange:
 		return "", fmt.Errorf("sequences not supported in MySQL, use AUTO_INCREMENT instead")
@@ -97,6 +131,15 @@ func quoteIdentifier(name string) string {
 	return "`" + strings.Replace(name, "`", "``", -1) + "`"
 }
 
+// Helper function to quote a comma separated list of MySQL identifiers
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // Helper function to quote MySQL string literals
 func quoteLiteral(str string) string {
 	return "'" + strings.Replace(str, "'", "''", -1) + "'"
@@ -149,6 +192,18 @@ func (my *MySQL) generateCreateTable(c schema.CreateTableChange) string {
 			strings.Join(pkColumnsList, ", ")))
 	}
 
+	for _, unique := range table.Uniques {
+		sb.WriteString(fmt.Sprintf(",\n  CONSTRAINT %s UNIQUE (%s)",
+			quoteIdentifier(unique.Name),
+			quoteIdentifiers(unique.Columns)))
+	}
+
+	for _, check := range table.Checks {
+		sb.WriteString(fmt.Sprintf(",\n  CONSTRAINT %s CHECK (%s)",
+			quoteIdentifier(check.Name),
+			check.Expression))
+	}
+
 	sb.WriteString("\n) ENGINE=InnoDB;")
 
 	// Add comments for columns if present (MySQL syntax differs from PostgreSQL)
@@ -178,6 +233,10 @@ func (my *MySQL) generateDropTable(c schema.DropTableChange) string {
 	return fmt.Sprintf("DROP TABLE %s;", quoteIdentifier(c.TableName))
 }
 
+func (my *MySQL) generateRenameTable(c schema.RenameTableChange) string {
+	return fmt.Sprintf("RENAME TABLE %s TO %s;", quoteIdentifier(c.OldName), quoteIdentifier(c.NewName))
+}
+
 // Column-related SQL generation
 
 func (my *MySQL) generateAddColumn(c schema.AddColumnChange) string {
@@ -199,19 +258,42 @@ func (my *MySQL) generateAddColumn(c schema.AddColumnChange) string {
 		sql += fmt.Sprintf(" COMMENT %s", quoteLiteral(column.Comment))
 	}
 
-	sql += ";"
+	sql += my.alterOptions(", ") + ";"
 
 	return sql
 }
 
 func (my *MySQL) generateDropColumn(c schema.DropColumnChange) string {
-	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;",
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.ColumnName),
+		my.alterOptions(", "))
+}
+
+func (my *MySQL) generateRenameColumn(c schema.RenameColumnChange) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s%s;",
 		quoteIdentifier(c.TableName),
-		quoteIdentifier(c.ColumnName))
+		quoteIdentifier(c.OldName),
+		quoteIdentifier(c.NewName),
+		my.alterOptions(", "))
 }
 
 func (my *MySQL) generateAlterColumn(c schema.AlterColumnChange) string {
 	column := c.Column
+	if c.Changed.Only("Default") {
+		if column.Default != "" {
+			return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s%s;",
+				quoteIdentifier(c.TableName),
+				quoteIdentifier(column.Name),
+				column.Default,
+				my.alterOptions(", "))
+		}
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT%s;",
+			quoteIdentifier(c.TableName),
+			quoteIdentifier(column.Name),
+			my.alterOptions(", "))
+	}
+
 	sql := fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s",
 		quoteIdentifier(c.TableName),
 		quoteIdentifier(column.Name),
@@ -229,7 +311,7 @@ func (my *MySQL) generateAlterColumn(c schema.AlterColumnChange) string {
 		sql += fmt.Sprintf(" COMMENT %s", quoteLiteral(column.Comment))
 	}
 
-	sql += ";"
+	sql += my.alterOptions(", ") + ";"
 	return sql
 }
 
@@ -242,41 +324,88 @@ func (my *MySQL) generateAddPrimaryKey(c schema.AddPrimaryKeyChange) string {
 		columns[i] = quoteIdentifier(col)
 	}
 
-	return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);",
+	return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)%s;",
 		quoteIdentifier(c.TableName),
-		strings.Join(columns, ", "))
+		strings.Join(columns, ", "),
+		my.alterOptions(", "))
 }
 
 func (my *MySQL) generateDropPrimaryKey(c schema.DropPrimaryKeyChange) string {
-	return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;",
-		quoteIdentifier(c.TableName))
+	return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY%s;",
+		quoteIdentifier(c.TableName),
+		my.alterOptions(", "))
 }
 
 // Index-related SQL generation
 
-func (my *MySQL) generateAddIndex(c schema.AddIndexChange) string {
+func (my *MySQL) generateAddIndex(c schema.AddIndexChange) (string, error) {
 	index := c.Index
-	indexType := "INDEX"
-	if index.Unique {
-		indexType = "UNIQUE INDEX"
+	if index.Where != "" {
+		return "", fmt.Errorf("partial indexes not supported in MySQL")
+	}
+	if len(index.Include) > 0 {
+		return "", fmt.Errorf("included index columns not supported in MySQL")
 	}
 
-	columns := make([]string, len(index.Columns))
-	for i, col := range index.Columns {
-		columns[i] = quoteIdentifier(col)
+	indexType := "INDEX"
+	using := ""
+	switch method := strings.ToUpper(index.Method); method {
+	case "", "BTREE":
+	case "FULLTEXT", "SPATIAL":
+		indexType = method + " INDEX"
+	case "HASH":
+		using = " USING HASH"
+	default:
+		return "", fmt.Errorf("index method %s not supported in MySQL", index.Method)
+	}
+	if index.Unique {
+		indexType = "UNIQUE " + indexType
 	}
 
-	return fmt.Sprintf("CREATE %s %s ON %s (%s);",
+	return fmt.Sprintf("CREATE %s %s ON %s (%s)%s%s;",
 		indexType,
 		quoteIdentifier(index.Name),
 		quoteIdentifier(c.TableName),
-		strings.Join(columns, ", "))
+		indexKeyParts(index),
+		using,
+		my.alterOptions(" ")), nil
+}
+
+// indexKeyParts renders the key columns and expressions of an index with
+// their prefix length and sort order
+func indexKeyParts(index *schema.Index) string {
+	parts := make([]string, len(index.Columns))
+	for i, col := range index.Columns {
+		part := col
+		if !strings.HasPrefix(col, "(") {
+			part = quoteIdentifier(col)
+		}
+
+		option := index.KeyOption(col)
+		if option.Length > 0 {
+			part += fmt.Sprintf("(%d)", option.Length)
+		}
+		if option.Desc {
+			part += " DESC"
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", ")
 }
 
 func (my *MySQL) generateDropIndex(c schema.DropIndexChange) string {
-	return fmt.Sprintf("DROP INDEX %s ON %s;",
+	return fmt.Sprintf("DROP INDEX %s ON %s%s;",
 		quoteIdentifier(c.IndexName),
-		quoteIdentifier(c.TableName))
+		quoteIdentifier(c.TableName),
+		my.alterOptions(" "))
+}
+
+func (my *MySQL) generateRenameIndex(c schema.RenameIndexChange) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME INDEX %s TO %s%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.OldName),
+		quoteIdentifier(c.NewName),
+		my.alterOptions(", "))
 }
 
 // Foreign key-related SQL generation
@@ -308,14 +437,48 @@ func (my *MySQL) generateAddForeignKey(c schema.AddForeignKeyChange) string {
 		sql += fmt.Sprintf(" ON UPDATE %s", fk.OnUpdate)
 	}
 
-	sql += ";"
+	sql += my.alterOptions(", ") + ";"
 	return sql
 }
 
 func (my *MySQL) generateDropForeignKey(c schema.DropForeignKeyChange) string {
-	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;",
+	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.FKName),
+		my.alterOptions(", "))
+}
+
+// Constraint-related SQL generation
+
+func (my *MySQL) generateAddCheck(c schema.AddCheckChange) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s)%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.Check.Name),
+		c.Check.Expression,
+		my.alterOptions(", "))
+}
+
+func (my *MySQL) generateDropCheck(c schema.DropCheckChange) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CHECK %s%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.CheckName),
+		my.alterOptions(", "))
+}
+
+func (my *MySQL) generateAddUnique(c schema.AddUniqueConstraintChange) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s)%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.Unique.Name),
+		quoteIdentifiers(c.Unique.Columns),
+		my.alterOptions(", "))
+}
+
+// MySQL implements unique constraints as unique indexes
+func (my *MySQL) generateDropUnique(c schema.DropUniqueConstraintChange) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s%s;",
 		quoteIdentifier(c.TableName),
-		quoteIdentifier(c.FKName))
+		quoteIdentifier(c.ConstraintName),
+		my.alterOptions(", "))
 }
 
 // Function-related SQL generation
@@ -531,14 +694,9 @@ func (my *MySQL) CreatePrimaryKey(pk *schema.PrimaryKey) string {
 
 // CreateUniqueConstraint generates SQL for a unique constraint
 func (my *MySQL) CreateUniqueConstraint(index *schema.Index) string {
-	var columns []string
-	for _, col := range index.Columns {
-		columns = append(columns, QuoteIdentifier(col))
-	}
-
 	return fmt.Sprintf("UNIQUE KEY %s (%s)",
 		QuoteIdentifier(index.Name),
-		strings.Join(columns, ", "))
+		indexKeyParts(index))
 }
 
 // CreateIndex generates SQL to create an index
@@ -555,12 +713,7 @@ func (my *MySQL) CreateIndex(index *schema.Index, tableName string) string {
 			QuoteIdentifier(tableName))
 	}
 
-	for i, col := range index.Columns {
-		if i > 0 {
-			b.WriteString(", ")
-		}
-		b.WriteString(QuoteIdentifier(col))
-	}
+	b.WriteString(indexKeyParts(index))
 	b.WriteString(")")
 
 	return b.String()
@@ -659,3 +812,8 @@ func (my *MySQL) CreateTrigger(trigger *schema.Trigger) string {
 func QuoteIdentifier(s string) string {
 	return "`" + strings.Replace(s, "`", "``", -1) + "`"
 }
+
+// Placeholder returns the bind parameter placeholder for the nth argument of a query
+func (my *MySQL) Placeholder(n int) string {
+	return "?"
+}
@@ -69,6 +69,14 @@ func TestSequenceNotSupported(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestEnumTypeNotSupported(t *testing.T) {
+	my := New()
+	_, err := my.GenerateSQL(schema.CreateEnumChange{
+		Enum: &schema.Enum{Name: "mood", Values: []string{"sad", "happy"}},
+	})
+	require.EqualError(t, err, "enum types not supported in MySQL, use an ENUM column type instead")
+}
+
 func TestCreateTable(t *testing.T) {
 	my := New()
 
@@ -95,6 +103,24 @@ func TestCreateTable(t *testing.T) {
 	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
 }
 
+func TestEnumAndSetTypes(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.AddColumnChange{
+		TableName: "posts",
+		Column:    &schema.Column{Name: "status", Type: &ENUMType{Values: []string{"draft", "it's live"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `posts` ADD COLUMN `status` enum('draft','it''s live') NOT NULL;", sql)
+
+	require.Equal(t, "set('a','b\\\\c')", (&SetType{Values: []string{"a", `b\c`}}).SQL())
+}
+
+func TestParseValues(t *testing.T) {
+	require.Equal(t, []string{"Draft", "it's live", "a,b"}, parseValues(`enum('Draft','it''s live','a,b')`))
+	require.Equal(t, []string{"a", `b\c`}, parseValues(`set('a','b\\c')`))
+	require.Equal(t, []string{"Draft"}, ConvertDataTypeToColumnType("ENUM('Draft')").(*ENUMType).Values)
+}
+
 func TestDropTable(t *testing.T) {
 	my := New()
 	dropTable := schema.DropTableChange{
@@ -105,6 +131,13 @@ func TestDropTable(t *testing.T) {
 	require.Equal(t, "DROP TABLE `users`;", sql)
 }
 
+func TestRenameTable(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.RenameTableChange{OldName: "users", NewName: "accounts"})
+	require.NoError(t, err)
+	require.Equal(t, "RENAME TABLE `users` TO `accounts`;", sql)
+}
+
 func TestAddColumn(t *testing.T) {
 	my := New()
 	column := &schema.Column{
@@ -134,6 +167,13 @@ func TestDropColumn(t *testing.T) {
 	require.Equal(t, "ALTER TABLE `users` DROP COLUMN `email`;", sql)
 }
 
+func TestRenameColumn(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.RenameColumnChange{TableName: "users", OldName: "name", NewName: "full_name"})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` RENAME COLUMN `name` TO `full_name`;", sql)
+}
+
 func TestAlterColumn(t *testing.T) {
 	my := New()
 	alterColumn := schema.AlterColumnChange{
@@ -151,6 +191,25 @@ func TestAlterColumn(t *testing.T) {
 	require.Equal(t, "ALTER TABLE `users` MODIFY COLUMN `email` varchar(100) COMMENT 'Updated comment';", sql)
 }
 
+func TestAlterColumnDefault(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: &schema.VarcharType{Length: 20}, Default: "'active'"},
+		Changed:   schema.Attributes{"Default"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` ALTER COLUMN `status` SET DEFAULT 'active';", sql)
+
+	sql, err = New(WithAlgorithm(AlgorithmInstant)).GenerateSQL(schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: &schema.VarcharType{Length: 20}},
+		Changed:   schema.Attributes{"Default"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` ALTER COLUMN `status` DROP DEFAULT, ALGORITHM=INSTANT;", sql)
+}
+
 func TestAddPrimaryKey(t *testing.T) {
 	my := New()
 
@@ -229,6 +288,45 @@ func TestAddIndex(t *testing.T) {
 	require.Equal(t, "CREATE INDEX `idx_users_name_email` ON `users` (`name`, `email`);", sql)
 }
 
+func TestAddIndexWithOptions(t *testing.T) {
+	my := New()
+
+	sql, err := my.GenerateSQL(schema.AddIndexChange{
+		TableName: "posts",
+		Index: &schema.Index{
+			Name:    "idx_posts_title_created",
+			Columns: []string{"title", "created_at"},
+			KeyOptions: []*schema.IndexKeyOption{
+				{Column: "title", Length: 20},
+				{Column: "created_at", Desc: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "CREATE INDEX `idx_posts_title_created` ON `posts` (`title`(20), `created_at` DESC);", sql)
+
+	sql, err = my.GenerateSQL(schema.AddIndexChange{
+		TableName: "posts",
+		Index: &schema.Index{
+			Name:    "idx_posts_body",
+			Columns: []string{"body"},
+			Method:  "FULLTEXT",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "CREATE FULLTEXT INDEX `idx_posts_body` ON `posts` (`body`);", sql)
+
+	_, err = my.GenerateSQL(schema.AddIndexChange{
+		TableName: "posts",
+		Index: &schema.Index{
+			Name:    "idx_posts_published",
+			Columns: []string{"title"},
+			Where:   "published",
+		},
+	})
+	require.EqualError(t, err, "partial indexes not supported in MySQL")
+}
+
 func TestDropIndex(t *testing.T) {
 	my := New()
 	dropIdx := schema.DropIndexChange{
@@ -240,6 +338,13 @@ func TestDropIndex(t *testing.T) {
 	require.Equal(t, "DROP INDEX `idx_users_email` ON `users`;", sql)
 }
 
+func TestRenameIndex(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.RenameIndexChange{TableName: "users", OldName: "idx_email", NewName: "users_email_idx"})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` RENAME INDEX `idx_email` TO `users_email_idx`;", sql)
+}
+
 func TestAddForeignKey(t *testing.T) {
 	my := New()
 
@@ -299,6 +404,54 @@ func TestDropForeignKey(t *testing.T) {
 	require.Equal(t, "ALTER TABLE `posts` DROP FOREIGN KEY `fk_posts_user`;", sql)
 }
 
+func TestAddCheck(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.AddCheckChange{
+		TableName: "products",
+		Check:     &schema.CheckConstraint{Name: "products_price_check", Expression: "price > 0"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `products` ADD CONSTRAINT `products_price_check` CHECK (price > 0);", sql)
+}
+
+func TestNotValidConstraintNotSupported(t *testing.T) {
+	my := New()
+
+	_, err := my.GenerateSQL(&schema.AddCheckChange{
+		TableName: "products",
+		Check:     &schema.CheckConstraint{Name: "products_price_check", Expression: "price > 0"},
+		NotValid:  true,
+	})
+	require.EqualError(t, err, "NOT VALID constraints not supported in MySQL")
+
+	_, err = my.GenerateSQL(schema.ValidateConstraintChange{TableName: "products", ConstraintName: "products_price_check"})
+	require.EqualError(t, err, "NOT VALID constraints not supported in MySQL")
+}
+
+func TestDropCheck(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.DropCheckChange{TableName: "products", CheckName: "products_price_check"})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `products` DROP CHECK `products_price_check`;", sql)
+}
+
+func TestAddUniqueConstraint(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.AddUniqueConstraintChange{
+		TableName: "products",
+		Unique:    &schema.UniqueConstraint{Name: "products_sku_vendor_key", Columns: []string{"sku", "vendor"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `products` ADD CONSTRAINT `products_sku_vendor_key` UNIQUE (`sku`, `vendor`);", sql)
+}
+
+func TestDropUniqueConstraint(t *testing.T) {
+	my := New()
+	sql, err := my.GenerateSQL(schema.DropUniqueConstraintChange{TableName: "products", ConstraintName: "products_sku_key"})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `products` DROP INDEX `products_sku_key`;", sql)
+}
+
 func TestCreateFunction(t *testing.T) {
 	my := New()
 	createFn := schema.CreateFunctionChange{
@@ -493,3 +646,36 @@ func TestQuoteLiteral(t *testing.T) {
 		require.Equal(t, test.expected, quoteLiteral(test.input))
 	}
 }
+
+func TestWithAlgorithmAndLock(t *testing.T) {
+	my := New(WithAlgorithm(AlgorithmInplace), WithLock(LockNone))
+
+	sql, err := my.GenerateSQL(schema.AddColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "email", Type: &schema.VarcharType{Length: 255}, Nullable: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` ADD COLUMN `email` varchar(255), ALGORITHM=INPLACE, LOCK=NONE;", sql)
+
+	sql, err = my.GenerateSQL(schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "email", Type: &schema.VarcharType{Length: 320}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` MODIFY COLUMN `email` varchar(320) NOT NULL, ALGORITHM=INPLACE, LOCK=NONE;", sql)
+
+	sql, err = my.GenerateSQL(schema.AddIndexChange{
+		TableName: "users",
+		Index:     &schema.Index{Name: "idx_users_email", Columns: []string{"email"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "CREATE INDEX `idx_users_email` ON `users` (`email`) ALGORITHM=INPLACE LOCK=NONE;", sql)
+
+	sql, err = my.GenerateSQL(schema.DropIndexChange{TableName: "users", IndexName: "idx_users_email"})
+	require.NoError(t, err)
+	require.Equal(t, "DROP INDEX `idx_users_email` ON `users` ALGORITHM=INPLACE LOCK=NONE;", sql)
+
+	sql, err = New(WithAlgorithm(AlgorithmInstant)).GenerateSQL(schema.DropColumnChange{TableName: "users", ColumnName: "email"})
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `users` DROP COLUMN `email`, ALGORITHM=INSTANT;", sql)
+}
@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/swiftcarrot/dbx"
+)
+
+var _ dbx.Timeouter = (*MySQL)(nil)
+
+// lockWaitTimeoutPattern matches the message of error 1205 (ER_LOCK_WAIT_TIMEOUT)
+var lockWaitTimeoutPattern = regexp.MustCompile(`\bError 1205\b`)
+
+// SetTimeoutsSQL returns the statement that sets lock_wait_timeout for the
+// session, rounded up to whole seconds. A zero timeout restores the global
+// setting. MySQL cannot limit the run time of DDL statements, so the
+// statement timeout is ignored.
+func (my *MySQL) SetTimeoutsSQL(lockTimeout, statementTimeout time.Duration) []string {
+	if lockTimeout <= 0 {
+		return my.ResetTimeoutsSQL()
+	}
+	seconds := (lockTimeout + time.Second - 1) / time.Second
+	return []string{fmt.Sprintf("SET SESSION lock_wait_timeout = %d", seconds)}
+}
+
+// ResetTimeoutsSQL returns the statement that restores the global lock_wait_timeout
+func (my *MySQL) ResetTimeoutsSQL() []string {
+	return []string{"SET SESSION lock_wait_timeout = DEFAULT"}
+}
+
+// IsLockTimeout reports whether err is error 1205, returned when
+// lock_wait_timeout expires
+func (my *MySQL) IsLockTimeout(err error) bool {
+	return err != nil && lockWaitTimeoutPattern.MatchString(err.Error())
+}
@@ -0,0 +1,23 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTimeoutsSQL(t *testing.T) {
+	my := New()
+	require.Equal(t, []string{"SET SESSION lock_wait_timeout = 2"}, my.SetTimeoutsSQL(1500*time.Millisecond, time.Minute))
+	require.Equal(t, []string{"SET SESSION lock_wait_timeout = DEFAULT"}, my.SetTimeoutsSQL(0, time.Minute))
+	require.Equal(t, []string{"SET SESSION lock_wait_timeout = DEFAULT"}, my.ResetTimeoutsSQL())
+}
+
+func TestIsLockTimeout(t *testing.T) {
+	my := New()
+	require.True(t, my.IsLockTimeout(errors.New("Error 1205 (HY000): Lock wait timeout exceeded; try restarting transaction")))
+	require.False(t, my.IsLockTimeout(errors.New("Error 1213 (40001): Deadlock found when trying to get lock")))
+	require.False(t, my.IsLockTimeout(nil))
+}
@@ -0,0 +1,51 @@
+package dbx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// Parser is implemented by dialects that read a schema from a SQL file, such
+// as the output of pg_dump --schema-only, mysqldump --no-data or the sqlite3
+// .schema command, so that a checked-in file can be the target of
+// schema.Diff without a running database
+type Parser interface {
+	// ParseSQL parses the DDL statements of sql into a schema. The statements
+	// it does not support are reported in a *ParseError, returned along with
+	// the schema of the other statements.
+	ParseSQL(sql string) (*schema.Schema, error)
+}
+
+// UnsupportedStatement is a statement of a SQL file that a Parser could not
+// turn into schema objects
+type UnsupportedStatement struct {
+	Line      int    // Line of the file the statement starts on
+	Statement string // Text of the statement, without comments
+	Reason    string
+}
+
+// ParseError is returned by Parser.ParseSQL when a SQL file has statements
+// it does not support
+type ParseError struct {
+	Unsupported []UnsupportedStatement
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dbx: %d unsupported statement(s)", len(e.Unsupported))
+	for _, stmt := range e.Unsupported {
+		fmt.Fprintf(&b, "\nline %d: %s: %s", stmt.Line, stmt.Reason, statementSummary(stmt.Statement))
+	}
+	return b.String()
+}
+
+// statementSummary returns the first line of a statement, shortened
+func statementSummary(statement string) string {
+	summary, _, _ := strings.Cut(statement, "\n")
+	if len(summary) > 60 {
+		summary = summary[:57] + "..."
+	}
+	return summary
+}
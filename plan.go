@@ -0,0 +1,332 @@
+package dbx
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// Plan is the ordered list of changes that migrate a database from a source
+// schema to a target schema, rendered as SQL for a dialect. A plan can be
+// printed for review with SQL or as JSON, and applied later with Apply, which
+// refuses to run when the database no longer matches the source schema.
+type Plan struct {
+	Dialect Dialect
+	Source  *schema.Schema
+	Target  *schema.Schema
+	Steps   []*PlanStep
+}
+
+// PlanStep is a change of a plan and the SQL that applies it
+type PlanStep struct {
+	Change schema.Change
+	SQL    string
+	// Transactional is false when the SQL must run outside a transaction,
+	// see NonTransactional
+	Transactional bool
+}
+
+// NewPlan diffs source against target and renders the SQL of every change
+// for dialect. Source is usually the inspected schema of the database the
+// plan is applied to.
+func NewPlan(dialect Dialect, source, target *schema.Schema, options ...schema.DiffOption) (*Plan, error) {
+	plan := &Plan{Dialect: dialect, Source: source, Target: target}
+	nonTransactional, _ := dialect.(NonTransactional)
+
+	changes := schema.Diff(source, target, options...)
+	ClassifyHazards(dialect, changes)
+	for _, change := range changes {
+		stmt, err := dialect.GenerateSQL(change)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate SQL for %s: %w", change.Type(), err)
+		}
+		plan.Steps = append(plan.Steps, &PlanStep{
+			Change:        change,
+			SQL:           stmt,
+			Transactional: nonTransactional == nil || !nonTransactional.OutsideTransaction(change),
+		})
+	}
+
+	return plan, nil
+}
+
+// Hazards returns the hazards of the changes of the plan
+func (p *Plan) Hazards() []schema.ChangeHazard {
+	var hazards []schema.ChangeHazard
+	for _, step := range p.Steps {
+		for _, hazard := range step.Change.Hazards() {
+			hazards = append(hazards, schema.ChangeHazard{Change: step.Change, Hazard: hazard})
+		}
+	}
+	return hazards
+}
+
+// Checksum returns a SHA-256 digest of the source schema and the SQL of the
+// plan, which stays the same when the plan is computed again for the same
+// schemas and dialect
+func (p *Plan) Checksum() string {
+	h := sha256.New()
+	h.Write([]byte(p.Source.String()))
+	for _, step := range p.Steps {
+		fmt.Fprintf(h, "\x00%t\x00%s", step.Transactional, step.SQL)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SQL returns the plan as a SQL script for review. Each change is preceded by
+// comments listing its hazards, and consecutive changes that can run in a
+// transaction are wrapped in BEGIN and COMMIT.
+func (p *Plan) SQL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Plan %s\n", p.Checksum())
+
+	for i, step := range p.Steps {
+		b.WriteString("\n")
+		if step.Transactional && (i == 0 || !p.Steps[i-1].Transactional) {
+			b.WriteString("BEGIN;\n\n")
+		}
+
+		fmt.Fprintf(&b, "-- %d. %s", i+1, step.Change.Type())
+		if !step.Transactional {
+			b.WriteString(" (outside a transaction)")
+		}
+		b.WriteString("\n")
+		for _, hazard := range step.Change.Hazards() {
+			fmt.Fprintf(&b, "-- hazard %s\n", hazard)
+		}
+		if step.SQL == "" {
+			b.WriteString("-- applied by another step\n")
+		} else {
+			b.WriteString(strings.TrimRight(step.SQL, "; \n"))
+			b.WriteString(";\n")
+		}
+
+		if step.Transactional && (i == len(p.Steps)-1 || !p.Steps[i+1].Transactional) {
+			b.WriteString("\nCOMMIT;\n")
+		}
+	}
+
+	return b.String()
+}
+
+// planJSON is the JSON representation of a plan
+type planJSON struct {
+	Checksum string          `json:"checksum"`
+	Source   json.RawMessage `json:"source"`
+	Target   json.RawMessage `json:"target"`
+	Steps    []planStepJSON  `json:"steps"`
+}
+
+type planStepJSON struct {
+	Type          schema.ChangeType `json:"type"`
+	SQL           string            `json:"sql"`
+	Transactional bool              `json:"transactional"`
+	Hazards       []schema.Hazard   `json:"hazards,omitempty"`
+}
+
+// MarshalJSON encodes the checksum of the plan, its source and target schemas
+// in the format of schema.Marshal, and the type, SQL, transaction mode and
+// hazards of each change
+func (p *Plan) MarshalJSON() ([]byte, error) {
+	source, err := schema.Marshal(p.Source, schema.FormatJSON)
+	if err != nil {
+		return nil, err
+	}
+	target, err := schema.Marshal(p.Target, schema.FormatJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	out := planJSON{Checksum: p.Checksum(), Source: source, Target: target, Steps: []planStepJSON{}}
+	for _, step := range p.Steps {
+		out.Steps = append(out.Steps, planStepJSON{
+			Type:          step.Change.Type(),
+			SQL:           step.SQL,
+			Transactional: step.Transactional,
+			Hazards:       step.Change.Hazards(),
+		})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalPlan decodes a plan encoded as JSON by MarshalJSON. The plan is
+// computed again from its schemas for dialect with options, and rejected
+// unless its checksum matches the encoded one, so that the SQL applied is the
+// SQL that was reviewed.
+func UnmarshalPlan(data []byte, dialect Dialect, options ...schema.DiffOption) (*Plan, error) {
+	var in planJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("dbx: %w", err)
+	}
+	source, err := schema.Unmarshal(in.Source, schema.FormatJSON)
+	if err != nil {
+		return nil, fmt.Errorf("dbx: plan source: %w", err)
+	}
+	target, err := schema.Unmarshal(in.Target, schema.FormatJSON)
+	if err != nil {
+		return nil, fmt.Errorf("dbx: plan target: %w", err)
+	}
+
+	plan, err := NewPlan(dialect, source, target, options...)
+	if err != nil {
+		return nil, err
+	}
+	if checksum := plan.Checksum(); checksum != in.Checksum {
+		return nil, fmt.Errorf("dbx: plan checksum %s does not match checksum %s computed from its schemas", in.Checksum, checksum)
+	}
+	return plan, nil
+}
+
+// PlanDriftError is returned by Plan.Apply when the database no longer
+// matches the source schema of the plan
+type PlanDriftError struct {
+	// Changes turn the source schema of the plan into the current schema
+	Changes []schema.Change
+}
+
+func (e *PlanDriftError) Error() string {
+	types := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		types[i] = string(change.Type())
+	}
+	return fmt.Sprintf("dbx: database no longer matches the source schema of the plan: %s", strings.Join(types, ", "))
+}
+
+// DefaultApplyLockName is the name of the lock Plan.Apply holds by default. It
+// is the migrator's default too, so that a plan and migrations do not run at
+// the same time.
+const DefaultApplyLockName = "schema_migrations"
+
+// ApplyOption configures Plan.Apply
+type ApplyOption func(*applyConfig)
+
+type applyConfig struct {
+	lockName    string
+	lockTimeout time.Duration
+}
+
+// WithApplyLock sets the name of the lock Plan.Apply holds on dialects that
+// implement Locker, and how long it waits for it. A timeout of zero or less
+// waits until the context is done.
+func WithApplyLock(name string, timeout time.Duration) ApplyOption {
+	return func(c *applyConfig) {
+		c.lockName = name
+		c.lockTimeout = timeout
+	}
+}
+
+// Apply verifies that the database still matches the source schema of the
+// plan and executes its SQL, holding the dialect's lock when it implements
+// Locker so that the database cannot change in between. Consecutive
+// transactional steps run in one transaction, the other steps run on their
+// own, so a failure leaves the transactions committed before it applied.
+func (p *Plan) Apply(ctx context.Context, db *sql.DB, options ...ApplyOption) (err error) {
+	config := &applyConfig{lockName: DefaultApplyLockName}
+	for _, option := range options {
+		option(config)
+	}
+
+	if locker, ok := p.Dialect.(Locker); ok {
+		unlock, err := locker.Lock(ctx, db, config.lockName, config.lockTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		defer func() {
+			if unlockErr := unlock(); unlockErr != nil && err == nil {
+				err = fmt.Errorf("failed to release lock: %w", unlockErr)
+			}
+		}()
+	}
+
+	current, err := p.Dialect.InspectContext(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to inspect database: %w", err)
+	}
+	if changes := schema.Diff(p.Source, current); len(changes) > 0 {
+		return &PlanDriftError{Changes: changes}
+	}
+
+	// The steps run on one connection, whose settings the dialect may change
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	check := func(context.Context, Querier) error { return nil }
+	if preparer, ok := p.Dialect.(ConnPreparer); ok {
+		changes := make([]schema.Change, len(p.Steps))
+		for i, step := range p.Steps {
+			changes[i] = step.Change
+		}
+		var restore func() error
+		check, restore, err = preparer.PrepareConn(ctx, conn, changes)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if restoreErr := restore(); restoreErr != nil && err == nil {
+				err = restoreErr
+			}
+		}()
+	}
+
+	for i := 0; i < len(p.Steps); {
+		if !p.Steps[i].Transactional {
+			if err := p.Steps[i].exec(ctx, conn); err != nil {
+				return err
+			}
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(p.Steps) && p.Steps[j].Transactional {
+			j++
+		}
+		if err := p.applyInTransaction(ctx, conn, p.Steps[i:j], check); err != nil {
+			return err
+		}
+		i = j
+	}
+
+	return nil
+}
+
+// applyInTransaction executes steps in a transaction and verifies them with
+// check before committing
+func (p *Plan) applyInTransaction(ctx context.Context, conn *sql.Conn, steps []*PlanStep, check func(context.Context, Querier) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, step := range steps {
+		if err := step.exec(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if err := check(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// exec executes the SQL of a step
+func (s *PlanStep) exec(ctx context.Context, db Querier) error {
+	if s.SQL == "" {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, s.SQL); err != nil {
+		return fmt.Errorf("failed to execute %q: %w", s.SQL, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package dbx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/postgresql"
+	"github.com/swiftcarrot/dbx/schema"
+	"github.com/swiftcarrot/dbx/sqlite"
+)
+
+// openPlanDB opens an SQLite database with a users table
+func openPlanDB(t *testing.T) *dbx.DB {
+	db, err := dbx.Open("sqlite3", filepath.Join(t.TempDir(), "plan.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER NOT NULL, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	return db
+}
+
+// planSchemas returns a users table, and the same table with a bio column and an index on name
+func planSchemas(integerType, textType schema.ColumnType) (*schema.Schema, *schema.Schema) {
+	source := schema.NewSchema()
+	source.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", integerType)
+		t.Column("name", textType)
+	})
+
+	target := schema.NewSchema()
+	target.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", integerType)
+		t.Column("name", textType)
+		t.Column("bio", textType, schema.Nullable)
+		t.Index("users_name_idx", []string{"name"})
+	})
+	return source, target
+}
+
+func TestPlanSQL(t *testing.T) {
+	source, target := planSchemas(&schema.IntegerType{}, &schema.TextType{})
+	plan, err := dbx.NewPlan(postgresql.New(postgresql.WithConcurrentIndexes()), source, target)
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 2)
+	require.True(t, plan.Steps[0].Transactional)
+	require.False(t, plan.Steps[1].Transactional)
+	require.Len(t, plan.Hazards(), 1)
+
+	require.Equal(t, `-- Plan `+plan.Checksum()+`
+
+BEGIN;
+
+-- 1. add_column
+ALTER TABLE "users" ADD COLUMN "bio" text;
+
+COMMIT;
+
+-- 2. add_index (outside a transaction)
+-- hazard index_build: adding index users_name_idx builds it on users
+CREATE INDEX CONCURRENTLY "users_name_idx" ON "users" ("name");
+`, plan.SQL())
+}
+
+func TestPlanAddEnumValue(t *testing.T) {
+	source := schema.NewSchema()
+	source.CreateEnum("mood", "sad", "happy")
+	source.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &schema.IntegerType{})
+	})
+
+	target := schema.NewSchema()
+	target.CreateEnum("mood", "sad", "ok", "happy")
+	target.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &schema.IntegerType{})
+		t.Column("mood", &postgresql.EnumType{Name: "mood"}, schema.Default("'ok'"))
+	})
+
+	plan, err := dbx.NewPlan(postgresql.New(), source, target)
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 2)
+	require.Equal(t, schema.AddEnumValue, plan.Steps[0].Change.Type())
+	require.False(t, plan.Steps[0].Transactional)
+	require.Equal(t, schema.AddColumn, plan.Steps[1].Change.Type())
+	require.True(t, plan.Steps[1].Transactional)
+}
+
+func TestPlanChecksum(t *testing.T) {
+	source, target := planSchemas(&schema.IntegerType{}, &schema.TextType{})
+	plan, err := dbx.NewPlan(postgresql.New(), source, target)
+	require.NoError(t, err)
+	require.Len(t, plan.Checksum(), 64)
+
+	source, target = planSchemas(&schema.IntegerType{}, &schema.TextType{})
+	again, err := dbx.NewPlan(postgresql.New(), source, target)
+	require.NoError(t, err)
+	require.Equal(t, plan.Checksum(), again.Checksum())
+
+	concurrent, err := dbx.NewPlan(postgresql.New(postgresql.WithConcurrentIndexes()), source, target)
+	require.NoError(t, err)
+	require.NotEqual(t, plan.Checksum(), concurrent.Checksum())
+
+	source.Tables[0].Column("email", &schema.TextType{})
+	drifted, err := dbx.NewPlan(postgresql.New(), source, target)
+	require.NoError(t, err)
+	require.NotEqual(t, plan.Checksum(), drifted.Checksum())
+}
+
+func TestPlanJSON(t *testing.T) {
+	source, target := planSchemas(&schema.IntegerType{}, &schema.TextType{})
+	plan, err := dbx.NewPlan(postgresql.New(), source, target)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(plan)
+	require.NoError(t, err)
+	sourceJSON, err := schema.Marshal(source, schema.FormatJSON)
+	require.NoError(t, err)
+	targetJSON, err := schema.Marshal(target, schema.FormatJSON)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"checksum": "`+plan.Checksum()+`",
+		"source": `+string(sourceJSON)+`,
+		"target": `+string(targetJSON)+`,
+		"steps": [
+			{"type": "add_column", "sql": "ALTER TABLE \"users\" ADD COLUMN \"bio\" text;", "transactional": true},
+			{"type": "add_index", "sql": "CREATE INDEX \"users_name_idx\" ON \"users\" (\"name\");", "transactional": true, "hazards": [
+				{"type": "index_build", "reason": "adding index users_name_idx builds it on users"},
+				{"type": "long_lock", "reason": "writes to users are blocked while index users_name_idx is built"}
+			]}
+		]
+	}`, string(b))
+}
+
+func TestUnmarshalPlan(t *testing.T) {
+	db := openPlanDB(t)
+	_, target := planSchemas(&sqlite.IntegerType{}, &sqlite.TextType{})
+	plan, err := db.Plan(context.Background(), target)
+	require.NoError(t, err)
+	b, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	loaded, err := dbx.UnmarshalPlan(b, sqlite.New())
+	require.NoError(t, err)
+	require.Equal(t, plan.Checksum(), loaded.Checksum())
+	require.Equal(t, plan.SQL(), loaded.SQL())
+	require.NoError(t, loaded.Apply(context.Background(), db.DB))
+
+	// The target schema was edited after the plan was reviewed
+	_, err = dbx.UnmarshalPlan(bytes.ReplaceAll(b, []byte(`"bio"`), []byte(`"about"`)), sqlite.New())
+	require.ErrorContains(t, err, "does not match checksum")
+}
+
+func TestPlanApplyLock(t *testing.T) {
+	db := openPlanDB(t)
+	_, target := planSchemas(&sqlite.IntegerType{}, &sqlite.TextType{})
+	plan, err := db.Plan(context.Background(), target)
+	require.NoError(t, err)
+
+	unlock, err := sqlite.New().Lock(context.Background(), db.DB, dbx.DefaultApplyLockName, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, unlock())
+	})
+
+	var timeoutErr *dbx.LockTimeoutError
+	require.ErrorAs(t, plan.Apply(context.Background(), db.DB, dbx.WithApplyLock(dbx.DefaultApplyLockName, 10*time.Millisecond)), &timeoutErr)
+	s, err := db.Inspect()
+	require.NoError(t, err)
+	require.Len(t, s.Tables[0].Columns, 2)
+}
+
+func TestPlanApply(t *testing.T) {
+	db := openPlanDB(t)
+	_, target := planSchemas(&sqlite.IntegerType{}, &sqlite.TextType{})
+	plan, err := db.Plan(context.Background(), target)
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 2)
+
+	require.NoError(t, plan.Apply(context.Background(), db.DB))
+	s, err := db.Inspect()
+	require.NoError(t, err)
+	require.Len(t, s.Tables[0].Columns, 3)
+	require.Equal(t, "bio", s.Tables[0].Columns[2].Name)
+	require.Len(t, s.Tables[0].Indexes, 1)
+
+	// The database no longer matches the source of the plan
+	var driftErr *dbx.PlanDriftError
+	require.ErrorAs(t, plan.Apply(context.Background(), db.DB), &driftErr)
+}
+
+func TestPlanApplyDrift(t *testing.T) {
+	db := openPlanDB(t)
+	_, target := planSchemas(&sqlite.IntegerType{}, &sqlite.TextType{})
+	plan, err := db.Plan(context.Background(), target)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`ALTER TABLE users ADD COLUMN email TEXT`)
+	require.NoError(t, err)
+
+	err = plan.Apply(context.Background(), db.DB)
+	var driftErr *dbx.PlanDriftError
+	require.ErrorAs(t, err, &driftErr)
+	require.Len(t, driftErr.Changes, 1)
+	require.EqualError(t, err, "dbx: database no longer matches the source schema of the plan: add_column")
+
+	s, err := db.Inspect()
+	require.NoError(t, err)
+	require.Len(t, s.Tables[0].Columns, 3)
+	require.Empty(t, s.Tables[0].Indexes)
+}
@@ -2,6 +2,19 @@ package postgresql
 
 import "github.com/swiftcarrot/dbx/schema"
 
+func init() {
+	schema.RegisterColumnType("postgresql.serial", &SerialType{})
+	schema.RegisterColumnType("postgresql.json", &JSONType{})
+	schema.RegisterColumnType("postgresql.jsonb", &JSONBType{})
+	schema.RegisterColumnType("postgresql.array", &ArrayType{})
+	schema.RegisterColumnType("postgresql.enum", &EnumType{})
+	schema.RegisterColumnType("postgresql.interval", &IntervalType{})
+	schema.RegisterColumnType("postgresql.cidr", &CIDRType{})
+	schema.RegisterColumnType("postgresql.inet", &INETType{})
+	schema.RegisterColumnType("postgresql.macaddr", &MACAddrType{})
+	schema.RegisterColumnType("postgresql.bigserial", &BigSerialType{})
+}
+
 // SerialType represents a SERIAL column type in PostgreSQL for auto-incrementing integers
 type SerialType struct{}
 
@@ -32,6 +45,16 @@ func (t *ArrayType) SQL() string {
 	return t.ElementType.SQL() + "[]"
 }
 
+// EnumType represents a column whose type is an enum type of the schema
+type EnumType struct {
+	Schema string // Schema of the enum type, the default schema when empty
+	Name   string
+}
+
+func (t *EnumType) SQL() string {
+	return quoteIdentifier(qualifiedName(t.Schema, t.Name))
+}
+
 // IntervalType represents an INTERVAL column type in PostgreSQL
 type IntervalType struct{}
 
@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestColumnTypesRoundTrip(t *testing.T) {
+	s := schema.NewSchema()
+	s.CreateTable("events", func(t *schema.Table) {
+		t.Column("id", &BigSerialType{})
+		t.Column("payload", &JSONBType{})
+		t.Column("tags", &ArrayType{ElementType: &schema.VarcharType{Length: 50}})
+		t.Column("status", &EnumType{Schema: "audit", Name: "status"})
+		t.Column("source", &INETType{}, schema.Nullable)
+	})
+
+	data, err := schema.Marshal(s, schema.FormatYAML)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `      - name: tags
+        type:
+          kind: postgresql.array
+          element_type:
+            kind: varchar
+            length: 50
+`)
+
+	decoded, err := schema.Unmarshal(data, schema.FormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, s, decoded)
+}
+
+func TestInspectedSchemaRoundTrip(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TYPE test_status AS ENUM ('active', 'inactive');
+		CREATE TABLE test_events (
+			id bigserial PRIMARY KEY,
+			payload jsonb NOT NULL DEFAULT '{}',
+			tags varchar(50)[],
+			status test_status,
+			created_at timestamptz DEFAULT now()
+		);
+		CREATE INDEX test_events_payload_idx ON test_events USING gin (payload);
+		CREATE VIEW test_active_events AS SELECT id FROM test_events WHERE status = 'active';
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`
+			DROP VIEW IF EXISTS test_active_events;
+			DROP TABLE IF EXISTS test_events;
+			DROP TYPE IF EXISTS test_status;
+		`)
+		require.NoError(t, err)
+	})
+
+	inspected, err := New().Inspect(db)
+	require.NoError(t, err)
+
+	data, err := schema.Marshal(inspected, schema.FormatYAML)
+	require.NoError(t, err)
+	decoded, err := schema.Unmarshal(data, schema.FormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, inspected, decoded)
+}
@@ -32,51 +32,60 @@ func ConvertDataTypeToColumnType(dataType string) schema.ColumnType {
 		return ConvertDataTypeToColumnType(baseType)
 	}
 
+	if columnType, ok := lookupDataType(dataType); ok {
+		return columnType
+	}
+
+	// If we can't determine the type, create a custom PostgreSQL type
+	// In a real implementation, you might want to handle this differently
+	fmt.Printf("Warning: Unknown PostgreSQL data type: %s\n", dataType)
+	return &schema.TextType{} // Fallback to text type
+}
+
+// lookupDataType returns the column type of a data type name without
+// parameters, and false when the name is unknown
+func lookupDataType(dataType string) (schema.ColumnType, bool) {
 	switch dataType {
 	case "integer", "int", "int4":
-		return &schema.IntegerType{}
+		return &schema.IntegerType{}, true
 	case "bigint", "int8":
-		return &schema.BigIntType{}
+		return &schema.BigIntType{}, true
 	case "smallint", "int2":
-		return &schema.SmallIntType{}
+		return &schema.SmallIntType{}, true
 	case "text":
-		return &schema.TextType{}
+		return &schema.TextType{}, true
 	case "boolean", "bool":
-		return &schema.BooleanType{}
+		return &schema.BooleanType{}, true
 	case "real", "float4":
-		return &schema.FloatType{}
+		return &schema.FloatType{}, true
 	case "double precision", "float8":
-		return &schema.FloatType{}
+		return &schema.FloatType{}, true
 	case "numeric", "decimal":
-		return &schema.DecimalType{}
+		return &schema.DecimalType{}, true
 	case "varchar", "character varying":
-		return &schema.VarcharType{}
+		return &schema.VarcharType{}, true
 	case "timestamp", "timestamp without time zone":
-		return &schema.TimestampType{WithTimeZone: false}
+		return &schema.TimestampType{WithTimeZone: false}, true
 	case "timestamptz", "timestamp with time zone":
-		return &schema.TimestampType{WithTimeZone: true}
+		return &schema.TimestampType{WithTimeZone: true}, true
 	case "date":
-		return &schema.DateType{}
+		return &schema.DateType{}, true
 	case "time", "time without time zone":
-		return &schema.TimeType{}
+		return &schema.TimeType{}, true
 	case "uuid":
-		return &schema.UUIDType{}
+		return &schema.UUIDType{}, true
 	case "bytea":
-		return &schema.BlobType{}
+		return &schema.BlobType{}, true
 	case "json":
-		return &JSONType{}
+		return &JSONType{}, true
 	case "jsonb":
-		return &JSONBType{}
+		return &JSONBType{}, true
 	case "interval":
-		return &IntervalType{}
+		return &IntervalType{}, true
 	case "cidr":
-		return &CIDRType{}
+		return &CIDRType{}, true
 	case "inet":
-		return &INETType{}
-	default:
-		// If we can't determine the type, create a custom PostgreSQL type
-		// In a real implementation, you might want to handle this differently
-		fmt.Printf("Warning: Unknown PostgreSQL data type: %s\n", dataType)
-		return &schema.TextType{} // Fallback to text type
+		return &INETType{}, true
 	}
+	return nil, false
 }
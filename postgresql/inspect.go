@@ -1,85 +1,170 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
+func init() {
+	dbx.Register("postgres", New())
+	dbx.Register("pgx", New())
+}
+
 // PostgreSQL implements the Dialect interface for PostgreSQL databases
-type PostgreSQL struct{}
+type PostgreSQL struct {
+	schemas           []string       // Schemas to inspect, only public when empty
+	excludeSchemas    []string       // Schemas to leave out of inspection
+	concurrentIndexes bool           // Create and drop every index concurrently
+	filter            *schema.Filter // Objects Inspect keeps
+}
+
+// Option configures a PostgreSQL dialect
+type Option func(*PostgreSQL)
+
+// WithSchemas sets the schemas that Inspect reads. Tables outside the public
+// schema have their schema in Table.Schema.
+func WithSchemas(names ...string) Option {
+	return func(pg *PostgreSQL) {
+		pg.schemas = names
+	}
+}
+
+// WithoutSchemas leaves schemas out of inspection. Without WithSchemas, every
+// schema except the given ones and the system schemas is inspected.
+func WithoutSchemas(names ...string) Option {
+	return func(pg *PostgreSQL) {
+		pg.excludeSchemas = names
+	}
+}
+
+// WithConcurrentIndexes makes GenerateSQL create and drop every index with
+// CONCURRENTLY, as if Concurrently were set on each index change. Writes to
+// the table are not blocked while the index is built, but the statements
+// cannot run inside a transaction.
+func WithConcurrentIndexes() Option {
+	return func(pg *PostgreSQL) {
+		pg.concurrentIndexes = true
+	}
+}
+
+// WithFilter makes Inspect leave out the objects that filter does not keep,
+// such as tables owned by extensions or other tools
+func WithFilter(filter *schema.Filter) Option {
+	return func(pg *PostgreSQL) {
+		pg.filter = filter
+	}
+}
 
 // New creates a new PostgreSQL dialect
-func New() *PostgreSQL {
-	return &PostgreSQL{}
+func New(options ...Option) *PostgreSQL {
+	pg := &PostgreSQL{}
+	for _, option := range options {
+		option(pg)
+	}
+	return pg
 }
 
 // Inspect queries the PostgreSQL database and returns its schema
-func (pg *PostgreSQL) Inspect(db *sql.DB) (*schema.Schema, error) {
+func (pg *PostgreSQL) Inspect(db dbx.Querier) (*schema.Schema, error) {
+	return pg.InspectContext(context.Background(), db)
+}
+
+// InspectContext queries the PostgreSQL database and returns its schema, using ctx for every catalog query
+func (pg *PostgreSQL) InspectContext(ctx context.Context, db dbx.Querier) (*schema.Schema, error) {
 	// Create a new schema with default "public" schema name
 	s := schema.NewSchema()
 
+	// Get the other schemas that exist, so that Diff does not create them
+	schemas, err := pg.InspectSchemasContext(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schemas: %w", err)
+	}
+	for _, name := range schemas {
+		if name != s.Name {
+			s.Namespaces = append(s.Namespaces, name)
+		}
+	}
+
 	// Get installed extensions
-	if err := pg.InspectExtensions(db, s); err != nil {
+	if err := pg.InspectExtensionsContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("failed to get extensions: %w", err)
 	}
 
+	// Get enum types
+	if err := pg.InspectEnumsContext(ctx, db, s); err != nil {
+		return nil, fmt.Errorf("failed to get enums: %w", err)
+	}
+
 	// Get sequences
-	if err := pg.InspectSequences(db, s); err != nil {
+	if err := pg.InspectSequencesContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("failed to get sequences: %w", err)
 	}
 
 	// Get functions
-	if err := pg.InspectFunctions(db, s); err != nil {
+	if err := pg.InspectFunctionsContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("failed to get functions: %w", err)
 	}
 
 	// Get views
-	if err := pg.InspectViews(db, s); err != nil {
+	if err := pg.InspectViewsContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("failed to get views: %w", err)
 	}
 
 	// Get row policies
-	if err := pg.InspectRowPolicies(db, s); err != nil {
+	if err := pg.InspectRowPoliciesContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("failed to get row policies: %w", err)
 	}
 
-	// Get tables in public schema
-	tables, err := pg.InspectTables(db)
+	// Get tables in the inspected schemas
+	tables, err := pg.InspectTablesContext(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tables: %w", err)
 	}
 
-	// For each table, get its columns, primary key, indexes, and foreign keys
+	// For each table, get its columns, primary key, indexes, foreign keys and constraints
 	for _, tableName := range tables {
-		table := s.CreateTable(tableName, nil)
+		schemaName, name := splitQualifiedName(tableName)
+		table := s.CreateTable(name, nil)
+		table.Schema = schemaName
 
 		// Get columns
-		if err := pg.InspectColumns(db, table); err != nil {
+		if err := pg.InspectColumnsContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get columns for table %s: %w", tableName, err)
 		}
 
 		// Get primary key
-		if err := pg.InspectPrimaryKey(db, table); err != nil {
+		if err := pg.InspectPrimaryKeyContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get primary key for table %s: %w", tableName, err)
 		}
 
 		// Get indexes
-		if err := pg.InspectIndexes(db, table); err != nil {
+		if err := pg.InspectIndexesContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get indexes for table %s: %w", tableName, err)
 		}
 
 		// Get foreign keys
-		if err := pg.InspectForeignKeys(db, table); err != nil {
+		if err := pg.InspectForeignKeysContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get foreign keys for table %s: %w", tableName, err)
 		}
+
+		// Get check constraints
+		if err := pg.InspectChecksContext(ctx, db, table); err != nil {
+			return nil, fmt.Errorf("failed to get check constraints for table %s: %w", tableName, err)
+		}
+
+		// Get unique constraints
+		if err := pg.InspectUniquesContext(ctx, db, table); err != nil {
+			return nil, fmt.Errorf("failed to get unique constraints for table %s: %w", tableName, err)
+		}
 	}
 
 	// Get triggers (after tables to ensure proper dependencies)
-	if err := pg.InspectTriggers(db, s); err != nil {
+	if err := pg.InspectTriggersContext(ctx, db, s); err != nil {
 		return nil, fmt.Errorf("failed to get triggers: %w", err)
 	}
 
-	return s, nil
+	return pg.filter.Apply(s), nil
 }
@@ -1,13 +1,20 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectColumns gets all columns for a table
-func (pg *PostgreSQL) InspectColumns(db *sql.DB, table *schema.Table) error {
+func (pg *PostgreSQL) InspectColumns(db dbx.Querier, table *schema.Table) error {
+	return pg.InspectColumnsContext(context.Background(), db, table)
+}
+
+// InspectColumnsContext gets all columns for a table, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectColumnsContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := `
 		SELECT
 			c.column_name,
@@ -16,17 +23,22 @@ func (pg *PostgreSQL) InspectColumns(db *sql.DB, table *schema.Table) error {
 			c.column_default,
 			c.numeric_precision,
 			c.numeric_scale,
-			pd.description AS column_comment
+			pd.description AS column_comment,
+			c.udt_schema,
+			c.udt_name,
+			ut.typtype
 		FROM information_schema.columns c
+		LEFT JOIN pg_catalog.pg_namespace un ON un.nspname = c.udt_schema
+		LEFT JOIN pg_catalog.pg_type ut ON ut.typnamespace = un.oid AND ut.typname = c.udt_name
 		LEFT JOIN pg_catalog.pg_statio_all_tables st ON c.table_schema = st.schemaname AND c.table_name = st.relname
 		LEFT JOIN pg_catalog.pg_description pd ON st.relid = pd.objoid
 			AND pd.objsubid = c.ordinal_position
-		WHERE c.table_schema = 'public'
-		AND c.table_name = $1
+		WHERE c.table_schema = $1
+		AND c.table_name = $2
 		ORDER BY c.ordinal_position
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, tableSchema(table), table.Name)
 	if err != nil {
 		return err
 	}
@@ -35,9 +47,9 @@ func (pg *PostgreSQL) InspectColumns(db *sql.DB, table *schema.Table) error {
 	for rows.Next() {
 		var colName, dataType, nullable, defaultValue sql.NullString
 		var precision, scale sql.NullInt64
-		var comment sql.NullString
+		var comment, udtSchema, udtName, typeType sql.NullString
 
-		if err := rows.Scan(&colName, &dataType, &nullable, &defaultValue, &precision, &scale, &comment); err != nil {
+		if err := rows.Scan(&colName, &dataType, &nullable, &defaultValue, &precision, &scale, &comment, &udtSchema, &udtName, &typeType); err != nil {
 			return err
 		}
 
@@ -56,7 +68,16 @@ func (pg *PostgreSQL) InspectColumns(db *sql.DB, table *schema.Table) error {
 			options = append(options, schema.Comment(comment.String))
 		}
 
-		columnType := ConvertDataTypeToColumnType(dataType.String)
+		var columnType schema.ColumnType
+		if typeType.String == "e" {
+			enumSchema := udtSchema.String
+			if enumSchema == "public" {
+				enumSchema = ""
+			}
+			columnType = &EnumType{Schema: enumSchema, Name: udtName.String}
+		} else {
+			columnType = ConvertDataTypeToColumnType(dataType.String)
+		}
 		// Set precision/scale for decimal/numeric
 		if decType, ok := columnType.(*schema.DecimalType); ok {
 			if precision.Valid {
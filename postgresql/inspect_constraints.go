@@ -0,0 +1,119 @@
+package postgresql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// InspectChecks gets the check constraints of a table
+func (pg *PostgreSQL) InspectChecks(db dbx.Querier, table *schema.Table) error {
+	return pg.InspectChecksContext(context.Background(), db, table)
+}
+
+// InspectChecksContext gets the check constraints of a table, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectChecksContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	query := `
+		SELECT
+			con.conname,
+			pg_get_constraintdef(con.oid),
+			con.convalidated
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		AND c.relname = $2
+		AND con.contype = 'c'
+		ORDER BY con.conname
+	`
+
+	rows, err := db.QueryContext(ctx, query, tableSchema(table), table.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, definition string
+		var validated bool
+		if err := rows.Scan(&name, &definition, &validated); err != nil {
+			return err
+		}
+		table.Check(name, checkExpression(definition)).NotValid = !validated
+	}
+
+	return rows.Err()
+}
+
+// InspectUniques gets the unique constraints of a table
+func (pg *PostgreSQL) InspectUniques(db dbx.Querier, table *schema.Table) error {
+	return pg.InspectUniquesContext(context.Background(), db, table)
+}
+
+// InspectUniquesContext gets the unique constraints of a table, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectUniquesContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	query := `
+		SELECT
+			con.conname,
+			array_agg(a.attname ORDER BY k.ordinality) AS columns
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL unnest(con.conkey) WITH ORDINALITY AS k(attnum, ordinality)
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+		WHERE n.nspname = $1
+		AND c.relname = $2
+		AND con.contype = 'u'
+		GROUP BY con.conname
+		ORDER BY con.conname
+	`
+
+	rows, err := db.QueryContext(ctx, query, tableSchema(table), table.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, columns string
+		if err := rows.Scan(&name, &columns); err != nil {
+			return err
+		}
+		table.Unique(name, PostgresArrayToSlice(columns)...)
+	}
+
+	return rows.Err()
+}
+
+// checkExpression extracts the expression from a definition returned by
+// pg_get_constraintdef, e.g. "CHECK ((price > 0))" becomes "price > 0"
+func checkExpression(definition string) string {
+	expression := strings.TrimSpace(strings.TrimPrefix(definition, "CHECK "))
+	expression = strings.TrimSuffix(expression, " NOT VALID")
+	for i := 0; i < 2 && isWrapped(expression); i++ {
+		expression = strings.TrimSpace(expression[1 : len(expression)-1])
+	}
+	return expression
+}
+
+// isWrapped reports whether the whole expression is enclosed in one pair of parentheses
+func isWrapped(expression string) bool {
+	if !strings.HasPrefix(expression, "(") || !strings.HasSuffix(expression, ")") {
+		return false
+	}
+	depth := 0
+	for i, r := range expression {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(expression)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
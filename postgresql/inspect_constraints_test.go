@@ -0,0 +1,93 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestInspectConstraints(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE test_constraint_products (
+			id serial PRIMARY KEY,
+			sku text NOT NULL,
+			vendor text NOT NULL,
+			price integer NOT NULL,
+			CONSTRAINT products_vendor_sku_key UNIQUE (vendor, sku),
+			CONSTRAINT products_price_check CHECK (price > 0)
+		);
+
+		CREATE UNIQUE INDEX idx_products_sku ON test_constraint_products (sku);
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS test_constraint_products`)
+		require.NoError(t, err)
+	})
+
+	pg := New()
+	table := &schema.Table{
+		Name:   "test_constraint_products",
+		Schema: "public",
+	}
+	require.NoError(t, pg.InspectChecks(db, table))
+	require.NoError(t, pg.InspectUniques(db, table))
+	require.NoError(t, pg.InspectIndexes(db, table))
+
+	require.Equal(t, []*schema.CheckConstraint{
+		{Name: "products_price_check", Expression: "price > 0"},
+	}, table.Checks)
+	require.Equal(t, []*schema.UniqueConstraint{
+		{Name: "products_vendor_sku_key", Columns: []string{"vendor", "sku"}},
+	}, table.Uniques)
+	require.Equal(t, []*schema.Index{
+		{Name: "idx_products_sku", Columns: []string{"sku"}, Unique: true},
+	}, table.Indexes)
+}
+
+func TestInspectNotValidConstraints(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE test_not_valid_users (id integer PRIMARY KEY);
+		CREATE TABLE test_not_valid_posts (id integer PRIMARY KEY, user_id integer);
+		ALTER TABLE test_not_valid_posts ADD CONSTRAINT posts_user_id_fkey
+			FOREIGN KEY (user_id) REFERENCES test_not_valid_users (id) NOT VALID;
+		ALTER TABLE test_not_valid_posts ADD CONSTRAINT posts_id_check CHECK (id > 0) NOT VALID;
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS test_not_valid_posts, test_not_valid_users`)
+		require.NoError(t, err)
+	})
+
+	pg := New()
+	table := &schema.Table{Name: "test_not_valid_posts", Schema: "public"}
+	require.NoError(t, pg.InspectChecks(db, table))
+	require.NoError(t, pg.InspectForeignKeys(db, table))
+
+	require.Equal(t, []*schema.CheckConstraint{
+		{Name: "posts_id_check", Expression: "id > 0", NotValid: true},
+	}, table.Checks)
+	require.Equal(t, []*schema.ForeignKey{
+		{
+			Name:       "posts_user_id_fkey",
+			Columns:    []string{"user_id"},
+			RefTable:   "test_not_valid_users",
+			RefColumns: []string{"id"},
+			NotValid:   true,
+		},
+	}, table.ForeignKeys)
+}
+
+func TestCheckExpression(t *testing.T) {
+	require.Equal(t, "price > 0", checkExpression("CHECK ((price > 0))"))
+	require.Equal(t, "(price > 0) AND (price < 100)", checkExpression("CHECK (((price > 0) AND (price < 100)))"))
+	require.Equal(t, "price > 0", checkExpression("CHECK ((price > 0)) NOT VALID"))
+}
@@ -0,0 +1,54 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+// InspectEnums gets the enum types of the inspected schemas
+func (pg *PostgreSQL) InspectEnums(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectEnumsContext(context.Background(), db, s)
+}
+
+// InspectEnumsContext gets the enum types of the inspected schemas, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectEnumsContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
+	query := fmt.Sprintf(`
+		SELECT
+			n.nspname,
+			t.typname,
+			e.enumlabel
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		WHERE %s
+		ORDER BY n.nspname, t.typname, e.enumsortorder
+	`, pg.schemaFilter("n.nspname"))
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var enum *schema.Enum
+	for rows.Next() {
+		var schemaName, name, value string
+		if err := rows.Scan(&schemaName, &name, &value); err != nil {
+			return err
+		}
+
+		if schemaName == "public" {
+			schemaName = ""
+		}
+		if enum == nil || enum.Schema != schemaName || enum.Name != name {
+			enum = s.CreateEnum(name)
+			enum.Schema = schemaName
+		}
+		enum.Values = append(enum.Values, value)
+	}
+
+	return rows.Err()
+}
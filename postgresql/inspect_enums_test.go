@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestInspectEnums(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TYPE test_mood AS ENUM ('sad', 'it''s ok', 'happy');
+		ALTER TYPE test_mood ADD VALUE 'awful' BEFORE 'sad';
+
+		CREATE TABLE test_enum_people (
+			name text NOT NULL,
+			mood test_mood NOT NULL DEFAULT 'happy'
+		);
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`
+			DROP TABLE IF EXISTS test_enum_people;
+			DROP TYPE IF EXISTS test_mood;
+		`)
+		require.NoError(t, err)
+	})
+
+	pg := New()
+	s := schema.NewSchema()
+	require.NoError(t, pg.InspectEnums(db, s))
+	require.Equal(t, []*schema.Enum{
+		{Name: "test_mood", Values: []string{"awful", "sad", "it's ok", "happy"}},
+	}, s.Enums)
+
+	table := &schema.Table{Name: "test_enum_people"}
+	require.NoError(t, pg.InspectColumns(db, table))
+	require.Equal(t, &EnumType{Name: "test_mood"}, table.Columns[1].Type)
+}
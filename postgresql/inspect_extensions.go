@@ -1,20 +1,26 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectExtensions returns all installed PostgreSQL extensions
-func (pg *PostgreSQL) InspectExtensions(db *sql.DB, s *schema.Schema) error {
+func (pg *PostgreSQL) InspectExtensions(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectExtensionsContext(context.Background(), db, s)
+}
+
+// InspectExtensionsContext returns all installed PostgreSQL extensions, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectExtensionsContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	query := `
 		SELECT extname
 		FROM pg_extension
 		ORDER BY extname
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -1,35 +1,49 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectForeignKeys gets all foreign keys for a table
-func (pg *PostgreSQL) InspectForeignKeys(db *sql.DB, table *schema.Table) error {
+func (pg *PostgreSQL) InspectForeignKeys(db dbx.Querier, table *schema.Table) error {
+	return pg.InspectForeignKeysContext(context.Background(), db, table)
+}
+
+// InspectForeignKeysContext gets all foreign keys for a table, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectForeignKeysContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := `
 		SELECT
 			tc.constraint_name,
 			kcu.column_name,
+			ccu.table_schema AS foreign_table_schema,
 			ccu.table_name AS foreign_table_name,
 			ccu.column_name AS foreign_column_name,
 			rc.update_rule,
-			rc.delete_rule
+			rc.delete_rule,
+			con.convalidated
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu
 			ON tc.constraint_name = kcu.constraint_name
+			AND tc.constraint_schema = kcu.constraint_schema
 		JOIN information_schema.constraint_column_usage ccu
 			ON tc.constraint_name = ccu.constraint_name
+			AND tc.constraint_schema = ccu.constraint_schema
 		JOIN information_schema.referential_constraints rc
 			ON tc.constraint_name = rc.constraint_name
-		WHERE tc.table_schema = 'public'
-		AND tc.table_name = $1
+			AND tc.constraint_schema = rc.constraint_schema
+		JOIN pg_constraint con
+			ON con.conname = tc.constraint_name
+			AND con.conrelid = format('%I.%I', tc.table_schema, tc.table_name)::regclass
+		WHERE tc.table_schema = $1
+		AND tc.table_name = $2
 		AND tc.constraint_type = 'FOREIGN KEY'
 		ORDER BY tc.constraint_name, kcu.ordinal_position
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, tableSchema(table), table.Name)
 	if err != nil {
 		return err
 	}
@@ -42,19 +56,22 @@ func (pg *PostgreSQL) InspectForeignKeys(db *sql.DB, table *schema.Table) error
 		refColumns []string
 		onUpdate   string
 		onDelete   string
+		validated  bool
 	})
 
 	for rows.Next() {
 		var (
 			constraintName string
 			columnName     string
+			refSchemaName  string
 			refTableName   string
 			refColumnName  string
 			updateRule     string
 			deleteRule     string
+			validated      bool
 		)
 
-		if err := rows.Scan(&constraintName, &columnName, &refTableName, &refColumnName, &updateRule, &deleteRule); err != nil {
+		if err := rows.Scan(&constraintName, &columnName, &refSchemaName, &refTableName, &refColumnName, &updateRule, &deleteRule, &validated); err != nil {
 			return err
 		}
 
@@ -66,10 +83,12 @@ func (pg *PostgreSQL) InspectForeignKeys(db *sql.DB, table *schema.Table) error
 				refColumns []string
 				onUpdate   string
 				onDelete   string
+				validated  bool
 			}{
-				refTable: refTableName,
-				onUpdate: updateRule,
-				onDelete: deleteRule,
+				refTable:  qualifiedName(refSchemaName, refTableName),
+				onUpdate:  updateRule,
+				onDelete:  deleteRule,
+				validated: validated,
 			}
 		}
 
@@ -94,7 +113,7 @@ func (pg *PostgreSQL) InspectForeignKeys(db *sql.DB, table *schema.Table) error
 			options = append(options, schema.OnUpdate(fk.onUpdate))
 		}
 
-		table.ForeignKey(fkName, fk.columns, fk.refTable, fk.refColumns, options...)
+		table.ForeignKey(fkName, fk.columns, fk.refTable, fk.refColumns, options...).NotValid = !fk.validated
 	}
 
 	return nil
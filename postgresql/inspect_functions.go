@@ -1,14 +1,20 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectFunctions retrieves all functions from the database
-func (pg *PostgreSQL) InspectFunctions(db *sql.DB, s *schema.Schema) error {
+func (pg *PostgreSQL) InspectFunctions(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectFunctionsContext(context.Background(), db, s)
+}
+
+// InspectFunctionsContext retrieves all functions from the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectFunctionsContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	query := `
 		SELECT
 			n.nspname AS schema_name,
@@ -35,13 +41,13 @@ func (pg *PostgreSQL) InspectFunctions(db *sql.DB, s *schema.Schema) error {
 		JOIN
 			pg_language l ON p.prolang = l.oid
 		WHERE
-			n.nspname NOT IN ('pg_catalog', 'information_schema')
+			` + pg.schemaFilter("n.nspname") + `
 			AND p.prokind = 'f' -- Function (not procedure or aggregate)
 		ORDER BY
 			n.nspname, p.proname
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
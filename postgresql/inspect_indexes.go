@@ -1,51 +1,111 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
+	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
-// InspectIndexes gets all indexes for a table (excluding primary key)
-func (pg *PostgreSQL) InspectIndexes(db *sql.DB, table *schema.Table) error {
+// InspectIndexes gets all indexes for a table (excluding primary key and unique constraints)
+func (pg *PostgreSQL) InspectIndexes(db dbx.Querier, table *schema.Table) error {
+	return pg.InspectIndexesContext(context.Background(), db, table)
+}
+
+// InspectIndexesContext gets all indexes for a table (excluding primary key and unique constraints), using ctx for the catalog queries
+func (pg *PostgreSQL) InspectIndexesContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	// One row per key and included column of each index
 	query := `
 		SELECT
-            i.indexname AS name,
-            array_agg(a.attname) AS columns,
-            idx.indisunique AS is_unique
-        FROM pg_indexes i
-        JOIN pg_class c ON c.relname = i.tablename AND c.relnamespace = i.schemaname::regnamespace
-        JOIN pg_index idx ON idx.indexrelid = (SELECT oid FROM pg_class WHERE relname = i.indexname AND relnamespace = i.schemaname::regnamespace)
-        JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = ANY(idx.indkey)
-        WHERE
-			i.schemaname NOT IN ('pg_catalog', 'information_schema')
-			AND i.tablename = $1
+			ic.relname AS name,
+			idx.indisunique AS is_unique,
+			idx.indisvalid AS is_valid,
+			am.amname AS method,
+			COALESCE(pg_get_expr(idx.indpred, idx.indrelid, true), '') AS predicate,
+			k.n > idx.indnkeyatts AS is_included,
+			pg_get_indexdef(idx.indexrelid, k.n, true) AS key,
+			idx.indkey[k.n - 1] = 0 AS is_expression,
+			COALESCE(idx.indoption[k.n - 1], 0) AS key_option,
+			COALESCE(CASE WHEN opc.opcdefault THEN '' ELSE opc.opcname END, '') AS opclass
+		FROM pg_index idx
+		JOIN pg_class ic ON ic.oid = idx.indexrelid
+		JOIN pg_class c ON c.oid = idx.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_am am ON am.oid = ic.relam
+		CROSS JOIN LATERAL generate_series(1, idx.indnatts::int) AS k(n)
+		LEFT JOIN pg_opclass opc ON opc.oid = idx.indclass[k.n - 1]
+		WHERE
+			n.nspname = $1
+			AND c.relname = $2
 			AND idx.indisprimary = false
-        GROUP BY i.indexname, i.schemaname, idx.indisunique
-		ORDER BY i.indexname;
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = idx.indexrelid AND con.contype = 'u')
+		ORDER BY ic.relname, k.n
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, tableSchema(table), table.Name)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	var idx *schema.Index
 	for rows.Next() {
-		var name string
-		var columns string
-		var isUnique bool
+		var name, method, predicate, key, opClass string
+		var isUnique, isValid, isIncluded, isExpression bool
+		var keyOption int
 
-		if err := rows.Scan(&name, &columns, &isUnique); err != nil {
+		if err := rows.Scan(&name, &isUnique, &isValid, &method, &predicate, &isIncluded, &key, &isExpression, &keyOption, &opClass); err != nil {
 			return err
 		}
 
-		options := []schema.IndexOption{}
-		if isUnique {
-			options = append(options, schema.Unique)
+		if idx == nil || idx.Name != name {
+			options := []schema.IndexOption{schema.Where(predicate)}
+			if isUnique {
+				options = append(options, schema.Unique)
+			}
+			if method != "btree" {
+				options = append(options, schema.Using(method))
+			}
+			idx = table.Index(name, nil, options...)
+			// A failed CREATE INDEX CONCURRENTLY leaves an invalid index behind
+			idx.Invalid = !isValid
+		}
+
+		if isExpression {
+			key = "(" + key + ")"
+		} else {
+			key = unquoteIdentifier(key)
+		}
+
+		if isIncluded {
+			idx.Include = append(idx.Include, key)
+			continue
+		}
+		idx.Columns = append(idx.Columns, key)
+
+		// indoption holds the DESC (1) and NULLS FIRST (2) flags of a key column
+		desc, nullsFirst := keyOption&1 != 0, keyOption&2 != 0
+		if desc {
+			schema.Desc(key)(idx)
+		}
+		if desc && !nullsFirst {
+			schema.NullsLast(key)(idx)
+		} else if !desc && nullsFirst {
+			schema.NullsFirst(key)(idx)
+		}
+		if opClass != "" {
+			schema.OpClass(key, opClass)(idx)
 		}
-		table.Index(name, PostgresArrayToSlice(columns), options...)
 	}
 
 	return rows.Err()
 }
+
+// unquoteIdentifier removes the double quotes around an identifier
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return name
+}
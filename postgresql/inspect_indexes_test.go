@@ -32,7 +32,10 @@ func TestInspectIndexes(t *testing.T) {
 		CREATE INDEX idx_test_name ON test_indexes (first_name, last_name);
 
 		-- Expression index
-		-- CREATE INDEX idx_test_created_year ON test_indexes (EXTRACT(YEAR FROM created_at));
+		CREATE INDEX idx_test_lower_username ON test_indexes (lower(username));
+
+		-- Partial index with a descending key and an included column
+		CREATE INDEX idx_test_created_at ON test_indexes (created_at DESC) INCLUDE (email) WHERE first_name IS NOT NULL;
 	`)
 	require.NoError(t, err)
 
@@ -50,9 +53,41 @@ func TestInspectIndexes(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Equal(t, []*schema.Index{
+		{
+			Name:       "idx_test_created_at",
+			Columns:    []string{"created_at"},
+			Where:      "first_name IS NOT NULL",
+			Include:    []string{"email"},
+			KeyOptions: []*schema.IndexKeyOption{{Column: "created_at", Desc: true}},
+		},
 		{Name: "idx_test_email", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_test_lower_username", Columns: []string{"(lower(username::text))"}},
 		{Name: "idx_test_name", Columns: []string{"first_name", "last_name"}},
 		{Name: "idx_test_username", Columns: []string{"username"}},
-		// {Name: "idx_test_created_year", Unique: false, Columns: []string{"EXTRACT(YEAR FROM created_at)"}},
+	}, table.Indexes)
+}
+
+func TestInspectInvalidIndex(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE test_invalid_indexes (email varchar(100));
+		INSERT INTO test_invalid_indexes VALUES ('a@example.com'), ('a@example.com');
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err = db.Exec(`DROP TABLE IF EXISTS test_invalid_indexes`)
+		require.NoError(t, err)
+	})
+
+	// The duplicate rows make the concurrent build fail and leave an invalid index
+	_, err = db.Exec(`CREATE UNIQUE INDEX CONCURRENTLY idx_test_invalid_email ON test_invalid_indexes (email)`)
+	require.Error(t, err)
+
+	table := &schema.Table{Name: "test_invalid_indexes", Schema: "public"}
+	require.NoError(t, New().InspectIndexes(db, table))
+	require.Equal(t, []*schema.Index{
+		{Name: "idx_test_invalid_email", Columns: []string{"email"}, Unique: true, Invalid: true},
 	}, table.Indexes)
 }
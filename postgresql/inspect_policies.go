@@ -1,15 +1,22 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectRowPolicies retrieves all row policies from the database
-func (pg *PostgreSQL) InspectRowPolicies(db *sql.DB, s *schema.Schema) error {
+func (pg *PostgreSQL) InspectRowPolicies(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectRowPoliciesContext(context.Background(), db, s)
+}
+
+// InspectRowPoliciesContext retrieves all row policies from the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectRowPoliciesContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	query := `
         SELECT
             schemaname,
@@ -21,10 +28,11 @@ func (pg *PostgreSQL) InspectRowPolicies(db *sql.DB, s *schema.Schema) error {
             with_check,
             CASE WHEN permissive = 'PERMISSIVE' THEN true ELSE false END AS permissive
         FROM pg_policies
+        WHERE ` + pg.schemaFilter("schemaname") + `
         ORDER BY schemaname, tablename, policyname
     `
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to query pg_policies: %w", err)
 	}
@@ -1,13 +1,19 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectPrimaryKey gets the primary key for a table
-func (pg *PostgreSQL) InspectPrimaryKey(db *sql.DB, table *schema.Table) error {
+func (pg *PostgreSQL) InspectPrimaryKey(db dbx.Querier, table *schema.Table) error {
+	return pg.InspectPrimaryKeyContext(context.Background(), db, table)
+}
+
+// InspectPrimaryKeyContext gets the primary key for a table, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectPrimaryKeyContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := `
 		SELECT
 			tc.constraint_name,
@@ -15,13 +21,14 @@ func (pg *PostgreSQL) InspectPrimaryKey(db *sql.DB, table *schema.Table) error {
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu
 			ON tc.constraint_name = kcu.constraint_name
-		WHERE tc.table_schema = 'public'
-		AND tc.table_name = $1
+			AND tc.constraint_schema = kcu.constraint_schema
+		WHERE tc.table_schema = $1
+		AND tc.table_name = $2
 		AND tc.constraint_type = 'PRIMARY KEY'
 		ORDER BY kcu.ordinal_position
 	`
 
-	rows, err := db.Query(query, table.Name)
+	rows, err := db.QueryContext(ctx, query, tableSchema(table), table.Name)
 	if err != nil {
 		return err
 	}
@@ -1,11 +1,13 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
+
+	"github.com/swiftcarrot/dbx"
 )
 
 // RowData represents a row of data with column name to value mapping
@@ -13,7 +15,13 @@ type RowData map[string]interface{}
 
 // InspectRows retrieves rows from a table in the database
 // Limit is required to avoid retrieving too many rows
-func (pg *PostgreSQL) InspectRows(db *sql.DB, tableName string, limit int, whereClause string, args ...interface{}) ([]RowData, error) {
+func (pg *PostgreSQL) InspectRows(db dbx.Querier, tableName string, limit int, whereClause string, args ...interface{}) ([]RowData, error) {
+	return pg.InspectRowsContext(context.Background(), db, tableName, limit, whereClause, args...)
+}
+
+// InspectRowsContext retrieves rows from a table in the database, using ctx for the query
+// Limit is required to avoid retrieving too many rows
+func (pg *PostgreSQL) InspectRowsContext(ctx context.Context, db dbx.Querier, tableName string, limit int, whereClause string, args ...interface{}) ([]RowData, error) {
 	// Build the query
 	query := fmt.Sprintf("SELECT * FROM %s", quoteIdentifier(tableName))
 
@@ -26,7 +34,7 @@ func (pg *PostgreSQL) InspectRows(db *sql.DB, tableName string, limit int, where
 	query += fmt.Sprintf(" LIMIT %d", limit)
 
 	// Execute the query
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
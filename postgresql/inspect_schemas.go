@@ -1,9 +1,22 @@
 package postgresql
 
-import "database/sql"
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
 
 // InspectSchemas returns all schema names in the database
-func (pg *PostgreSQL) InspectSchemas(db *sql.DB) ([]string, error) {
+func (pg *PostgreSQL) InspectSchemas(db dbx.Querier) ([]string, error) {
+	return pg.InspectSchemasContext(context.Background(), db)
+}
+
+// InspectSchemasContext returns all schema names in the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectSchemasContext(ctx context.Context, db dbx.Querier) ([]string, error) {
 	query := `
 		SELECT schema_name
 		FROM information_schema.schemata
@@ -11,7 +24,7 @@ func (pg *PostgreSQL) InspectSchemas(db *sql.DB) ([]string, error) {
 		ORDER BY schema_name
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -28,3 +41,57 @@ func (pg *PostgreSQL) InspectSchemas(db *sql.DB) ([]string, error) {
 
 	return schemas, rows.Err()
 }
+
+// schemaFilter returns the SQL condition that restricts a schema name column
+// to the schemas selected with WithSchemas and WithoutSchemas
+func (pg *PostgreSQL) schemaFilter(column string) string {
+	if len(pg.schemas) == 0 && len(pg.excludeSchemas) > 0 {
+		excluded := append([]string{"information_schema"}, pg.excludeSchemas...)
+		return fmt.Sprintf(`%s NOT IN (%s) AND %s NOT LIKE 'pg\_%%'`, column, quoteLiterals(excluded), column)
+	}
+
+	included := []string{"public"}
+	if len(pg.schemas) > 0 {
+		included = slices.DeleteFunc(slices.Clone(pg.schemas), func(name string) bool {
+			return slices.Contains(pg.excludeSchemas, name)
+		})
+	}
+	if len(included) == 0 {
+		return "false"
+	}
+	return fmt.Sprintf("%s IN (%s)", column, quoteLiterals(included))
+}
+
+// quoteLiterals quotes strings as a comma separated list of SQL literals
+func quoteLiterals(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteLiteral(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// tableSchema returns the schema of a table, public when it is not set
+func tableSchema(table *schema.Table) string {
+	if table.Schema == "" {
+		return "public"
+	}
+	return table.Schema
+}
+
+// qualifiedName prefixes a name with its schema unless the schema is public
+func qualifiedName(schemaName, name string) string {
+	if schemaName == "" || schemaName == "public" {
+		return name
+	}
+	return schemaName + "." + name
+}
+
+// splitQualifiedName splits a name returned by qualifiedName into its schema
+// (empty for public) and unqualified name
+func splitQualifiedName(name string) (string, string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
@@ -1,14 +1,20 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectSequences returns all sequences in the database
-func (pg *PostgreSQL) InspectSequences(db *sql.DB, s *schema.Schema) error {
+func (pg *PostgreSQL) InspectSequences(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectSequencesContext(context.Background(), db, s)
+}
+
+// InspectSequencesContext returns all sequences in the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectSequencesContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	// First check which column names are used in this PostgreSQL version
 	var cacheColumn string
 	checkCacheQuery := `
@@ -18,7 +24,7 @@ func (pg *PostgreSQL) InspectSequences(db *sql.DB, s *schema.Schema) error {
 		LIMIT 1
 	`
 
-	err := db.QueryRow(checkCacheQuery).Scan(&cacheColumn)
+	err := db.QueryRowContext(ctx, checkCacheQuery).Scan(&cacheColumn)
 	if err != nil {
 		// If we can't determine the column, default to cache_size which is more common
 		cacheColumn = "cache_size"
@@ -33,7 +39,7 @@ func (pg *PostgreSQL) InspectSequences(db *sql.DB, s *schema.Schema) error {
 		LIMIT 1
 	`
 
-	err = db.QueryRow(checkCycleQuery).Scan(&cycleColumn)
+	err = db.QueryRowContext(ctx, checkCycleQuery).Scan(&cycleColumn)
 	if err != nil {
 		// If we can't determine the column, default to is_cycled which is more common
 		cycleColumn = "is_cycled"
@@ -58,13 +64,12 @@ func (pg *PostgreSQL) InspectSequences(db *sql.DB, s *schema.Schema) error {
 			pg_sequences s ON s.schemaname = n.nspname AND s.sequencename = c.relname
 		WHERE
 			c.relkind = 'S' AND
-			n.nspname != 'pg_catalog' AND
-			n.nspname != 'information_schema'
+			%s
 		ORDER BY
 			n.nspname, c.relname
-	`, cacheColumn, cycleColumn)
+	`, cacheColumn, cycleColumn, pg.schemaFilter("n.nspname"))
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
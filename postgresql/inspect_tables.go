@@ -1,18 +1,28 @@
 package postgresql
 
-import "database/sql"
+import (
+	"context"
 
-// InspectTables returns all table names in the database
-func (pg *PostgreSQL) InspectTables(db *sql.DB) ([]string, error) {
+	"github.com/swiftcarrot/dbx"
+)
+
+// InspectTables returns all table names in the inspected schemas. Tables
+// outside the public schema are qualified with their schema name.
+func (pg *PostgreSQL) InspectTables(db dbx.Querier) ([]string, error) {
+	return pg.InspectTablesContext(context.Background(), db)
+}
+
+// InspectTablesContext returns all table names in the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectTablesContext(ctx context.Context, db dbx.Querier) ([]string, error) {
 	query := `
-		SELECT table_name
+		SELECT table_schema, table_name
 		FROM information_schema.tables
-		WHERE table_schema = 'public'
+		WHERE ` + pg.schemaFilter("table_schema") + `
 		AND table_type = 'BASE TABLE'
-		ORDER BY table_name
+		ORDER BY table_schema, table_name
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -20,11 +30,11 @@ func (pg *PostgreSQL) InspectTables(db *sql.DB) ([]string, error) {
 
 	var tables []string
 	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
+		var schemaName, tableName string
+		if err := rows.Scan(&schemaName, &tableName); err != nil {
 			return nil, err
 		}
-		tables = append(tables, tableName)
+		tables = append(tables, qualifiedName(schemaName, tableName))
 	}
 
 	return tables, rows.Err()
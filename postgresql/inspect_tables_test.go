@@ -1,10 +1,12 @@
 package postgresql
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
 )
 
 func TestInspectTables(t *testing.T) {
@@ -31,3 +33,82 @@ func TestInspectTables(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []string{"test_table_1", "test_table_2"}, tables)
 }
+
+func TestInspectTablesInTransaction(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE test_table_tx (id serial PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	pg := New()
+	tables, err := pg.InspectTablesContext(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"test_table_tx"}, tables)
+
+	require.NoError(t, tx.Rollback())
+
+	tables, err = pg.InspectTables(db)
+	require.NoError(t, err)
+	require.Empty(t, tables)
+}
+
+func TestInspectTablesWithSchemas(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE SCHEMA audit;
+		CREATE TABLE events (id serial PRIMARY KEY);
+		CREATE TABLE audit.events (id serial PRIMARY KEY, event_id integer REFERENCES public.events (id));
+		CREATE INDEX events_event_id_idx ON audit.events (event_id);
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`
+			DROP TABLE IF EXISTS audit.events;
+			DROP TABLE IF EXISTS events;
+			DROP SCHEMA IF EXISTS audit;
+		`)
+		require.NoError(t, err)
+	})
+
+	tables, err := New().InspectTables(db)
+	require.NoError(t, err)
+	require.Equal(t, []string{"events"}, tables)
+
+	pg := New(WithSchemas("public", "audit"))
+	tables, err = pg.InspectTables(db)
+	require.NoError(t, err)
+	require.Equal(t, []string{"audit.events", "events"}, tables)
+
+	tables, err = New(WithoutSchemas("public")).InspectTables(db)
+	require.NoError(t, err)
+	require.Equal(t, []string{"audit.events"}, tables)
+
+	s, err := pg.Inspect(db)
+	require.NoError(t, err)
+	require.Len(t, s.Tables, 2)
+	require.Equal(t, "audit", s.Tables[0].Schema)
+	require.Equal(t, "events", s.Tables[0].Name)
+	require.Len(t, s.Tables[0].Columns, 2)
+	require.Equal(t, []*schema.Index{{Name: "events_event_id_idx", Columns: []string{"event_id"}}}, s.Tables[0].Indexes)
+	require.Equal(t, "events", s.Tables[0].ForeignKeys[0].RefTable)
+	require.Equal(t, "", s.Tables[1].Schema)
+	require.Len(t, s.Tables[1].Columns, 1)
+	require.Empty(t, s.Tables[1].Indexes)
+}
+
+func TestSchemaFilter(t *testing.T) {
+	require.Equal(t, "n.nspname IN ('public')", New().schemaFilter("n.nspname"))
+	require.Equal(t, "n.nspname IN ('public', 'audit')", New(WithSchemas("public", "audit", "billing"), WithoutSchemas("billing")).schemaFilter("n.nspname"))
+	require.Equal(t, `n.nspname NOT IN ('information_schema', 'billing') AND n.nspname NOT LIKE 'pg\_%'`, New(WithoutSchemas("billing")).schemaFilter("n.nspname"))
+	require.Equal(t, "false", New(WithSchemas("audit"), WithoutSchemas("audit")).schemaFilter("n.nspname"))
+}
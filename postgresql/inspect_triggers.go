@@ -1,14 +1,20 @@
 package postgresql
 
 import (
-	"database/sql"
+	"context"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectTriggers retrieves all triggers from the database
-func (pg *PostgreSQL) InspectTriggers(db *sql.DB, s *schema.Schema) error {
+func (pg *PostgreSQL) InspectTriggers(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectTriggersContext(context.Background(), db, s)
+}
+
+// InspectTriggersContext retrieves all triggers from the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectTriggersContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	query := `
 		SELECT
 			n.nspname AS schema_name,
@@ -23,12 +29,12 @@ func (pg *PostgreSQL) InspectTriggers(db *sql.DB, s *schema.Schema) error {
 			pg_namespace n ON c.relnamespace = n.oid
 		WHERE
 			NOT t.tgisinternal AND
-			n.nspname NOT IN ('pg_catalog', 'information_schema')
+			` + pg.schemaFilter("n.nspname") + `
 		ORDER BY
 			n.nspname, c.relname, t.tgname
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
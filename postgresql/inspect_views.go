@@ -1,14 +1,21 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectViews retrieves all views from the database
-func (pg *PostgreSQL) InspectViews(db *sql.DB, s *schema.Schema) error {
+func (pg *PostgreSQL) InspectViews(db dbx.Querier, s *schema.Schema) error {
+	return pg.InspectViewsContext(context.Background(), db, s)
+}
+
+// InspectViewsContext retrieves all views from the database, using ctx for the catalog queries
+func (pg *PostgreSQL) InspectViewsContext(ctx context.Context, db dbx.Querier, s *schema.Schema) error {
 	query := `
     SELECT
         n.nspname AS schema,
@@ -21,14 +28,14 @@ func (pg *PostgreSQL) InspectViews(db *sql.DB, s *schema.Schema) error {
         LEFT JOIN pg_attribute a ON c.oid = a.attrelid AND a.attnum > 0 AND NOT a.attisdropped
     WHERE
         c.relkind = 'v'
-        AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+        AND ` + pg.schemaFilter("n.nspname") + `
     GROUP BY
         n.nspname, c.relname, c.oid
     ORDER BY
         n.nspname, c.relname;
     `
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("query failed: %v", err)
 	}
@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/lockutil"
+)
+
+var _ dbx.Locker = (*PostgreSQL)(nil)
+
+// Lock acquires a session-level advisory lock keyed by a hash of name. The
+// lock is held by a dedicated connection which is closed when the lock is
+// released, or discarded if unlocking fails so that the server drops the lock.
+func (pg *PostgreSQL) Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := advisoryLockKey(name)
+	err = lockutil.Poll(ctx, timeout, func() (bool, error) {
+		var acquired bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired)
+		return acquired, err
+	})
+	if err != nil {
+		if errors.Is(err, lockutil.ErrTimeout) {
+			err = &dbx.LockTimeoutError{Name: name, Timeout: timeout, Holder: advisoryLockHolder(conn, key)}
+		}
+		conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		var released bool
+		err := conn.QueryRowContext(context.Background(), "SELECT pg_advisory_unlock($1)", key).Scan(&released)
+		if err == nil && !released {
+			err = fmt.Errorf("advisory lock %q was not held", name)
+		}
+		if err != nil {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			return err
+		}
+		return conn.Close()
+	}, nil
+}
+
+// advisoryLockKey converts a lock name into a bigint advisory lock key
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// advisoryLockHolder describes the backend holding an advisory lock, or
+// returns an empty string if it cannot be determined
+func advisoryLockHolder(conn *sql.Conn, key int64) string {
+	query := `
+		SELECT a.pid, COALESCE(a.usename, ''), COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), '')
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory'
+			AND l.granted
+			AND l.objsubid = 1
+			AND l.classid::bigint = ($1::bigint >> 32) & 4294967295
+			AND l.objid::bigint = $1::bigint & 4294967295
+		LIMIT 1
+	`
+
+	var (
+		pid     int
+		user    string
+		app     string
+		address string
+	)
+	if err := conn.QueryRowContext(context.Background(), query, key).Scan(&pid, &user, &app, &address); err != nil {
+		return ""
+	}
+
+	holder := fmt.Sprintf("pid %d", pid)
+	if user != "" {
+		holder += " user " + user
+	}
+	if app != "" {
+		holder += " application " + app
+	}
+	if address != "" {
+		holder += " from " + address
+	}
+	return holder
+}
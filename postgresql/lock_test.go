@@ -0,0 +1,36 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+)
+
+func TestLock(t *testing.T) {
+	db, err := testutil.GetPGTestConn()
+	require.NoError(t, err)
+
+	pg := New()
+	unlock, err := pg.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+
+	_, err = pg.Lock(context.Background(), db, "schema_migrations", 10*time.Millisecond)
+	var timeoutErr *dbx.LockTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Regexp(t, `^pid \d+ user postgres`, timeoutErr.Holder)
+
+	require.NoError(t, unlock())
+
+	unlock, err = pg.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
+
+func TestAdvisoryLockKey(t *testing.T) {
+	require.Equal(t, advisoryLockKey("schema_migrations"), advisoryLockKey("schema_migrations"))
+	require.NotEqual(t, advisoryLockKey("schema_migrations"), advisoryLockKey("other_migrations"))
+}
@@ -0,0 +1,1020 @@
+package postgresql
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/ddl"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var _ dbx.Parser = (*PostgreSQL)(nil)
+
+var parseOptions = ddl.Options{FoldCase: true, DollarQuotes: true, MetaCommands: true}
+
+// typePattern matches a type name with optional parameters, as in
+// "timestamp(3) with time zone" or "numeric(10,2)"
+var typePattern = regexp.MustCompile(`^([a-z_][a-z0-9_]*(?:\s+[a-z_][a-z0-9_]*)*?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?((?:\s+[a-z_]+)*)$`)
+
+// ParseSQL parses the statements of a SQL file, such as the output of
+// pg_dump --schema-only, into a schema as Inspect would return it. Schemas,
+// extensions, enum types, sequences, tables, constraints, indexes, views,
+// functions, triggers, row policies and column comments are read. Session
+// settings, ownership and privileges are skipped, column collations are not
+// read and other statements are reported in a *dbx.ParseError.
+func (pg *PostgreSQL) ParseSQL(sql string) (*schema.Schema, error) {
+	p := &ddlParser{schema: schema.NewSchema()}
+	err := ddl.Parse(sql, parseOptions, p.statement)
+	return pg.filter.Apply(p.schema), err
+}
+
+// ddlParser builds a schema from the statements of a SQL file
+type ddlParser struct {
+	schema *schema.Schema
+}
+
+func (p *ddlParser) statement(q *ddl.Parser) error {
+	switch {
+	case q.Is("SET"), q.Is("SELECT", "pg_catalog", ".", "set_config"), q.Is("GRANT"), q.Is("REVOKE"),
+		q.Is("COMMENT", "ON", "EXTENSION"), q.Is("ALTER") && q.Find("OWNER", "TO"):
+		// Session settings, ownership and privileges are not part of the schema
+		q.Rest()
+		return nil
+	case q.Accept("CREATE"):
+		q.Accept("OR", "REPLACE")
+		switch {
+		case q.Accept("SCHEMA"):
+			return p.createSchema(q)
+		case q.Accept("EXTENSION"):
+			return p.createExtension(q)
+		case q.Accept("TYPE"):
+			return p.createType(q)
+		case q.Accept("SEQUENCE"):
+			return p.createSequence(q)
+		case q.Accept("TABLE"):
+			return p.createTable(q)
+		case q.Accept("UNIQUE", "INDEX"):
+			return p.createIndex(q, true)
+		case q.Accept("INDEX"):
+			return p.createIndex(q, false)
+		case q.Accept("VIEW"):
+			return p.createView(q)
+		case q.Accept("FUNCTION"):
+			return p.createFunction(q)
+		case q.Accept("TRIGGER"):
+			return p.createTrigger(q)
+		case q.Accept("POLICY"):
+			return p.createPolicy(q)
+		}
+	case q.Accept("ALTER", "TABLE"):
+		return p.alterTable(q)
+	case q.Accept("ALTER", "SEQUENCE"):
+		if _, _, err := q.Name(); err != nil {
+			return err
+		}
+		if q.Accept("OWNED", "BY") {
+			// The sequence of a column default is dropped with its table
+			q.Rest()
+			return nil
+		}
+	case q.Accept("COMMENT", "ON", "COLUMN"):
+		return p.commentOnColumn(q)
+	}
+	return q.Unsupported()
+}
+
+// namespace returns the schema of a table, sequence or enum as Inspect sets
+// it, which is empty for public
+func namespace(name string) string {
+	if name == "public" {
+		return ""
+	}
+	return name
+}
+
+// objectSchema returns the schema of a function, view, trigger or row policy
+// as Inspect sets it, which is public when the name is not qualified
+func objectSchema(name string) string {
+	if name == "" {
+		return "public"
+	}
+	return name
+}
+
+func (p *ddlParser) findTable(schemaName, name string) (*schema.Table, error) {
+	for _, table := range p.schema.Tables {
+		if table.Schema == namespace(schemaName) && table.Name == name {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("table %s does not exist", qualifiedName(schemaName, name))
+}
+
+func findColumn(table *schema.Table, name string) (*schema.Column, error) {
+	for _, column := range table.Columns {
+		if column.Name == name {
+			return column, nil
+		}
+	}
+	return nil, fmt.Errorf("column %s of table %s does not exist", name, table.Name)
+}
+
+func (p *ddlParser) createSchema(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	if q.Accept("AUTHORIZATION") {
+		if _, err := q.Ident(); err != nil {
+			return err
+		}
+	}
+	if name != "public" && !slices.Contains(p.schema.Namespaces, name) {
+		p.schema.Namespaces = append(p.schema.Namespaces, name)
+	}
+	return nil
+}
+
+func (p *ddlParser) createExtension(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	// The schema and version of an extension are not part of the schema
+	q.Rest()
+	if !slices.Contains(p.schema.Extensions, name) {
+		p.schema.Extensions = append(p.schema.Extensions, name)
+	}
+	return nil
+}
+
+func (p *ddlParser) createType(q *ddl.Parser) error {
+	schemaName, name, err := q.Name()
+	if err != nil {
+		return err
+	}
+	if !q.Accept("AS", "ENUM") {
+		return fmt.Errorf("unsupported type %s, only enum types are supported", name)
+	}
+	items, err := q.Items()
+	if err != nil {
+		return err
+	}
+
+	enum := p.schema.CreateEnum(name)
+	enum.Schema = namespace(schemaName)
+	for _, item := range items {
+		value, err := ddl.NewParser(item, parseOptions).String()
+		if err != nil {
+			return err
+		}
+		enum.Values = append(enum.Values, value)
+	}
+	return nil
+}
+
+func (p *ddlParser) findEnum(schemaName, name string) *schema.Enum {
+	for _, enum := range p.schema.Enums {
+		if enum.Schema == namespace(schemaName) && enum.Name == name {
+			return enum
+		}
+	}
+	return nil
+}
+
+func (p *ddlParser) createSequence(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	schemaName, name, err := q.Name()
+	if err != nil {
+		return err
+	}
+	seq := p.schema.CreateSequence(name, schema.InSchema(namespace(schemaName)))
+	return sequenceOptions(q, seq)
+}
+
+// sequenceOptions reads the options of CREATE SEQUENCE, leaving the limits
+// and start value that are not given at the defaults for the sequence type
+// and direction
+func sequenceOptions(q *ddl.Parser, seq *schema.Sequence) error {
+	maxValue := int64(math.MaxInt64)
+	var minSet, maxSet, startSet bool
+	for !q.Done() {
+		var err error
+		switch {
+		case q.Accept("AS"):
+			var typeName string
+			typeName, err = q.Ident()
+			switch strings.ToLower(typeName) {
+			case "smallint", "int2":
+				maxValue = math.MaxInt16
+			case "integer", "int", "int4":
+				maxValue = math.MaxInt32
+			}
+		case q.Accept("INCREMENT"):
+			q.Accept("BY")
+			seq.Increment, err = q.Int()
+		case q.Accept("NO", "MINVALUE"), q.Accept("NO", "MAXVALUE"):
+		case q.Accept("MINVALUE"):
+			seq.MinValue, err = q.Int()
+			minSet = true
+		case q.Accept("MAXVALUE"):
+			seq.MaxValue, err = q.Int()
+			maxSet = true
+		case q.Accept("START"):
+			q.Accept("WITH")
+			seq.Start, err = q.Int()
+			startSet = true
+		case q.Accept("CACHE"):
+			seq.Cache, err = q.Int()
+		case q.Accept("NO", "CYCLE"):
+			seq.Cycle = false
+		case q.Accept("CYCLE"):
+			seq.Cycle = true
+		case q.Accept("OWNED", "BY"):
+			_, err = q.Path()
+		default:
+			return fmt.Errorf("unsupported sequence option %q", q.Peek().Text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !minSet {
+		seq.MinValue = 1
+		if seq.Increment < 0 {
+			seq.MinValue = -maxValue - 1
+		}
+	}
+	if !maxSet {
+		seq.MaxValue = maxValue
+		if seq.Increment < 0 {
+			seq.MaxValue = -1
+		}
+	}
+	if !startSet {
+		seq.Start = seq.MinValue
+		if seq.Increment < 0 {
+			seq.Start = seq.MaxValue
+		}
+	}
+	return nil
+}
+
+func (p *ddlParser) createTable(q *ddl.Parser) error {
+	q.Accept("IF", "NOT", "EXISTS")
+	schemaName, name, err := q.Name()
+	if err != nil {
+		return err
+	}
+	if _, err := p.findTable(schemaName, name); err == nil {
+		return fmt.Errorf("table %s already exists", qualifiedName(schemaName, name))
+	}
+	items, err := q.Items()
+	if err != nil {
+		return err
+	}
+
+	table := p.schema.CreateTable(name, nil)
+	table.Schema = namespace(schemaName)
+	for _, item := range items {
+		r := ddl.NewParser(item, parseOptions)
+		if isTableConstraint(r) {
+			err = p.tableConstraint(r, table)
+		} else {
+			err = p.column(r, table)
+		}
+		if err == nil && !r.Done() {
+			err = fmt.Errorf("unexpected %q", r.Peek().Text)
+		}
+		if err != nil {
+			p.schema.Tables = p.schema.Tables[:len(p.schema.Tables)-1]
+			return err
+		}
+	}
+	return nil
+}
+
+func isTableConstraint(q *ddl.Parser) bool {
+	return q.Is("CONSTRAINT") || q.Is("PRIMARY", "KEY") || q.Is("UNIQUE") || q.Is("FOREIGN", "KEY") ||
+		q.Is("CHECK") || q.Is("EXCLUDE") || q.Is("LIKE")
+}
+
+// columnStops are the keywords that end the type of a column definition
+var columnStops = []string{"CONSTRAINT", "NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "REFERENCES", "CHECK", "COLLATE", "GENERATED"}
+
+func (p *ddlParser) column(q *ddl.Parser, table *schema.Table) error {
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	columnType, err := p.columnType(q.Until(columnStops...))
+	if err != nil {
+		return err
+	}
+	column := table.Column(name, columnType, schema.Nullable)
+
+	for !q.Done() {
+		constraint := ""
+		if q.Accept("CONSTRAINT") {
+			if constraint, err = q.Ident(); err != nil {
+				return err
+			}
+		}
+		switch {
+		case q.Accept("NOT", "NULL"):
+			column.Nullable = false
+		case q.Accept("NULL"):
+			column.Nullable = true
+		case q.Accept("DEFAULT"):
+			column.Default = q.Until(columnStops...)
+		case q.Accept("COLLATE"):
+			_, err = q.Path()
+		case q.Accept("PRIMARY", "KEY"):
+			if constraint == "" {
+				constraint = table.Name + "_pkey"
+			}
+			table.SetPrimaryKey(constraint, []string{name})
+			column.Nullable = false
+		case q.Accept("UNIQUE"):
+			if constraint == "" {
+				constraint = table.Name + "_" + name + "_key"
+			}
+			table.Unique(constraint, name)
+		case q.Accept("REFERENCES"):
+			if constraint == "" {
+				constraint = table.Name + "_" + name + "_fkey"
+			}
+			err = p.references(q, table, constraint, []string{name})
+		case q.Accept("CHECK"):
+			if constraint == "" {
+				constraint = table.Name + "_" + name + "_check"
+			}
+			err = check(q, table, constraint)
+		case q.Accept("GENERATED"):
+			if !q.Accept("ALWAYS") && !q.Accept("BY", "DEFAULT") {
+				return fmt.Errorf("expected ALWAYS or BY DEFAULT, found %q", q.Peek().Text)
+			}
+			if !q.Accept("AS", "IDENTITY") {
+				return fmt.Errorf("generated columns are not supported")
+			}
+			if q.Is("(") {
+				_, err = q.Group()
+			}
+			column.Nullable = false
+			column.AutoIncrement = true
+		default:
+			return fmt.Errorf("unexpected %q", q.Peek().Text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnType returns the column type named by a type in a column definition
+func (p *ddlParser) columnType(text string) (schema.ColumnType, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasSuffix(text, "]") {
+		element, err := p.columnType(text[:strings.LastIndex(text, "[")])
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayType{ElementType: element}, nil
+	}
+
+	q := ddl.NewParser(text, parseOptions)
+	if schemaName, name, err := q.Name(); err == nil && q.Done() {
+		if enum := p.findEnum(schemaName, name); enum != nil {
+			return &EnumType{Schema: enum.Schema, Name: enum.Name}, nil
+		}
+	}
+
+	match := typePattern.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return nil, fmt.Errorf("unsupported column type %q", text)
+	}
+	name := strings.Join(strings.Fields(match[1]+" "+match[4]), " ")
+	length, _ := strconv.Atoi(match[2])
+	scale, _ := strconv.Atoi(match[3])
+
+	switch name {
+	case "serial", "serial4":
+		return &SerialType{}, nil
+	case "bigserial", "serial8":
+		return &BigSerialType{}, nil
+	case "macaddr":
+		return &MACAddrType{}, nil
+	case "varchar", "character varying":
+		return &schema.VarcharType{Length: length}, nil
+	case "numeric", "decimal":
+		return &schema.DecimalType{Precision: length, Scale: scale}, nil
+	}
+	if columnType, ok := lookupDataType(name); ok {
+		return columnType, nil
+	}
+	return nil, fmt.Errorf("unsupported column type %q", text)
+}
+
+// tableConstraint reads a table constraint, with its optional CONSTRAINT name
+func (p *ddlParser) tableConstraint(q *ddl.Parser, table *schema.Table) error {
+	name := ""
+	if q.Accept("CONSTRAINT") {
+		var err error
+		if name, err = q.Ident(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case q.Accept("PRIMARY", "KEY"):
+		columns, err := q.Names()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = table.Name + "_pkey"
+		}
+		for _, column := range columns {
+			if c, err := findColumn(table, column); err == nil {
+				c.Nullable = false
+			}
+		}
+		table.SetPrimaryKey(name, columns)
+	case q.Accept("UNIQUE"):
+		columns, err := q.Names()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = table.Name + "_" + strings.Join(columns, "_") + "_key"
+		}
+		table.Unique(name, columns...)
+	case q.Accept("FOREIGN", "KEY"):
+		columns, err := q.Names()
+		if err != nil {
+			return err
+		}
+		if err := q.Expect("REFERENCES"); err != nil {
+			return err
+		}
+		if name == "" {
+			name = table.Name + "_" + strings.Join(columns, "_") + "_fkey"
+		}
+		if err := p.references(q, table, name, columns); err != nil {
+			return err
+		}
+		if q.Accept("NOT", "VALID") {
+			table.ForeignKeys[len(table.ForeignKeys)-1].NotValid = true
+		}
+	case q.Accept("CHECK"):
+		if name == "" {
+			name = table.Name + "_check"
+		}
+		if err := check(q, table, name); err != nil {
+			return err
+		}
+		if q.Accept("NOT", "VALID") {
+			table.Checks[len(table.Checks)-1].NotValid = true
+		}
+	default:
+		return fmt.Errorf("unsupported table constraint %q", q.Peek().Text)
+	}
+	return nil
+}
+
+// references reads the referenced table and columns and the actions of a
+// foreign key
+func (p *ddlParser) references(q *ddl.Parser, table *schema.Table, name string, columns []string) error {
+	schemaName, refTable, err := q.Name()
+	if err != nil {
+		return err
+	}
+	var refColumns []string
+	if q.Is("(") {
+		if refColumns, err = q.Names(); err != nil {
+			return err
+		}
+	} else if refTable, err := p.findTable(schemaName, refTable); err == nil && refTable.PrimaryKey != nil {
+		refColumns = refTable.PrimaryKey.Columns
+	} else {
+		return fmt.Errorf("foreign key %s must list the referenced columns", name)
+	}
+
+	fk := table.ForeignKey(name, columns, qualifiedName(schemaName, refTable), refColumns)
+	for {
+		switch {
+		case q.Accept("ON", "DELETE"):
+			fk.OnDelete = referentialAction(q)
+		case q.Accept("ON", "UPDATE"):
+			fk.OnUpdate = referentialAction(q)
+		case q.Accept("MATCH", "SIMPLE"):
+		default:
+			return nil
+		}
+	}
+}
+
+// referentialAction reads the action of a foreign key, empty for NO ACTION as Inspect sets it
+func referentialAction(q *ddl.Parser) string {
+	for _, action := range [][]string{{"CASCADE"}, {"RESTRICT"}, {"SET", "NULL"}, {"SET", "DEFAULT"}} {
+		if q.Accept(action...) {
+			return strings.Join(action, " ")
+		}
+	}
+	q.Accept("NO", "ACTION")
+	return ""
+}
+
+func check(q *ddl.Parser, table *schema.Table, name string) error {
+	expression, err := q.Group()
+	if err != nil {
+		return err
+	}
+	table.Check(name, checkExpression("CHECK ("+expression+")"))
+	return nil
+}
+
+func (p *ddlParser) createIndex(q *ddl.Parser, unique bool) error {
+	q.Accept("CONCURRENTLY")
+	q.Accept("IF", "NOT", "EXISTS")
+	name := ""
+	if !q.Is("ON") {
+		var err error
+		if name, err = q.Ident(); err != nil {
+			return err
+		}
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	q.Accept("ONLY")
+	schemaName, tableName, err := q.Name()
+	if err != nil {
+		return err
+	}
+	table, err := p.findTable(schemaName, tableName)
+	if err != nil {
+		return err
+	}
+
+	index := &schema.Index{Name: name, Unique: unique}
+	if q.Accept("USING") {
+		method, err := q.Ident()
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(method, "btree") {
+			index.Method = strings.ToLower(method)
+		}
+	}
+	keys, err := q.Items()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := indexKey(ddl.NewParser(key, parseOptions), index); err != nil {
+			return err
+		}
+	}
+	if q.Accept("INCLUDE") {
+		if index.Include, err = q.Names(); err != nil {
+			return err
+		}
+	}
+	if q.Accept("WHERE") {
+		index.Where = checkExpression("CHECK (" + q.Rest() + ")")
+	}
+	if index.Name == "" {
+		index.Name = table.Name + "_" + strings.Join(index.Columns, "_") + "_idx"
+	}
+	table.Indexes = append(table.Indexes, index)
+	return nil
+}
+
+// indexKey reads a key column or expression of an index with its operator
+// class, sort order and nulls order
+func indexKey(q *ddl.Parser, index *schema.Index) error {
+	var key string
+	if q.Is("(") {
+		expression, err := q.Group()
+		if err != nil {
+			return err
+		}
+		key = "(" + expression + ")"
+	} else {
+		text := q.Until("COLLATE", "ASC", "DESC", "NULLS")
+		tokens := ddl.Tokenize(text, parseOptions)
+		if isNameList(tokens) {
+			key = tokens[0].Value()
+			if len(tokens) > 1 {
+				schema.OpClass(key, text[tokens[1].Pos:])(index)
+			}
+		} else {
+			// pg_dump writes function calls without parentheses around them
+			key = "(" + text + ")"
+		}
+	}
+	index.Columns = append(index.Columns, key)
+
+	if q.Accept("COLLATE") {
+		if _, err := q.Path(); err != nil {
+			return err
+		}
+	}
+	desc := false
+	if order, ok := q.AcceptAny("ASC", "DESC"); ok && order == "DESC" {
+		desc = true
+		schema.Desc(key)(index)
+	}
+	// Nulls sort last in ascending and first in descending order unless given
+	if q.Accept("NULLS", "FIRST") && !desc {
+		schema.NullsFirst(key)(index)
+	} else if q.Accept("NULLS", "LAST") && desc {
+		schema.NullsLast(key)(index)
+	}
+	return nil
+}
+
+// isNameList reports whether tokens are a column followed by an optional,
+// possibly qualified operator class
+func isNameList(tokens []ddl.Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, token := range tokens {
+		if token.Kind != ddl.Word && token.Kind != ddl.Identifier && token.Text != "." {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *ddlParser) createView(q *ddl.Parser) error {
+	schemaName, name, err := q.Name()
+	if err != nil {
+		return err
+	}
+	view := &schema.View{Schema: objectSchema(schemaName), Name: name, Options: []string{}}
+	if q.Is("(") {
+		if view.Columns, err = q.Names(); err != nil {
+			return err
+		}
+	}
+	if q.Accept("WITH") {
+		if view.Options, err = q.Items(); err != nil {
+			return err
+		}
+	}
+	if err := q.Expect("AS"); err != nil {
+		return err
+	}
+	view.Definition = q.Until("WITH CASCADED CHECK OPTION", "WITH LOCAL CHECK OPTION", "WITH CHECK OPTION")
+	if !q.Done() {
+		return fmt.Errorf("view check options are not supported")
+	}
+	p.schema.Views = append(p.schema.Views, view)
+	return nil
+}
+
+// functionStops are the keywords that end the return type of a function
+var functionStops = []string{
+	"LANGUAGE", "IMMUTABLE", "STABLE", "VOLATILE", "STRICT", "CALLED", "RETURNS NULL", "SECURITY",
+	"EXTERNAL", "COST", "ROWS", "PARALLEL", "LEAKPROOF", "NOT", "SET", "AS", "WINDOW", "SUPPORT", "TRANSFORM", "BEGIN",
+}
+
+func (p *ddlParser) createFunction(q *ddl.Parser) error {
+	schemaName, name, err := q.Name()
+	if err != nil {
+		return err
+	}
+	items, err := q.Items()
+	if err != nil {
+		return err
+	}
+	args := make([]schema.FunctionArg, 0, len(items))
+	for _, item := range items {
+		args = append(args, functionArg(ddl.NewParser(item, parseOptions)))
+	}
+	if err := q.Expect("RETURNS"); err != nil {
+		return err
+	}
+	returns := q.Until(functionStops...)
+
+	function := p.schema.CreateFunction(name, returns, "", schema.FunctionInSchema(objectSchema(schemaName)), schema.FunctionArgs(args...))
+	for !q.Done() {
+		switch {
+		case q.Accept("LANGUAGE"):
+			language, err := q.Ident()
+			if err != nil {
+				return err
+			}
+			function.Language = strings.ToLower(language)
+		case q.Accept("IMMUTABLE"):
+			schema.Immutable(function)
+		case q.Accept("STABLE"):
+			schema.Stable(function)
+		case q.Accept("VOLATILE"):
+			schema.Volatile(function)
+		case q.Accept("STRICT"), q.Accept("RETURNS", "NULL", "ON", "NULL", "INPUT"):
+			schema.Strict(function)
+		case q.Accept("CALLED", "ON", "NULL", "INPUT"):
+			schema.NotStrict(function)
+		case q.Accept("SECURITY", "DEFINER"), q.Accept("EXTERNAL", "SECURITY", "DEFINER"):
+			schema.SecurityDefiner(function)
+		case q.Accept("SECURITY", "INVOKER"), q.Accept("EXTERNAL", "SECURITY", "INVOKER"):
+			schema.SecurityInvoker(function)
+		case q.Accept("COST"):
+			cost, err := q.Int()
+			if err != nil {
+				return err
+			}
+			function.Cost = int(cost)
+		case q.Accept("AS"):
+			if function.Body, err = q.String(); err != nil {
+				return err
+			}
+			if q.Is(",") {
+				return fmt.Errorf("functions in object files are not supported")
+			}
+		default:
+			p.schema.Functions = p.schema.Functions[:len(p.schema.Functions)-1]
+			return fmt.Errorf("unsupported function attribute %q", q.Peek().Text)
+		}
+	}
+	return nil
+}
+
+// multiwordTypes are the first words of type names made of several words,
+// which are not argument names
+var multiwordTypes = []string{"double", "character", "bit", "timestamp", "time", "interval", "national"}
+
+// functionArg reads an argument of a function, with its mode, name, type and default
+func functionArg(q *ddl.Parser) schema.FunctionArg {
+	arg := schema.FunctionArg{Mode: "IN"}
+	if mode, ok := q.AcceptAny("IN", "OUT", "INOUT", "VARIADIC"); ok {
+		arg.Mode = mode
+	}
+	declaration := q.Until("DEFAULT", "=")
+	tokens := ddl.Tokenize(declaration, parseOptions)
+	arg.Type = declaration
+	if len(tokens) > 1 && (tokens[1].Kind == ddl.Word || tokens[1].Kind == ddl.Identifier) &&
+		!(tokens[0].Kind == ddl.Word && slices.Contains(multiwordTypes, strings.ToLower(tokens[0].Text))) {
+		arg.Name = tokens[0].Value()
+		arg.Type = declaration[tokens[1].Pos:]
+	}
+	if q.Accept("DEFAULT") || q.Accept("=") {
+		arg.Default = q.Rest()
+	}
+	return arg
+}
+
+func (p *ddlParser) createTrigger(q *ddl.Parser) error {
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	timing, ok := q.AcceptAny("BEFORE", "AFTER")
+	if !ok {
+		if err := q.Expect("INSTEAD", "OF"); err != nil {
+			return err
+		}
+		timing = "INSTEAD OF"
+	}
+	var events []string
+	for {
+		event, ok := q.AcceptAny("INSERT", "UPDATE", "DELETE", "TRUNCATE")
+		if !ok {
+			return fmt.Errorf("expected trigger event, found %q", q.Peek().Text)
+		}
+		events = append(events, event)
+		if event == "UPDATE" && q.Accept("OF") {
+			q.Until("OR", "ON")
+		}
+		if !q.Accept("OR") {
+			break
+		}
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	schemaName, tableName, err := q.Name()
+	if err != nil {
+		return err
+	}
+
+	forEach := "STATEMENT"
+	if q.Accept("FOR") {
+		q.Accept("EACH")
+		if forEach, ok = q.AcceptAny("ROW", "STATEMENT"); !ok {
+			return fmt.Errorf("expected ROW or STATEMENT, found %q", q.Peek().Text)
+		}
+	}
+	when := ""
+	if q.Accept("WHEN") {
+		if when, err = q.Group(); err != nil {
+			return err
+		}
+	}
+	if err := q.Expect("EXECUTE"); err != nil {
+		return err
+	}
+	if _, ok := q.AcceptAny("FUNCTION", "PROCEDURE"); !ok {
+		return fmt.Errorf("expected FUNCTION or PROCEDURE, found %q", q.Peek().Text)
+	}
+	functionSchema, function, err := q.Name()
+	if err != nil {
+		return err
+	}
+	args, err := q.Items()
+	if err != nil {
+		return err
+	}
+
+	trigger := p.schema.CreateTrigger(name, tableName, qualifiedName(functionSchema, function),
+		schema.TriggerInSchema(objectSchema(schemaName)), schema.OnEvents(events...), schema.WithCondition(when))
+	trigger.Timing = timing
+	trigger.ForEach = forEach
+	if len(args) > 0 {
+		trigger.Arguments = args
+	}
+	return nil
+}
+
+func (p *ddlParser) createPolicy(q *ddl.Parser) error {
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	schemaName, tableName, err := q.Name()
+	if err != nil {
+		return err
+	}
+
+	policy := &schema.RowPolicy{
+		Schema:      objectSchema(schemaName),
+		TableName:   tableName,
+		PolicyName:  name,
+		CommandType: "ALL",
+		Roles:       []string{"public"},
+		Permissive:  true,
+	}
+	if q.Accept("AS") {
+		kind, ok := q.AcceptAny("PERMISSIVE", "RESTRICTIVE")
+		if !ok {
+			return fmt.Errorf("expected PERMISSIVE or RESTRICTIVE, found %q", q.Peek().Text)
+		}
+		policy.Permissive = kind == "PERMISSIVE"
+	}
+	if q.Accept("FOR") {
+		command, ok := q.AcceptAny("ALL", "SELECT", "INSERT", "UPDATE", "DELETE")
+		if !ok {
+			return fmt.Errorf("expected policy command, found %q", q.Peek().Text)
+		}
+		policy.CommandType = command
+	}
+	if q.Accept("TO") {
+		policy.Roles = nil
+		for {
+			role, err := q.Ident()
+			if err != nil {
+				return err
+			}
+			policy.Roles = append(policy.Roles, role)
+			if !q.Accept(",") {
+				break
+			}
+		}
+	}
+	if q.Accept("USING") {
+		if policy.UsingExpr, err = q.Group(); err != nil {
+			return err
+		}
+	}
+	if q.Accept("WITH", "CHECK") {
+		if policy.CheckExpr, err = q.Group(); err != nil {
+			return err
+		}
+	}
+	p.schema.RowPolicies = append(p.schema.RowPolicies, policy)
+	return nil
+}
+
+func (p *ddlParser) alterTable(q *ddl.Parser) error {
+	q.Accept("IF", "EXISTS")
+	q.Accept("ONLY")
+	schemaName, name, err := q.Name()
+	if err != nil {
+		return err
+	}
+	table, err := p.findTable(schemaName, name)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := p.alterTableAction(q, table); err != nil {
+			return err
+		}
+		if !q.Accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *ddlParser) alterTableAction(q *ddl.Parser, table *schema.Table) error {
+	switch {
+	case q.Accept("ADD"):
+		if isTableConstraint(q) {
+			return p.tableConstraint(q, table)
+		}
+		q.Accept("COLUMN")
+		q.Accept("IF", "NOT", "EXISTS")
+		r := ddl.NewParser(q.Until(","), parseOptions)
+		if err := p.column(r, table); err != nil {
+			return err
+		}
+		if !r.Done() {
+			return fmt.Errorf("unexpected %q", r.Peek().Text)
+		}
+		return nil
+	case q.Accept("ALTER"):
+		q.Accept("COLUMN")
+		name, err := q.Ident()
+		if err != nil {
+			return err
+		}
+		column, err := findColumn(table, name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case q.Accept("SET", "DEFAULT"):
+			column.Default = q.Until(",")
+		case q.Accept("DROP", "DEFAULT"):
+			column.Default = ""
+		case q.Accept("SET", "NOT", "NULL"):
+			column.Nullable = false
+		case q.Accept("DROP", "NOT", "NULL"):
+			column.Nullable = true
+		case q.Accept("ADD", "GENERATED"):
+			if !q.Accept("ALWAYS") && !q.Accept("BY", "DEFAULT") {
+				return fmt.Errorf("expected ALWAYS or BY DEFAULT, found %q", q.Peek().Text)
+			}
+			if err := q.Expect("AS", "IDENTITY"); err != nil {
+				return err
+			}
+			if q.Is("(") {
+				if _, err := q.Group(); err != nil {
+					return err
+				}
+			}
+			column.AutoIncrement = true
+		default:
+			return fmt.Errorf("unsupported ALTER COLUMN action %q", q.Peek().Text)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported ALTER TABLE action %q", q.Peek().Text)
+}
+
+func (p *ddlParser) commentOnColumn(q *ddl.Parser) error {
+	path, err := q.Path()
+	if err != nil {
+		return err
+	}
+	if len(path) < 2 {
+		return fmt.Errorf("expected table.column, found %q", strings.Join(path, "."))
+	}
+	schemaName := ""
+	if len(path) > 2 {
+		schemaName = path[len(path)-3]
+	}
+	table, err := p.findTable(schemaName, path[len(path)-2])
+	if err != nil {
+		return err
+	}
+	column, err := findColumn(table, path[len(path)-1])
+	if err != nil {
+		return err
+	}
+	if err := q.Expect("IS"); err != nil {
+		return err
+	}
+	if q.Accept("NULL") {
+		column.Comment = ""
+		return nil
+	}
+	column.Comment, err = q.String()
+	return err
+}
@@ -0,0 +1,160 @@
+package postgresql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestParseSQL(t *testing.T) {
+	s, err := New().ParseSQL(`--
+-- PostgreSQL database dump
+--
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+CREATE EXTENSION IF NOT EXISTS pgcrypto WITH SCHEMA public;
+CREATE TYPE public.status AS ENUM (
+    'active',
+    'disabled'
+);
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$;
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    email character varying(255) NOT NULL,
+    status public.status DEFAULT 'active'::public.status NOT NULL,
+    updated_at timestamp with time zone,
+    CONSTRAINT users_email_check CHECK ((email <> ''::text))
+);
+ALTER TABLE public.users OWNER TO app;
+CREATE SEQUENCE public.users_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+CREATE TABLE public.posts (
+    id bigint NOT NULL,
+    user_id bigint,
+    title text
+);
+ALTER TABLE ONLY public.posts
+    ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (lower((email)::text));
+CREATE INDEX posts_title_idx ON public.posts USING btree (title DESC);
+CREATE VIEW public.active_users AS
+ SELECT users.id
+   FROM public.users
+  WHERE (users.status = 'active'::public.status);
+CREATE TRIGGER users_touch BEFORE UPDATE ON public.users FOR EACH ROW EXECUTE FUNCTION public.touch();
+CREATE POLICY own_posts ON public.posts USING ((user_id = 1));
+COMMENT ON COLUMN public.users.email IS 'login';
+`)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"pgcrypto"}, s.Extensions)
+	require.Equal(t, []*schema.Enum{{Name: "status", Values: []string{"active", "disabled"}}}, s.Enums)
+	require.Equal(t, []*schema.Sequence{
+		{Name: "users_id_seq", Start: 1, Increment: 1, MinValue: 1, MaxValue: 9223372036854775807, Cache: 1},
+	}, s.Sequences)
+
+	require.Len(t, s.Tables, 2)
+	users := s.Tables[0]
+	require.Equal(t, []*schema.Column{
+		{Name: "id", Type: &schema.BigIntType{}, Default: "nextval('public.users_id_seq'::regclass)"},
+		{Name: "email", Type: &schema.VarcharType{Length: 255}, Comment: "login"},
+		{Name: "status", Type: &EnumType{Name: "status"}, Default: "'active'::public.status"},
+		{Name: "updated_at", Type: &schema.TimestampType{WithTimeZone: true}, Nullable: true},
+	}, users.Columns)
+	require.Equal(t, &schema.PrimaryKey{Name: "users_pkey", Columns: []string{"id"}}, users.PrimaryKey)
+	require.Equal(t, []*schema.CheckConstraint{{Name: "users_email_check", Expression: "email <> ''::text"}}, users.Checks)
+	require.Equal(t, []*schema.Index{
+		{Name: "users_email_idx", Columns: []string{"(lower((email)::text))"}, Unique: true},
+	}, users.Indexes)
+
+	posts := s.Tables[1]
+	require.Equal(t, []*schema.ForeignKey{
+		{Name: "posts_user_id_fkey", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+	}, posts.ForeignKeys)
+	require.Equal(t, []*schema.Index{
+		{Name: "posts_title_idx", Columns: []string{"title"}, KeyOptions: []*schema.IndexKeyOption{{Column: "title", Desc: true}}},
+	}, posts.Indexes)
+
+	require.Equal(t, []*schema.Function{
+		{
+			Schema:     "public",
+			Name:       "touch",
+			Arguments:  []schema.FunctionArg{},
+			Returns:    "trigger",
+			Language:   "plpgsql",
+			Body:       "\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n",
+			Volatility: "VOLATILE",
+			Security:   "INVOKER",
+			Cost:       100,
+		},
+	}, s.Functions)
+	require.Equal(t, []*schema.Trigger{
+		{
+			Schema:    "public",
+			Name:      "users_touch",
+			Table:     "users",
+			Events:    []string{"UPDATE"},
+			Timing:    "BEFORE",
+			ForEach:   "ROW",
+			Function:  "touch",
+			Arguments: []string{},
+		},
+	}, s.Triggers)
+	require.Equal(t, []*schema.View{
+		{
+			Schema:     "public",
+			Name:       "active_users",
+			Definition: "SELECT users.id\n   FROM public.users\n  WHERE (users.status = 'active'::public.status)",
+			Options:    []string{},
+		},
+	}, s.Views)
+	require.Equal(t, []*schema.RowPolicy{
+		{
+			Schema:      "public",
+			TableName:   "posts",
+			PolicyName:  "own_posts",
+			CommandType: "ALL",
+			Roles:       []string{"public"},
+			UsingExpr:   "(user_id = 1)",
+			Permissive:  true,
+		},
+	}, s.RowPolicies)
+}
+
+func TestParseSQLUnsupported(t *testing.T) {
+	s, err := New().ParseSQL(`CREATE TABLE users (id integer PRIMARY KEY);
+
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+CREATE TABLE points (location point);
+CREATE INDEX users_id_idx ON users (id);
+`)
+
+	var parseErr *dbx.ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, []dbx.UnsupportedStatement{
+		{Line: 3, Statement: "ALTER TABLE users ENABLE ROW LEVEL SECURITY", Reason: `unsupported ALTER TABLE action "ENABLE"`},
+		{Line: 4, Statement: "CREATE TABLE points (location point)", Reason: `unsupported column type "point"`},
+	}, parseErr.Unsupported)
+
+	require.Len(t, s.Tables, 1)
+	require.Equal(t, "users", s.Tables[0].Name)
+	require.Len(t, s.Tables[0].Indexes, 1)
+}
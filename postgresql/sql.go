@@ -2,14 +2,21 @@ package postgresql
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
+var (
+	_ dbx.NonTransactional = (*PostgreSQL)(nil)
+	_ dbx.HazardClassifier = (*PostgreSQL)(nil)
+)
+
 // GenerateSQL converts a schema change to a PostgreSQL SQL statement
 func (pg *PostgreSQL) GenerateSQL(change schema.Change) (string, error) {
-	switch c := change.(type) {
+	switch c := schema.Indirect(change).(type) {
 	// Schema-related changes
 	case schema.CreateSchemaChange:
 		return pg.generateCreateSchema(c), nil
@@ -27,6 +34,8 @@ func (pg *PostgreSQL) GenerateSQL(change schema.Change) (string, error) {
 		return pg.generateCreateTable(c), nil
 	case schema.DropTableChange:
 		return pg.generateDropTable(c), nil
+	case schema.RenameTableChange:
+		return pg.generateRenameTable(c), nil
 
 	// Column-related changes
 	case schema.AddColumnChange:
@@ -35,6 +44,8 @@ func (pg *PostgreSQL) GenerateSQL(change schema.Change) (string, error) {
 		return pg.generateDropColumn(c), nil
 	case schema.AlterColumnChange:
 		return pg.generateAlterColumn(c), nil
+	case schema.RenameColumnChange:
+		return pg.generateRenameColumn(c), nil
 
 	// Primary key-related changes
 	case schema.AddPrimaryKeyChange:
@@ -47,6 +58,8 @@ func (pg *PostgreSQL) GenerateSQL(change schema.Change) (string, error) {
 		return pg.generateAddIndex(c), nil
 	case schema.DropIndexChange:
 		return pg.generateDropIndex(c), nil
+	case schema.RenameIndexChange:
+		return pg.generateRenameIndex(c), nil
 
 	// Foreign key-related changes
 	case schema.AddForeignKeyChange:
@@ -54,6 +67,28 @@ func (pg *PostgreSQL) GenerateSQL(change schema.Change) (string, error) {
 	case schema.DropForeignKeyChange:
 		return pg.generateDropForeignKey(c), nil
 
+	// Constraint-related changes
+	case schema.AddCheckChange:
+		return pg.generateAddCheck(c), nil
+	case schema.DropCheckChange:
+		return pg.generateDropConstraint(c.TableName, c.CheckName), nil
+	case schema.AddUniqueConstraintChange:
+		return pg.generateAddUnique(c), nil
+	case schema.DropUniqueConstraintChange:
+		return pg.generateDropConstraint(c.TableName, c.ConstraintName), nil
+	case schema.ValidateConstraintChange:
+		return pg.generateValidateConstraint(c), nil
+
+	// Enum-related changes
+	case schema.CreateEnumChange:
+		return pg.generateCreateEnum(c), nil
+	case schema.DropEnumChange:
+		return pg.generateDropEnum(c), nil
+	case schema.AddEnumValueChange:
+		return pg.generateAddEnumValue(c), nil
+	case schema.AlterEnumChange:
+		return pg.generateAlterEnum(c), nil
+
 	// Sequence-related changes
 	case schema.CreateSequenceChange:
 		return pg.generateCreateSequence(c), nil
@@ -99,6 +134,33 @@ func (pg *PostgreSQL) GenerateSQL(change schema.Change) (string, error) {
 	}
 }
 
+// OutsideTransaction reports whether the SQL for change must run outside a
+// transaction, which is the case for indexes created or dropped concurrently.
+// Constraint validation is run outside one too, so that the lock taken when
+// the constraint was added NOT VALID is released before the table is scanned,
+// and so are new enum values, which later changes cannot use until committed.
+func (pg *PostgreSQL) OutsideTransaction(change schema.Change) bool {
+	switch c := schema.Indirect(change).(type) {
+	case schema.AddIndexChange:
+		return c.Concurrently || pg.concurrentIndexes
+	case schema.DropIndexChange:
+		return c.Concurrently || pg.concurrentIndexes
+	case schema.ValidateConstraintChange:
+		return true
+	case schema.AddEnumValueChange:
+		return true
+	}
+	return false
+}
+
+// ClassifyHazards removes the long lock hazard of indexes built concurrently,
+// which do not block writes
+func (pg *PostgreSQL) ClassifyHazards(change schema.Change) {
+	if c, ok := change.(*schema.AddIndexChange); ok && pg.concurrentIndexes {
+		c.RemoveHazards(schema.HazardLongLock)
+	}
+}
+
 // Schema-related SQL generation
 
 func (pg *PostgreSQL) generateCreateSchema(c schema.CreateSchemaChange) string {
@@ -162,6 +224,18 @@ func (pg *PostgreSQL) generateCreateTable(c schema.CreateTableChange) string {
 			strings.Join(pkColumnsList, ", ")))
 	}
 
+	for _, unique := range table.Uniques {
+		sb.WriteString(fmt.Sprintf(",\n  CONSTRAINT %s UNIQUE (%s)",
+			quoteIdentifier(unique.Name),
+			quoteIdentifiers(unique.Columns)))
+	}
+
+	for _, check := range table.Checks {
+		sb.WriteString(fmt.Sprintf(",\n  CONSTRAINT %s CHECK (%s)",
+			quoteIdentifier(check.Name),
+			check.Expression))
+	}
+
 	sb.WriteString("\n);")
 
 	// Add comments for columns if present
@@ -185,6 +259,14 @@ func (pg *PostgreSQL) generateDropTable(c schema.DropTableChange) string {
 	return fmt.Sprintf("DROP TABLE %s;", quoteIdentifier(tableName))
 }
 
+func (pg *PostgreSQL) generateRenameTable(c schema.RenameTableChange) string {
+	tableName := c.OldName
+	if c.SchemaName != "" && c.SchemaName != "public" {
+		tableName = c.SchemaName + "." + tableName
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteIdentifier(tableName), quoteIdentifier(c.NewName))
+}
+
 // Column-related SQL generation
 
 func (pg *PostgreSQL) generateAddColumn(c schema.AddColumnChange) string {
@@ -221,45 +303,63 @@ func (pg *PostgreSQL) generateDropColumn(c schema.DropColumnChange) string {
 		quoteIdentifier(c.ColumnName))
 }
 
+func (pg *PostgreSQL) generateRenameColumn(c schema.RenameColumnChange) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.OldName),
+		quoteIdentifier(c.NewName))
+}
+
 func (pg *PostgreSQL) generateAlterColumn(c schema.AlterColumnChange) string {
 	column := c.Column
+	all := len(c.Changed) == 0
 	var statements []string
 
 	// Type change
-	statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
-		quoteIdentifier(c.TableName),
-		quoteIdentifier(column.Name),
-		column.TypeSQL()))
+	if all || c.Changed.Has("Type") {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+			quoteIdentifier(c.TableName),
+			quoteIdentifier(column.Name),
+			column.TypeSQL()))
+	}
 
 	// Nullability change
-	if !column.Nullable {
-		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;",
-			quoteIdentifier(c.TableName),
-			quoteIdentifier(column.Name)))
-	} else {
-		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;",
-			quoteIdentifier(c.TableName),
-			quoteIdentifier(column.Name)))
+	if all || c.Changed.Has("Nullable") {
+		if !column.Nullable {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;",
+				quoteIdentifier(c.TableName),
+				quoteIdentifier(column.Name)))
+		} else {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;",
+				quoteIdentifier(c.TableName),
+				quoteIdentifier(column.Name)))
+		}
 	}
 
 	// Default value change
-	if column.Default != "" {
-		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;",
-			quoteIdentifier(c.TableName),
-			quoteIdentifier(column.Name),
-			column.Default))
-	} else {
-		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;",
-			quoteIdentifier(c.TableName),
-			quoteIdentifier(column.Name)))
+	if all || c.Changed.Has("Default") {
+		if column.Default != "" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;",
+				quoteIdentifier(c.TableName),
+				quoteIdentifier(column.Name),
+				column.Default))
+		} else {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;",
+				quoteIdentifier(c.TableName),
+				quoteIdentifier(column.Name)))
+		}
 	}
 
 	// Comment change
-	if column.Comment != "" {
+	if column.Comment != "" && (all || c.Changed.Has("Comment")) {
 		statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;",
 			quoteIdentifier(c.TableName),
 			quoteIdentifier(column.Name),
 			quoteLiteral(column.Comment)))
+	} else if c.Changed.Has("Comment") {
+		statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS NULL;",
+			quoteIdentifier(c.TableName),
+			quoteIdentifier(column.Name)))
 	}
 
 	return strings.Join(statements, "\n")
@@ -295,20 +395,70 @@ func (pg *PostgreSQL) generateAddIndex(c schema.AddIndexChange) string {
 		unique = "UNIQUE "
 	}
 
-	columns := make([]string, len(idx.Columns))
-	for i, col := range idx.Columns {
-		columns[i] = quoteIdentifier(col)
+	concurrently := ""
+	if c.Concurrently || pg.concurrentIndexes {
+		concurrently = "CONCURRENTLY "
 	}
 
-	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);",
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("CREATE %sINDEX %s%s ON %s",
 		unique,
+		concurrently,
 		quoteIdentifier(idx.Name),
-		quoteIdentifier(c.TableName),
-		strings.Join(columns, ", "))
+		quoteIdentifier(c.TableName)))
+
+	if idx.Method != "" {
+		sb.WriteString(" USING " + strings.ToLower(idx.Method))
+	}
+
+	sb.WriteString(fmt.Sprintf(" (%s)", indexKeys(idx)))
+
+	if len(idx.Include) > 0 {
+		sb.WriteString(fmt.Sprintf(" INCLUDE (%s)", quoteIdentifiers(idx.Include)))
+	}
+
+	if idx.Where != "" {
+		sb.WriteString(" WHERE " + idx.Where)
+	}
+
+	sb.WriteString(";")
+	return sb.String()
+}
+
+// indexKeys renders the key columns and expressions of an index with their
+// operator class and sort order
+func indexKeys(idx *schema.Index) string {
+	keys := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		key := col
+		if !strings.HasPrefix(col, "(") {
+			key = quoteIdentifier(col)
+		}
+
+		option := idx.KeyOption(col)
+		if option.OpClass != "" {
+			key += " " + option.OpClass
+		}
+		if option.Desc {
+			key += " DESC"
+		}
+		if option.Nulls != "" {
+			key += " NULLS " + strings.ToUpper(option.Nulls)
+		}
+		keys[i] = key
+	}
+	return strings.Join(keys, ", ")
 }
 
 func (pg *PostgreSQL) generateDropIndex(c schema.DropIndexChange) string {
-	return fmt.Sprintf("DROP INDEX %s;", quoteIdentifier(c.IndexName))
+	if c.Concurrently || pg.concurrentIndexes {
+		return fmt.Sprintf("DROP INDEX CONCURRENTLY %s;", quoteIdentifier(indexName(c.TableName, c.IndexName)))
+	}
+	return fmt.Sprintf("DROP INDEX %s;", quoteIdentifier(indexName(c.TableName, c.IndexName)))
+}
+
+func (pg *PostgreSQL) generateRenameIndex(c schema.RenameIndexChange) string {
+	return fmt.Sprintf("ALTER INDEX %s RENAME TO %s;", quoteIdentifier(indexName(c.TableName, c.OldName)), quoteIdentifier(c.NewName))
 }
 
 // Foreign key-related SQL generation
@@ -341,6 +491,10 @@ func (pg *PostgreSQL) generateAddForeignKey(c schema.AddForeignKeyChange) string
 		sql += fmt.Sprintf(" ON UPDATE %s", fk.OnUpdate)
 	}
 
+	if c.NotValid || fk.NotValid {
+		sql += " NOT VALID"
+	}
+
 	return sql + ";"
 }
 
@@ -350,6 +504,91 @@ func (pg *PostgreSQL) generateDropForeignKey(c schema.DropForeignKeyChange) stri
 		quoteIdentifier(c.FKName))
 }
 
+// Constraint-related SQL generation
+
+func (pg *PostgreSQL) generateAddCheck(c schema.AddCheckChange) string {
+	notValid := ""
+	if c.NotValid || c.Check.NotValid {
+		notValid = " NOT VALID"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s)%s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.Check.Name),
+		c.Check.Expression,
+		notValid)
+}
+
+func (pg *PostgreSQL) generateValidateConstraint(c schema.ValidateConstraintChange) string {
+	return fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.ConstraintName))
+}
+
+func (pg *PostgreSQL) generateAddUnique(c schema.AddUniqueConstraintChange) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);",
+		quoteIdentifier(c.TableName),
+		quoteIdentifier(c.Unique.Name),
+		quoteIdentifiers(c.Unique.Columns))
+}
+
+func (pg *PostgreSQL) generateDropConstraint(tableName, constraintName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;",
+		quoteIdentifier(tableName),
+		quoteIdentifier(constraintName))
+}
+
+// Enum-related SQL generation
+
+func (pg *PostgreSQL) generateCreateEnum(c schema.CreateEnumChange) string {
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);",
+		quoteIdentifier(c.Enum.QualifiedName()),
+		quoteLiterals(c.Enum.Values))
+}
+
+func (pg *PostgreSQL) generateDropEnum(c schema.DropEnumChange) string {
+	return fmt.Sprintf("DROP TYPE %s;", quoteIdentifier(qualifiedName(c.SchemaName, c.EnumName)))
+}
+
+// ALTER TYPE ... ADD VALUE cannot run inside a transaction block before
+// PostgreSQL 12, and the new value cannot be used until the transaction commits
+func (pg *PostgreSQL) generateAddEnumValue(c schema.AddEnumValueChange) string {
+	sql := fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", quoteIdentifier(c.Enum.QualifiedName()), quoteLiteral(c.Value))
+	if c.Before != "" {
+		sql += " BEFORE " + quoteLiteral(c.Before)
+	} else if c.After != "" {
+		sql += " AFTER " + quoteLiteral(c.After)
+	}
+	return sql + ";"
+}
+
+// generateAlterEnum recreates an enum type with new values: the old type is
+// renamed, the new one created, the columns converted through text and the old
+// type dropped
+func (pg *PostgreSQL) generateAlterEnum(c schema.AlterEnumChange) string {
+	enumName := quoteIdentifier(c.Enum.QualifiedName())
+	oldName := c.Enum.Name + "_old"
+
+	statements := []string{
+		fmt.Sprintf("ALTER TYPE %s RENAME TO %s;", enumName, quoteIdentifier(oldName)),
+		fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);", enumName, quoteLiterals(c.Enum.Values)),
+	}
+	for _, col := range c.Columns {
+		tableName := quoteIdentifier(col.TableName)
+		columnName := quoteIdentifier(col.ColumnName)
+		if col.Default != "" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", tableName, columnName))
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::text::%s;",
+			tableName, columnName, enumName, columnName, enumName))
+		if col.Default != "" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", tableName, columnName, col.Default))
+		}
+	}
+	statements = append(statements, fmt.Sprintf("DROP TYPE %s;", quoteIdentifier(qualifiedName(c.Enum.Schema, oldName))))
+
+	return strings.Join(statements, "\n")
+}
+
 // Sequence-related SQL generation
 
 func (pg *PostgreSQL) generateCreateSequence(c schema.CreateSequenceChange) string {
@@ -393,6 +632,7 @@ func (pg *PostgreSQL) generateCreateSequence(c schema.CreateSequenceChange) stri
 
 func (pg *PostgreSQL) generateAlterSequence(c schema.AlterSequenceChange) string {
 	seq := c.Sequence
+	all := len(c.Changed) == 0
 	var sb strings.Builder
 
 	sequenceName := seq.Name
@@ -402,31 +642,42 @@ func (pg *PostgreSQL) generateAlterSequence(c schema.AlterSequenceChange) string
 
 	sb.WriteString(fmt.Sprintf("ALTER SEQUENCE %s", quoteIdentifier(sequenceName)))
 
-	// We only include properties that make sense to alter
-	if seq.Increment != 1 {
+	// Without the changed attributes we only include properties that make
+	// sense to alter
+	if (all && seq.Increment != 1) || c.Changed.Has("Increment") {
 		sb.WriteString(fmt.Sprintf(" INCREMENT BY %d", seq.Increment))
 	}
 
-	if seq.MinValue != 1 {
-		sb.WriteString(fmt.Sprintf(" MINVALUE %d", seq.MinValue))
-	} else {
-		sb.WriteString(" NO MINVALUE")
+	if all || c.Changed.Has("MinValue") {
+		if seq.MinValue != 1 {
+			sb.WriteString(fmt.Sprintf(" MINVALUE %d", seq.MinValue))
+		} else {
+			sb.WriteString(" NO MINVALUE")
+		}
 	}
 
-	if seq.MaxValue != 9223372036854775807 {
-		sb.WriteString(fmt.Sprintf(" MAXVALUE %d", seq.MaxValue))
-	} else {
-		sb.WriteString(" NO MAXVALUE")
+	if all || c.Changed.Has("MaxValue") {
+		if seq.MaxValue != 9223372036854775807 {
+			sb.WriteString(fmt.Sprintf(" MAXVALUE %d", seq.MaxValue))
+		} else {
+			sb.WriteString(" NO MAXVALUE")
+		}
 	}
 
-	if seq.Cache != 1 {
+	if c.Changed.Has("Start") {
+		sb.WriteString(fmt.Sprintf(" START WITH %d", seq.Start))
+	}
+
+	if (all && seq.Cache != 1) || c.Changed.Has("Cache") {
 		sb.WriteString(fmt.Sprintf(" CACHE %d", seq.Cache))
 	}
 
-	if seq.Cycle {
-		sb.WriteString(" CYCLE")
-	} else {
-		sb.WriteString(" NO CYCLE")
+	if all || c.Changed.Has("Cycle") {
+		if seq.Cycle {
+			sb.WriteString(" CYCLE")
+		} else {
+			sb.WriteString(" NO CYCLE")
+		}
 	}
 
 	sb.WriteString(";")
@@ -448,7 +699,48 @@ func (pg *PostgreSQL) generateCreateFunction(c schema.CreateFunctionChange) stri
 }
 
 func (pg *PostgreSQL) generateAlterFunction(c schema.AlterFunctionChange) string {
-	return pg.generateFunctionSQL("CREATE OR REPLACE FUNCTION", c.Function)
+	if !c.Changed.Only("Volatility", "Strict", "Security", "Cost") {
+		return pg.generateFunctionSQL("CREATE OR REPLACE FUNCTION", c.Function)
+	}
+
+	// Only the properties of the function changed, which ALTER FUNCTION
+	// updates without replacing its body
+	fn := c.Function
+	functionName := fn.Name
+	if fn.Schema != "" && fn.Schema != "public" {
+		functionName = fn.Schema + "." + functionName
+	}
+
+	argTypes := make([]string, len(fn.Arguments))
+	for i, arg := range fn.Arguments {
+		argTypes[i] = arg.Type
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("ALTER FUNCTION %s(%s)", quoteIdentifier(functionName), strings.Join(argTypes, ", ")))
+
+	if c.Changed.Has("Volatility") {
+		sb.WriteString(" " + fn.Volatility)
+	}
+
+	if c.Changed.Has("Strict") {
+		if fn.Strict {
+			sb.WriteString(" STRICT")
+		} else {
+			sb.WriteString(" CALLED ON NULL INPUT")
+		}
+	}
+
+	if c.Changed.Has("Security") {
+		sb.WriteString(" SECURITY " + fn.Security)
+	}
+
+	if c.Changed.Has("Cost") {
+		sb.WriteString(fmt.Sprintf(" COST %d", fn.Cost))
+	}
+
+	sb.WriteString(";")
+	return sb.String()
 }
 
 func (pg *PostgreSQL) generateFunctionSQL(command string, fn *schema.Function) string {
@@ -572,8 +864,45 @@ func (pg *PostgreSQL) generateCreateView(c schema.CreateViewChange) string {
 }
 
 func (pg *PostgreSQL) generateAlterView(c schema.AlterViewChange) string {
-	// For PostgreSQL, we create or replace the view rather than altering it
-	return pg.generateCreateView(schema.CreateViewChange{View: c.View})
+	if !c.Changed.Only("Options") || c.Source == nil {
+		// For PostgreSQL, we create or replace the view rather than altering it
+		return pg.generateCreateView(schema.CreateViewChange{View: c.View})
+	}
+
+	// Only the options changed, which are set and reset in place
+	view := c.View
+	viewName := view.Name
+	if view.Schema != "" && view.Schema != "public" {
+		viewName = view.Schema + "." + viewName
+	}
+
+	names := make([]string, len(view.Options))
+	for i, option := range view.Options {
+		names[i] = viewOptionName(option)
+	}
+
+	var statements []string
+	var reset []string
+	for _, option := range c.Source.Options {
+		if name := viewOptionName(option); !slices.Contains(names, name) {
+			reset = append(reset, name)
+		}
+	}
+	if len(reset) > 0 {
+		statements = append(statements, fmt.Sprintf("ALTER VIEW %s RESET (%s);", quoteIdentifier(viewName), strings.Join(reset, ", ")))
+	}
+	if len(view.Options) > 0 {
+		statements = append(statements, fmt.Sprintf("ALTER VIEW %s SET (%s);", quoteIdentifier(viewName), strings.Join(view.Options, ", ")))
+	}
+
+	return strings.Join(statements, "\n")
+}
+
+// viewOptionName returns the name of a view option such as
+// "security_barrier=true"
+func viewOptionName(option string) string {
+	name, _, _ := strings.Cut(option, "=")
+	return strings.TrimSpace(name)
 }
 
 func (pg *PostgreSQL) generateDropView(c schema.DropViewChange) string {
@@ -663,6 +992,23 @@ func quoteIdentifier(s string) string {
 	return "\"" + strings.ReplaceAll(s, "\"", "\"\"") + "\""
 }
 
+// quoteIdentifiers quotes identifiers as a comma separated list
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// indexName qualifies an index name with the schema of its schema-qualified table
+func indexName(tableName, name string) string {
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		return tableName[:i+1] + name
+	}
+	return name
+}
+
 func quoteLiteral(s string) string {
 	// Don't double quote
 	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
@@ -736,6 +1082,42 @@ func (pg *PostgreSQL) generateCreateRowPolicy(c schema.CreateRowPolicyChange) st
 }
 
 func (pg *PostgreSQL) generateAlterRowPolicy(c schema.AlterRowPolicyChange) string {
+	policy := c.RowPolicy
+	if c.Changed.Only("Roles", "UsingExpr", "CheckExpr") &&
+		(!c.Changed.Has("UsingExpr") || policy.UsingExpr != "") &&
+		(!c.Changed.Has("CheckExpr") || policy.CheckExpr != "") {
+		// ALTER POLICY changes the roles and expressions of a policy but cannot
+		// remove an expression
+		tableName := policy.TableName
+		if policy.Schema != "" && policy.Schema != "public" {
+			tableName = policy.Schema + "." + tableName
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("ALTER POLICY %s ON %s",
+			quoteIdentifier(policy.PolicyName),
+			quoteIdentifier(tableName)))
+
+		if c.Changed.Has("Roles") {
+			if len(policy.Roles) > 0 {
+				sb.WriteString(fmt.Sprintf(" TO %s", strings.Join(policy.Roles, ", ")))
+			} else {
+				sb.WriteString(" TO PUBLIC")
+			}
+		}
+
+		if c.Changed.Has("UsingExpr") {
+			sb.WriteString(fmt.Sprintf(" USING (%s)", policy.UsingExpr))
+		}
+
+		if c.Changed.Has("CheckExpr") {
+			sb.WriteString(fmt.Sprintf(" WITH CHECK (%s)", policy.CheckExpr))
+		}
+
+		sb.WriteString(";")
+		return sb.String()
+	}
+
 	// PostgreSQL doesn't support directly altering policies, so we drop and recreate
 	dropSQL := pg.generateDropRowPolicy(schema.DropRowPolicyChange{
 		SchemaName: c.RowPolicy.Schema,
@@ -760,3 +1142,8 @@ func (pg *PostgreSQL) generateDropRowPolicy(c schema.DropRowPolicyChange) string
 		quoteIdentifier(c.PolicyName),
 		quoteIdentifier(tableName))
 }
+
+// Placeholder returns the bind parameter placeholder for the nth argument of a query
+func (pg *PostgreSQL) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
@@ -119,6 +119,32 @@ func TestDropTable(t *testing.T) {
 	require.Equal(t, `DROP TABLE "test_schema"."users";`, sql)
 }
 
+func TestDropTableInSchema(t *testing.T) {
+	source := schema.NewSchema()
+	source.CreateTable("events", func(t *schema.Table) {
+		t.Schema = "audit"
+		t.Column("id", &schema.IntegerType{})
+	})
+
+	changes := schema.Diff(source, schema.NewSchema())
+	require.Len(t, changes, 1)
+	require.Equal(t, "events", changes[0].(*schema.DropTableChange).TableName)
+	sql, err := New().GenerateSQL(changes[0])
+	require.NoError(t, err)
+	require.Equal(t, `DROP TABLE "audit"."events";`, sql)
+}
+
+func TestRenameTable(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.RenameTableChange{OldName: "users", NewName: "accounts"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "users" RENAME TO "accounts";`, sql)
+
+	sql, err = pg.GenerateSQL(schema.RenameTableChange{SchemaName: "app", OldName: "users", NewName: "accounts"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "app"."users" RENAME TO "accounts";`, sql)
+}
+
 func TestAddColumn(t *testing.T) {
 	pg := New()
 	column := &schema.Column{
@@ -150,6 +176,13 @@ func TestDropColumn(t *testing.T) {
 	require.Equal(t, `ALTER TABLE "users" DROP COLUMN "email";`, sql)
 }
 
+func TestRenameColumn(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.RenameColumnChange{TableName: "users", OldName: "name", NewName: "full_name"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "users" RENAME COLUMN "name" TO "full_name";`, sql)
+}
+
 func TestAlterColumn(t *testing.T) {
 	pg := New()
 	alterColumn := schema.AlterColumnChange{
@@ -171,6 +204,32 @@ COMMENT ON COLUMN "users"."email" IS 'Updated comment';`
 	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
 }
 
+func TestAlterColumnChanged(t *testing.T) {
+	pg := New()
+	column := &schema.Column{Name: "status", Type: &schema.VarcharType{Length: 20}, Default: "'active'"}
+
+	sql, err := pg.GenerateSQL(schema.AlterColumnChange{
+		TableName: "users",
+		Column:    column,
+		Source:    &schema.Column{Name: "status", Type: &schema.VarcharType{Length: 20}},
+		Changed:   schema.Attributes{"Default"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "users" ALTER COLUMN "status" SET DEFAULT 'active';`, sql)
+
+	sql, err = pg.GenerateSQL(schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "status", Type: &schema.TextType{}, Nullable: true},
+		Source:    &schema.Column{Name: "status", Type: &schema.VarcharType{Length: 20}, Comment: "Account status"},
+		Changed:   schema.Attributes{"Type", "Nullable", "Comment"},
+	})
+	require.NoError(t, err)
+	expected := `ALTER TABLE "users" ALTER COLUMN "status" TYPE text;
+ALTER TABLE "users" ALTER COLUMN "status" DROP NOT NULL;
+COMMENT ON COLUMN "users"."status" IS NULL;`
+	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
+}
+
 func TestAddPrimaryKey(t *testing.T) {
 	pg := New()
 
@@ -252,6 +311,78 @@ func TestAddIndex(t *testing.T) {
 	require.Equal(t, `CREATE INDEX "idx_users_name_email" ON "users" ("name", "email");`, sql)
 }
 
+func TestAddIndexWithOptions(t *testing.T) {
+	pg := New()
+
+	sql, err := pg.GenerateSQL(schema.AddIndexChange{
+		TableName: "users",
+		Index: &schema.Index{
+			Name:    "idx_users_active_email",
+			Columns: []string{"(lower(email))", "created_at"},
+			Unique:  true,
+			Include: []string{"name"},
+			Where:   "deleted_at IS NULL",
+			KeyOptions: []*schema.IndexKeyOption{
+				{Column: "(lower(email))", OpClass: "text_pattern_ops"},
+				{Column: "created_at", Desc: true, Nulls: "LAST"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE UNIQUE INDEX "idx_users_active_email" ON "users" ((lower(email)) text_pattern_ops, "created_at" DESC NULLS LAST) INCLUDE ("name") WHERE deleted_at IS NULL;`, sql)
+
+	sql, err = pg.GenerateSQL(schema.AddIndexChange{
+		TableName: "users",
+		Index: &schema.Index{
+			Name:    "idx_users_tags",
+			Columns: []string{"tags"},
+			Method:  "gin",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE INDEX "idx_users_tags" ON "users" USING gin ("tags");`, sql)
+}
+
+func TestIndexConcurrently(t *testing.T) {
+	pg := New()
+
+	addIdx := schema.AddIndexChange{
+		TableName:    "users",
+		Index:        &schema.Index{Name: "idx_users_email", Columns: []string{"email"}},
+		Concurrently: true,
+	}
+	sql, err := pg.GenerateSQL(addIdx)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE INDEX CONCURRENTLY "idx_users_email" ON "users" ("email");`, sql)
+	require.True(t, pg.OutsideTransaction(addIdx))
+
+	dropIdx := &schema.DropIndexChange{TableName: "users", IndexName: "idx_users_email", Concurrently: true}
+	sql, err = pg.GenerateSQL(dropIdx)
+	require.NoError(t, err)
+	require.Equal(t, `DROP INDEX CONCURRENTLY "idx_users_email";`, sql)
+	require.True(t, pg.OutsideTransaction(dropIdx))
+
+	require.False(t, pg.OutsideTransaction(schema.DropIndexChange{TableName: "users", IndexName: "idx_users_email"}))
+	require.False(t, pg.OutsideTransaction(schema.DropTableChange{TableName: "users"}))
+}
+
+func TestWithConcurrentIndexes(t *testing.T) {
+	pg := New(WithConcurrentIndexes())
+
+	addIdx := &schema.AddIndexChange{
+		TableName: "users",
+		Index:     &schema.Index{Name: "idx_users_email", Columns: []string{"email"}, Unique: true},
+	}
+	sql, err := pg.GenerateSQL(addIdx)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE UNIQUE INDEX CONCURRENTLY "idx_users_email" ON "users" ("email");`, sql)
+	require.True(t, pg.OutsideTransaction(addIdx))
+
+	sql, err = pg.GenerateSQL(schema.DropIndexChange{TableName: "users", IndexName: "idx_users_email"})
+	require.NoError(t, err)
+	require.Equal(t, `DROP INDEX CONCURRENTLY "idx_users_email";`, sql)
+}
+
 func TestDropIndex(t *testing.T) {
 	pg := New()
 	dropIdx := schema.DropIndexChange{
@@ -262,6 +393,20 @@ func TestDropIndex(t *testing.T) {
 	require.Equal(t, `DROP INDEX "idx_users_email";`, sql)
 }
 
+func TestDropIndexInSchema(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.DropIndexChange{TableName: "audit.events", IndexName: "events_created_at_idx"})
+	require.NoError(t, err)
+	require.Equal(t, `DROP INDEX "audit"."events_created_at_idx";`, sql)
+}
+
+func TestRenameIndex(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.RenameIndexChange{TableName: "users", OldName: "idx_email", NewName: "users_email_idx"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER INDEX "idx_email" RENAME TO "users_email_idx";`, sql)
+}
+
 func TestAddForeignKey(t *testing.T) {
 	pg := New()
 
@@ -381,6 +526,17 @@ func TestAlterSequence(t *testing.T) {
 	require.Equal(t, expected, sql)
 }
 
+func TestAlterSequenceChanged(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.AlterSequenceChange{
+		Sequence: &schema.Sequence{Name: "order_id_seq", Start: 1, Increment: 1, MinValue: 1, MaxValue: 9223372036854775807, Cache: 1},
+		Source:   &schema.Sequence{Name: "order_id_seq", Start: 1, Increment: 5, MinValue: 1, MaxValue: 9223372036854775807, Cache: 1},
+		Changed:  schema.Attributes{"Increment"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER SEQUENCE "order_id_seq" INCREMENT BY 1;`, sql)
+}
+
 func TestDropSequence(t *testing.T) {
 	pg := New()
 	dropSeq := schema.DropSequenceChange{
@@ -455,6 +611,65 @@ $$ LANGUAGE plpgsql STABLE SECURITY DEFINER COST 200;`
 	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
 }
 
+func TestAlterFunctionChanged(t *testing.T) {
+	pg := New()
+	fn := &schema.Function{
+		Name:       "add_numbers",
+		Arguments:  []schema.FunctionArg{{Name: "a", Type: "integer", Mode: "IN"}, {Name: "b", Type: "integer", Mode: "IN"}},
+		Returns:    "integer",
+		Language:   "sql",
+		Body:       "SELECT a + b",
+		Volatility: "IMMUTABLE",
+		Strict:     false,
+		Security:   "INVOKER",
+		Cost:       100,
+	}
+
+	sql, err := pg.GenerateSQL(schema.AlterFunctionChange{
+		Function: fn,
+		Changed:  schema.Attributes{"Volatility", "Strict"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER FUNCTION "add_numbers"(integer, integer) IMMUTABLE CALLED ON NULL INPUT;`, sql)
+
+	sql, err = pg.GenerateSQL(schema.AlterFunctionChange{
+		Function: fn,
+		Changed:  schema.Attributes{"Body", "Cost"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE OR REPLACE FUNCTION "add_numbers"(a integer, b integer) RETURNS integer AS $$SELECT a + b$$ LANGUAGE sql IMMUTABLE COST 100;`, sql)
+}
+
+func TestAlterUnmarshaledDefaults(t *testing.T) {
+	source := schema.NewSchema()
+	source.CreateFunction("touch", "trigger", "BEGIN RETURN NEW; END;")
+	source.CreateSequence("ids", schema.Start(10))
+
+	target, err := schema.Unmarshal([]byte(`
+functions:
+  - name: touch
+    returns: trigger
+    body: BEGIN RETURN NEW; END;
+    volatility: STABLE
+sequences:
+  - name: ids
+    start: 1
+`), schema.FormatYAML)
+	require.NoError(t, err)
+
+	pg := New()
+	var statements []string
+	for _, change := range schema.Diff(source, target) {
+		sql, err := pg.GenerateSQL(change)
+		require.NoError(t, err)
+		statements = append(statements, sql)
+	}
+	require.Equal(t, []string{
+		`ALTER SEQUENCE "ids" START WITH 1;`,
+		`ALTER FUNCTION "touch"() STABLE;`,
+	}, statements)
+}
+
 func TestDropFunction(t *testing.T) {
 	pg := New()
 
@@ -522,6 +737,27 @@ func TestAlterView(t *testing.T) {
 	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
 }
 
+func TestAlterViewChanged(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.AlterViewChange{
+		View: &schema.View{
+			Name:       "active_users",
+			Definition: "SELECT * FROM users WHERE active",
+			Options:    []string{"security_barrier=true"},
+		},
+		Source: &schema.View{
+			Name:       "active_users",
+			Definition: "SELECT * FROM users WHERE active",
+			Options:    []string{"check_option=local"},
+		},
+		Changed: schema.Attributes{"Options"},
+	})
+	require.NoError(t, err)
+	expected := `ALTER VIEW "active_users" RESET (check_option);
+ALTER VIEW "active_users" SET (security_barrier=true);`
+	require.Equal(t, expected, sql)
+}
+
 func TestDropView(t *testing.T) {
 	pg := New()
 	dropView := schema.DropViewChange{
@@ -707,6 +943,35 @@ CREATE POLICY "orders_owner" ON "orders" AS RESTRICTIVE FOR SELECT USING (user_i
 	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
 }
 
+func TestAlterRowPolicyChanged(t *testing.T) {
+	pg := New()
+	policy := &schema.RowPolicy{
+		TableName:   "orders",
+		PolicyName:  "orders_owner",
+		CommandType: "SELECT",
+		Roles:       []string{"app_user"},
+		UsingExpr:   "user_id = current_user_id()",
+		Permissive:  true,
+	}
+
+	sql, err := pg.GenerateSQL(schema.AlterRowPolicyChange{
+		RowPolicy: policy,
+		Changed:   schema.Attributes{"Roles", "UsingExpr"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER POLICY "orders_owner" ON "orders" TO app_user USING (user_id = current_user_id());`, sql)
+
+	// Removing an expression recreates the policy
+	sql, err = pg.GenerateSQL(schema.AlterRowPolicyChange{
+		RowPolicy: policy,
+		Changed:   schema.Attributes{"CheckExpr"},
+	})
+	require.NoError(t, err)
+	expected := `DROP POLICY "orders_owner" ON "orders";
+CREATE POLICY "orders_owner" ON "orders" FOR SELECT TO app_user USING (user_id = current_user_id());`
+	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
+}
+
 func TestDropRowPolicy(t *testing.T) {
 	pg := New()
 	dropRowPolicy := schema.DropRowPolicyChange{
@@ -729,3 +994,177 @@ func TestDropRowPolicy(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `DROP POLICY "products_access" ON "store"."products";`, sql)
 }
+
+func TestCreateTableWithConstraints(t *testing.T) {
+	pg := New()
+	s := schema.NewSchema()
+	table := s.CreateTable("products", func(t *schema.Table) {
+		t.Column("id", &schema.IntegerType{})
+		t.Column("sku", &schema.TextType{})
+		t.Column("price", &schema.IntegerType{})
+		t.SetPrimaryKey("products_pkey", []string{"id"})
+		t.Unique("products_sku_key", "sku")
+		t.Check("products_price_check", "price > 0")
+	})
+
+	sql, err := pg.GenerateSQL(schema.CreateTableChange{TableDef: table})
+	require.NoError(t, err)
+	expected := `CREATE TABLE "products" (
+  "id" integer NOT NULL,
+  "sku" text NOT NULL,
+  "price" integer NOT NULL,
+  CONSTRAINT "products_pkey" PRIMARY KEY ("id"),
+  CONSTRAINT "products_sku_key" UNIQUE ("sku"),
+  CONSTRAINT "products_price_check" CHECK (price > 0)
+);`
+	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
+}
+
+func TestAddCheck(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.AddCheckChange{
+		TableName: "products",
+		Check:     &schema.CheckConstraint{Name: "products_price_check", Expression: "price > 0"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "products" ADD CONSTRAINT "products_price_check" CHECK (price > 0);`, sql)
+}
+
+func TestAddConstraintNotValid(t *testing.T) {
+	pg := New()
+
+	sql, err := pg.GenerateSQL(&schema.AddCheckChange{
+		TableName: "products",
+		Check:     &schema.CheckConstraint{Name: "products_price_check", Expression: "price > 0"},
+		NotValid:  true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "products" ADD CONSTRAINT "products_price_check" CHECK (price > 0) NOT VALID;`, sql)
+
+	sql, err = pg.GenerateSQL(schema.AddForeignKeyChange{
+		TableName: "posts",
+		ForeignKey: &schema.ForeignKey{
+			Name:       "fk_posts_user",
+			Columns:    []string{"user_id"},
+			RefTable:   "users",
+			RefColumns: []string{"id"},
+			NotValid:   true,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "posts" ADD CONSTRAINT "fk_posts_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") NOT VALID;`, sql)
+}
+
+func TestValidateConstraint(t *testing.T) {
+	pg := New()
+	validate := &schema.ValidateConstraintChange{TableName: "posts", ConstraintName: "fk_posts_user"}
+	sql, err := pg.GenerateSQL(validate)
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "posts" VALIDATE CONSTRAINT "fk_posts_user";`, sql)
+	require.True(t, pg.OutsideTransaction(validate))
+}
+
+func TestDropCheck(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.DropCheckChange{TableName: "store.products", CheckName: "products_price_check"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "store"."products" DROP CONSTRAINT "products_price_check";`, sql)
+}
+
+func TestAddUniqueConstraint(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.AddUniqueConstraintChange{
+		TableName: "products",
+		Unique:    &schema.UniqueConstraint{Name: "products_sku_vendor_key", Columns: []string{"sku", "vendor"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "products" ADD CONSTRAINT "products_sku_vendor_key" UNIQUE ("sku", "vendor");`, sql)
+}
+
+func TestDropUniqueConstraint(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.DropUniqueConstraintChange{TableName: "products", ConstraintName: "products_sku_key"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "products" DROP CONSTRAINT "products_sku_key";`, sql)
+}
+
+func TestCreateEnum(t *testing.T) {
+	pg := New()
+	s := schema.NewSchema()
+	enum := s.CreateEnum("mood", "sad", "it's ok", "happy")
+
+	sql, err := pg.GenerateSQL(schema.CreateEnumChange{Enum: enum})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TYPE "mood" AS ENUM ('sad', 'it''s ok', 'happy');`, sql)
+
+	table := s.CreateTable("people", func(t *schema.Table) {
+		t.Column("mood", &EnumType{Name: "mood"})
+		t.Column("previous_mood", &EnumType{Schema: "audit", Name: "mood"}, schema.Nullable)
+	})
+	sql, err = pg.GenerateSQL(schema.CreateTableChange{TableDef: table})
+	require.NoError(t, err)
+	expected := `CREATE TABLE "people" (
+  "mood" "mood" NOT NULL,
+  "previous_mood" "audit"."mood"
+);`
+	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
+}
+
+func TestDropEnum(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.DropEnumChange{SchemaName: "audit", EnumName: "mood"})
+	require.NoError(t, err)
+	require.Equal(t, `DROP TYPE "audit"."mood";`, sql)
+}
+
+func TestAddEnumValue(t *testing.T) {
+	pg := New()
+	enum := &schema.Enum{Name: "mood", Values: []string{"awful", "sad", "ok", "happy"}}
+
+	sql, err := pg.GenerateSQL(schema.AddEnumValueChange{Enum: enum, Value: "happy"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TYPE "mood" ADD VALUE 'happy';`, sql)
+
+	sql, err = pg.GenerateSQL(schema.AddEnumValueChange{Enum: enum, Value: "awful", Before: "sad"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TYPE "mood" ADD VALUE 'awful' BEFORE 'sad';`, sql)
+
+	sql, err = pg.GenerateSQL(schema.AddEnumValueChange{Enum: enum, Value: "ok", After: "sad"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TYPE "mood" ADD VALUE 'ok' AFTER 'sad';`, sql)
+
+	require.True(t, pg.OutsideTransaction(&schema.AddEnumValueChange{Enum: enum, Value: "happy"}))
+}
+
+func TestAlterEnum(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(schema.AlterEnumChange{
+		Enum: &schema.Enum{Name: "mood", Values: []string{"happy", "sad"}},
+		Columns: []schema.EnumColumn{
+			{TableName: "people", ColumnName: "mood", Default: "'sad'::mood"},
+			{TableName: "audit.people", ColumnName: "mood"},
+		},
+	})
+	require.NoError(t, err)
+	expected := `ALTER TYPE "mood" RENAME TO "mood_old";
+CREATE TYPE "mood" AS ENUM ('happy', 'sad');
+ALTER TABLE "people" ALTER COLUMN "mood" DROP DEFAULT;
+ALTER TABLE "people" ALTER COLUMN "mood" TYPE "mood" USING "mood"::text::"mood";
+ALTER TABLE "people" ALTER COLUMN "mood" SET DEFAULT 'sad'::mood;
+ALTER TABLE "audit"."people" ALTER COLUMN "mood" TYPE "mood" USING "mood"::text::"mood";
+DROP TYPE "mood_old";`
+	require.Equal(t, expected, sql)
+}
+
+func TestGenerateSQLPointerChange(t *testing.T) {
+	pg := New()
+	sql, err := pg.GenerateSQL(&schema.DropTableChange{TableName: "users"})
+	require.NoError(t, err)
+	require.Equal(t, `DROP TABLE "users";`, sql)
+}
+
+func TestPlaceholder(t *testing.T) {
+	pg := New()
+	require.Equal(t, "$1", pg.Placeholder(1))
+	require.Equal(t, "$3", pg.Placeholder(3))
+}
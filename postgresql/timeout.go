@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/swiftcarrot/dbx"
+)
+
+var _ dbx.Timeouter = (*PostgreSQL)(nil)
+
+// SetTimeoutsSQL returns the statements that set lock_timeout and
+// statement_timeout for the session. A zero timeout is reset to the
+// configured value, since setting it to 0 would disable it.
+func (pg *PostgreSQL) SetTimeoutsSQL(lockTimeout, statementTimeout time.Duration) []string {
+	return []string{
+		setTimeoutSQL("lock_timeout", lockTimeout),
+		setTimeoutSQL("statement_timeout", statementTimeout),
+	}
+}
+
+// setTimeoutSQL returns the statement that sets a timeout setting in milliseconds
+func setTimeoutSQL(name string, timeout time.Duration) string {
+	if timeout <= 0 {
+		return "RESET " + name
+	}
+	return fmt.Sprintf("SET %s = %d", name, timeout.Milliseconds())
+}
+
+// ResetTimeoutsSQL returns the statements that restore the configured
+// lock_timeout and statement_timeout
+func (pg *PostgreSQL) ResetTimeoutsSQL() []string {
+	return []string{"RESET lock_timeout", "RESET statement_timeout"}
+}
+
+// IsLockTimeout reports whether err has SQLSTATE 55P03 (lock_not_available),
+// which is returned when lock_timeout expires. Errors of lib/pq and pgx both
+// report their SQLSTATE.
+func (pg *PostgreSQL) IsLockTimeout(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == "55P03"
+}
@@ -0,0 +1,31 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTimeoutsSQL(t *testing.T) {
+	pg := New()
+	require.Equal(t, []string{
+		"SET lock_timeout = 2000",
+		"RESET statement_timeout",
+	}, pg.SetTimeoutsSQL(2*time.Second, 0))
+	require.Equal(t, []string{
+		"RESET lock_timeout",
+		"SET statement_timeout = 60000",
+	}, pg.SetTimeoutsSQL(0, time.Minute))
+	require.Equal(t, []string{"RESET lock_timeout", "RESET statement_timeout"}, pg.ResetTimeoutsSQL())
+}
+
+func TestIsLockTimeout(t *testing.T) {
+	pg := New()
+	require.True(t, pg.IsLockTimeout(fmt.Errorf("migration: %w", &pq.Error{Code: "55P03"})))
+	require.False(t, pg.IsLockTimeout(&pq.Error{Code: "57014"}))
+	require.False(t, pg.IsLockTimeout(errors.New("canceling statement due to lock timeout")))
+}
@@ -1,55 +1,75 @@
 package schema
 
+import (
+	"reflect"
+	"slices"
+)
+
 // ChangeType defines the type of schema change
 type ChangeType string
 
 const (
-	CreateSchema     ChangeType = "create_schema"
-	DropSchema       ChangeType = "drop_schema"
-	EnableExtension  ChangeType = "enable_extension"
-	DisableExtension ChangeType = "disable_extension"
-	CreateTable      ChangeType = "create_table"
-	DropTable        ChangeType = "drop_table"
-	AddColumn        ChangeType = "add_column"
-	DropColumn       ChangeType = "drop_column"
-	AlterColumn      ChangeType = "alter_column"
-	AddPrimaryKey    ChangeType = "add_primary_key"
-	DropPrimaryKey   ChangeType = "drop_primary_key"
-	AddIndex         ChangeType = "add_index"
-	DropIndex        ChangeType = "drop_index"
-	AddForeignKey    ChangeType = "add_foreign_key"
-	DropForeignKey   ChangeType = "drop_foreign_key"
-	CreateSequence   ChangeType = "create_sequence"
-	DropSequence     ChangeType = "drop_sequence"
-	AlterSequence    ChangeType = "alter_sequence"
-	CreateFunction   ChangeType = "create_function"
-	AlterFunction    ChangeType = "alter_function"
-	DropFunction     ChangeType = "drop_function"
-	CreateView       ChangeType = "create_view"
-	AlterView        ChangeType = "alter_view"
-	DropView         ChangeType = "drop_view"
-	CreateTrigger    ChangeType = "create_trigger"
-	AlterTrigger     ChangeType = "alter_trigger"
-	DropTrigger      ChangeType = "drop_trigger"
-	CreateRowPolicy  ChangeType = "create_row_policy"
-	AlterRowPolicy   ChangeType = "alter_row_policy"
-	DropRowPolicy    ChangeType = "drop_row_policy"
+	CreateSchema       ChangeType = "create_schema"
+	DropSchema         ChangeType = "drop_schema"
+	EnableExtension    ChangeType = "enable_extension"
+	DisableExtension   ChangeType = "disable_extension"
+	CreateTable        ChangeType = "create_table"
+	DropTable          ChangeType = "drop_table"
+	RenameTable        ChangeType = "rename_table"
+	AddColumn          ChangeType = "add_column"
+	DropColumn         ChangeType = "drop_column"
+	AlterColumn        ChangeType = "alter_column"
+	RenameColumn       ChangeType = "rename_column"
+	AddPrimaryKey      ChangeType = "add_primary_key"
+	DropPrimaryKey     ChangeType = "drop_primary_key"
+	AddIndex           ChangeType = "add_index"
+	DropIndex          ChangeType = "drop_index"
+	RenameIndex        ChangeType = "rename_index"
+	AddForeignKey      ChangeType = "add_foreign_key"
+	DropForeignKey     ChangeType = "drop_foreign_key"
+	AddCheck           ChangeType = "add_check"
+	DropCheck          ChangeType = "drop_check"
+	AddUnique          ChangeType = "add_unique_constraint"
+	DropUnique         ChangeType = "drop_unique_constraint"
+	ValidateConstraint ChangeType = "validate_constraint"
+	CreateEnum         ChangeType = "create_enum"
+	DropEnum           ChangeType = "drop_enum"
+	AddEnumValue       ChangeType = "add_enum_value"
+	AlterEnum          ChangeType = "alter_enum"
+	CreateSequence     ChangeType = "create_sequence"
+	DropSequence       ChangeType = "drop_sequence"
+	AlterSequence      ChangeType = "alter_sequence"
+	CreateFunction     ChangeType = "create_function"
+	AlterFunction      ChangeType = "alter_function"
+	DropFunction       ChangeType = "drop_function"
+	CreateView         ChangeType = "create_view"
+	AlterView          ChangeType = "alter_view"
+	DropView           ChangeType = "drop_view"
+	CreateTrigger      ChangeType = "create_trigger"
+	AlterTrigger       ChangeType = "alter_trigger"
+	DropTrigger        ChangeType = "drop_trigger"
+	CreateRowPolicy    ChangeType = "create_row_policy"
+	AlterRowPolicy     ChangeType = "alter_row_policy"
+	DropRowPolicy      ChangeType = "drop_row_policy"
 )
 
 // Change is an interface representing a database schema change
 type Change interface {
 	Type() ChangeType
 	IsUnsafe() bool
+	Hazards() []Hazard
 }
 
 // BaseChange provides common functionality for all change types
 type BaseChange struct {
-	unsafe bool
+	unsafe  bool
+	hazards []Hazard
 }
 
-// IsUnsafe returns whether the change is unsafe and should be reviewed before execution
+// IsUnsafe returns whether the change is marked unsafe or has hazards, and
+// should be reviewed before execution
 func (c BaseChange) IsUnsafe() bool {
-	return c.unsafe
+	return c.unsafe || len(c.hazards) > 0
 }
 
 // SetUnsafe marks a change as unsafe
@@ -57,6 +77,101 @@ func (c *BaseChange) SetUnsafe(unsafe bool) {
 	c.unsafe = unsafe
 }
 
+// Hazards returns the hazards of the change, which Diff records
+func (c BaseChange) Hazards() []Hazard {
+	return c.hazards
+}
+
+// AddHazard records a hazard of the change
+func (c *BaseChange) AddHazard(hazardType HazardType, reason string) {
+	c.hazards = append(c.hazards, Hazard{Type: hazardType, Reason: reason})
+}
+
+// HasHazard reports whether the change has a hazard of the given type
+func (c BaseChange) HasHazard(hazardType HazardType) bool {
+	return slices.ContainsFunc(c.hazards, func(h Hazard) bool {
+		return h.Type == hazardType
+	})
+}
+
+// RemoveHazards removes the hazards of the given type from the change
+func (c *BaseChange) RemoveHazards(hazardType HazardType) {
+	c.hazards = slices.DeleteFunc(c.hazards, func(h Hazard) bool {
+		return h.Type == hazardType
+	})
+}
+
+// Attributes lists the fields of an object that an Alter change modifies, by
+// their Go names such as "Type" or "Default"
+type Attributes []string
+
+// Has reports whether the named field is modified
+func (a Attributes) Has(name string) bool {
+	return slices.Contains(a, name)
+}
+
+// Only reports whether some fields are modified and all of them are among names
+func (a Attributes) Only(names ...string) bool {
+	if len(a) == 0 {
+		return false
+	}
+	for _, name := range a {
+		if !slices.Contains(names, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Indirect returns the value a change pointer points to, so that changes
+// returned by Diff (which are pointers) and changes built by hand (which are
+// usually values) can be handled by a single type switch
+func Indirect(change Change) Change {
+	v := reflect.ValueOf(change)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return change
+	}
+	if c, ok := v.Elem().Interface().(Change); ok {
+		return c
+	}
+	return change
+}
+
+// RebuildOf returns the Rebuild field of a change, or nil when the change has none
+func RebuildOf(change Change) *TableRebuild {
+	v := reflect.Indirect(reflect.ValueOf(change))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	field := v.FieldByName("Rebuild")
+	if !field.IsValid() {
+		return nil
+	}
+	rebuild, _ := field.Interface().(*TableRebuild)
+	return rebuild
+}
+
+// TableNameOf returns the table changed by a column, primary key, index or
+// constraint change returned by Diff, or "" for other changes
+func TableNameOf(change Change) string {
+	name, _ := tableChangeName(change)
+	return name
+}
+
+// TableRebuild describes a table right after a change, for dialects such as
+// SQLite that cannot alter the table in place and rebuild it instead
+type TableRebuild struct {
+	// Table is the complete definition of the table after the change
+	Table *Table
+	// Triggers on the table, which are dropped along with it
+	Triggers []*Trigger
+	// Views that reference the table
+	Views []*View
+	// Superseded is set when another change of the same table rebuilds it,
+	// so that this change needs no statements of its own
+	Superseded bool
+}
+
 // Table-related changes
 
 // CreateTableChange represents a table creation change
@@ -80,6 +195,18 @@ func (c DropTableChange) Type() ChangeType {
 	return DropTable
 }
 
+// RenameTableChange represents renaming a table
+type RenameTableChange struct {
+	BaseChange
+	SchemaName string
+	OldName    string
+	NewName    string
+}
+
+func (c RenameTableChange) Type() ChangeType {
+	return RenameTable
+}
+
 // Column-related changes
 
 // AddColumnChange represents adding a column to a table
@@ -98,23 +225,41 @@ type DropColumnChange struct {
 	BaseChange
 	TableName  string
 	ColumnName string
+	Rebuild    *TableRebuild
 }
 
 func (c DropColumnChange) Type() ChangeType {
 	return DropColumn
 }
 
-// AlterColumnChange represents altering a column in a table
+// AlterColumnChange represents altering a column in a table. Diff sets the
+// column before the change and the attributes that differ, so that dialects
+// only alter those, otherwise the whole column is altered.
 type AlterColumnChange struct {
 	BaseChange
 	TableName string
 	Column    *Column
+	Source    *Column
+	Changed   Attributes
+	Rebuild   *TableRebuild
 }
 
 func (c AlterColumnChange) Type() ChangeType {
 	return AlterColumn
 }
 
+// RenameColumnChange represents renaming a column of a table
+type RenameColumnChange struct {
+	BaseChange
+	TableName string
+	OldName   string
+	NewName   string
+}
+
+func (c RenameColumnChange) Type() ChangeType {
+	return RenameColumn
+}
+
 // PrimaryKey-related changes
 
 // AddPrimaryKeyChange represents adding a primary key to a table
@@ -122,6 +267,7 @@ type AddPrimaryKeyChange struct {
 	BaseChange
 	TableName  string
 	PrimaryKey *PrimaryKey
+	Rebuild    *TableRebuild
 }
 
 func (c AddPrimaryKeyChange) Type() ChangeType {
@@ -133,6 +279,7 @@ type DropPrimaryKeyChange struct {
 	BaseChange
 	TableName string
 	PKName    string
+	Rebuild   *TableRebuild
 }
 
 func (c DropPrimaryKeyChange) Type() ChangeType {
@@ -144,8 +291,9 @@ func (c DropPrimaryKeyChange) Type() ChangeType {
 // AddIndexChange represents adding an index to a table
 type AddIndexChange struct {
 	BaseChange
-	TableName string
-	Index     *Index
+	TableName    string
+	Index        *Index
+	Concurrently bool // Build the index without blocking writes (PostgreSQL), outside a transaction
 }
 
 func (c AddIndexChange) Type() ChangeType {
@@ -155,14 +303,29 @@ func (c AddIndexChange) Type() ChangeType {
 // DropIndexChange represents dropping an index from a table
 type DropIndexChange struct {
 	BaseChange
-	TableName string
-	IndexName string
+	TableName    string
+	IndexName    string
+	Concurrently bool // Drop the index without blocking writes (PostgreSQL), outside a transaction
 }
 
 func (c DropIndexChange) Type() ChangeType {
 	return DropIndex
 }
 
+// RenameIndexChange represents renaming an index of a table. Index is the
+// renamed index, for dialects that recreate it under the new name.
+type RenameIndexChange struct {
+	BaseChange
+	TableName string
+	OldName   string
+	NewName   string
+	Index     *Index
+}
+
+func (c RenameIndexChange) Type() ChangeType {
+	return RenameIndex
+}
+
 // ForeignKey-related changes
 
 // AddForeignKeyChange represents adding a foreign key to a table
@@ -170,6 +333,8 @@ type AddForeignKeyChange struct {
 	BaseChange
 	TableName  string
 	ForeignKey *ForeignKey
+	Rebuild    *TableRebuild
+	NotValid   bool // Add the foreign key without checking the existing rows (PostgreSQL)
 }
 
 func (c AddForeignKeyChange) Type() ChangeType {
@@ -181,12 +346,76 @@ type DropForeignKeyChange struct {
 	BaseChange
 	TableName string
 	FKName    string
+	Rebuild   *TableRebuild
 }
 
 func (c DropForeignKeyChange) Type() ChangeType {
 	return DropForeignKey
 }
 
+// Constraint-related changes
+
+// AddCheckChange represents adding a check constraint to a table
+type AddCheckChange struct {
+	BaseChange
+	TableName string
+	Check     *CheckConstraint
+	Rebuild   *TableRebuild
+	NotValid  bool // Add the check constraint without checking the existing rows (PostgreSQL)
+}
+
+func (c AddCheckChange) Type() ChangeType {
+	return AddCheck
+}
+
+// ValidateConstraintChange represents checking the existing rows against a
+// foreign key or check constraint that was added NOT VALID (PostgreSQL)
+type ValidateConstraintChange struct {
+	BaseChange
+	TableName      string
+	ConstraintName string
+}
+
+func (c ValidateConstraintChange) Type() ChangeType {
+	return ValidateConstraint
+}
+
+// DropCheckChange represents dropping a check constraint from a table
+type DropCheckChange struct {
+	BaseChange
+	TableName string
+	CheckName string
+	Rebuild   *TableRebuild
+}
+
+func (c DropCheckChange) Type() ChangeType {
+	return DropCheck
+}
+
+// AddUniqueConstraintChange represents adding a unique constraint to a table
+type AddUniqueConstraintChange struct {
+	BaseChange
+	TableName string
+	Unique    *UniqueConstraint
+	Rebuild   *TableRebuild
+}
+
+func (c AddUniqueConstraintChange) Type() ChangeType {
+	return AddUnique
+}
+
+// DropUniqueConstraintChange represents dropping a unique constraint from a table
+type DropUniqueConstraintChange struct {
+	BaseChange
+	TableName      string
+	ConstraintName string
+	Rebuild        *TableRebuild
+}
+
+func (c DropUniqueConstraintChange) Type() ChangeType {
+	return DropUnique
+}
+
 // Schema-related changes
 
 // CreateSchemaChange represents creating a new schema
@@ -231,6 +460,63 @@ func (c DisableExtensionChange) Type() ChangeType {
 	return DisableExtension
 }
 
+// Enum-related changes
+
+// CreateEnumChange represents creating an enum type
+type CreateEnumChange struct {
+	BaseChange
+	Enum *Enum
+}
+
+func (c CreateEnumChange) Type() ChangeType {
+	return CreateEnum
+}
+
+// DropEnumChange represents dropping an enum type
+type DropEnumChange struct {
+	BaseChange
+	SchemaName string
+	EnumName   string
+}
+
+func (c DropEnumChange) Type() ChangeType {
+	return DropEnum
+}
+
+// AddEnumValueChange represents adding a value to an enum type. The value is
+// added at the end unless Before or After names the value it goes next to.
+type AddEnumValueChange struct {
+	BaseChange
+	Enum   *Enum
+	Value  string
+	Before string
+	After  string
+}
+
+func (c AddEnumValueChange) Type() ChangeType {
+	return AddEnumValue
+}
+
+// EnumColumn is a column whose type is an enum type
+type EnumColumn struct {
+	TableName  string
+	ColumnName string
+	Default    string // Default of the column, which is set again once the column is converted
+}
+
+// AlterEnumChange represents removing or reordering the values of an enum
+// type, which recreates the type and converts the columns that use it. Diff
+// marks it unsafe, since rows holding a removed value fail the conversion.
+type AlterEnumChange struct {
+	BaseChange
+	Enum    *Enum
+	Columns []EnumColumn
+}
+
+func (c AlterEnumChange) Type() ChangeType {
+	return AlterEnum
+}
+
 // Sequence-related changes
 
 // CreateSequenceChange represents creating a new sequence
@@ -243,10 +529,13 @@ func (c CreateSequenceChange) Type() ChangeType {
 	return CreateSequence
 }
 
-// AlterSequenceChange represents altering an existing sequence
+// AlterSequenceChange represents altering an existing sequence, Source and Changed
+// are set by Diff as for AlterColumnChange
 type AlterSequenceChange struct {
 	BaseChange
 	Sequence *Sequence
+	Source   *Sequence
+	Changed  Attributes
 }
 
 func (c AlterSequenceChange) Type() ChangeType {
@@ -276,10 +565,13 @@ func (c CreateFunctionChange) Type() ChangeType {
 	return CreateFunction
 }
 
-// AlterFunctionChange represents altering an existing function
+// AlterFunctionChange represents altering an existing function, Source and Changed
+// are set by Diff as for AlterColumnChange
 type AlterFunctionChange struct {
 	BaseChange
 	Function *Function
+	Source   *Function
+	Changed  Attributes
 }
 
 func (c AlterFunctionChange) Type() ChangeType {
@@ -310,10 +602,13 @@ func (c CreateViewChange) Type() ChangeType {
 	return CreateView
 }
 
-// AlterViewChange represents altering an existing view
+// AlterViewChange represents altering an existing view, Source and Changed
+// are set by Diff as for AlterColumnChange
 type AlterViewChange struct {
 	BaseChange
-	View *View
+	View    *View
+	Source  *View
+	Changed Attributes
 }
 
 func (c AlterViewChange) Type() ChangeType {
@@ -343,10 +638,13 @@ func (c CreateTriggerChange) Type() ChangeType {
 	return CreateTrigger
 }
 
-// AlterTriggerChange represents altering an existing trigger
+// AlterTriggerChange represents altering an existing trigger, Source and Changed
+// are set by Diff as for AlterColumnChange
 type AlterTriggerChange struct {
 	BaseChange
 	Trigger *Trigger
+	Source  *Trigger
+	Changed Attributes
 }
 
 func (c AlterTriggerChange) Type() ChangeType {
@@ -377,10 +675,13 @@ func (c CreateRowPolicyChange) Type() ChangeType {
 	return CreateRowPolicy
 }
 
-// AlterRowPolicyChange represents altering an existing row policy
+// AlterRowPolicyChange represents altering an existing row policy, Source and Changed
+// are set by Diff as for AlterColumnChange
 type AlterRowPolicyChange struct {
 	BaseChange
 	RowPolicy *RowPolicy
+	Source    *RowPolicy
+	Changed   Attributes
 }
 
 func (c AlterRowPolicyChange) Type() ChangeType {
@@ -0,0 +1,15 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndirect(t *testing.T) {
+	change := &DropTableChange{TableName: "users"}
+	change.SetUnsafe(true)
+
+	require.Equal(t, DropTableChange{BaseChange: BaseChange{unsafe: true}, TableName: "users"}, Indirect(change))
+	require.Equal(t, DropTableChange{TableName: "users"}, Indirect(DropTableChange{TableName: "users"}))
+}
@@ -9,6 +9,14 @@ type ColumnType interface {
 	SQL() string
 }
 
+// EnumeratedType is implemented by column types that only accept values from
+// a list, such as MySQL ENUM and SET. Diff marks a column change unsafe when
+// the list changes other than by appending values.
+type EnumeratedType interface {
+	ColumnType
+	EnumValues() []string
+}
+
 // TextType represents a text column type
 type TextType struct{}
 
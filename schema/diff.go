@@ -1,5 +1,14 @@
 package schema
 
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
 // areColumnTypesEqual compares column types based on their SQL representation
 func areColumnTypesEqual(a, b ColumnType) bool {
 	if a == nil && b == nil {
@@ -19,12 +28,26 @@ func areColumnTypesEqual(a, b ColumnType) bool {
 	return a.SQL() == b.SQL()
 }
 
-// Diff compares two schemas and returns changes to migrate from source to target
-func Diff(source, target *Schema) []Change {
+// Diff compares two schemas and returns changes to migrate from source to target.
+// The changes are ordered so that objects are created after the objects they
+// depend on (referenced tables, tables used by views and policies, trigger
+// functions and sequences used in column defaults) and dropped before them.
+// Foreign keys between new tables that reference each other are added by
+// AddForeignKeyChanges at the end. Each change records its hazards, such as
+// data loss or a long lock, see CheckHazards.
+func Diff(source, target *Schema, options ...DiffOption) []Change {
+	config := &diffConfig{}
+	for _, option := range options {
+		option(config)
+	}
+	source = config.filter.Apply(source)
+	target = config.filter.Apply(target)
+
 	changes := []Change{}
 
 	changes = append(changes, diffSchemaNames(source, target)...)
 	changes = append(changes, diffExtensions(source, target)...)
+	changes = append(changes, diffEnums(source, target)...)
 	changes = append(changes, diffSequences(source, target)...)
 	changes = append(changes, diffFunctions(source, target)...)
 	changes = append(changes, diffViews(source, target)...)
@@ -32,15 +55,7 @@ func Diff(source, target *Schema) []Change {
 
 	// Tables that exist in source but not in target should be dropped
 	for _, sourceTable := range source.Tables {
-		found := false
-		for _, targetTable := range target.Tables {
-			if sourceTable.Name == targetTable.Name &&
-				sourceTable.Schema == targetTable.Schema {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if findTargetTable(source, target, sourceTable) == nil {
 			changes = append(changes, &DropTableChange{
 				TableName:  sourceTable.Name,
 				SchemaName: sourceTable.Schema,
@@ -48,33 +63,120 @@ func Diff(source, target *Schema) []Change {
 		}
 	}
 
-	// Find tables to create or modify
+	// Find tables to create, rename or modify, keeping the source of the
+	// changes to each table in case their rebuilds have to be set again
+	rebuildSources := map[string]*Table{}
 	for _, targetTable := range target.Tables {
-		found := false
-		for _, sourceTable := range source.Tables {
-			if targetTable.Name == sourceTable.Name &&
-				targetTable.Schema == sourceTable.Schema {
-				found = true
-				// Table exists in both source and target, diff it
-				changes = append(changes, diffTable(sourceTable, targetTable)...)
-				break
-			}
-		}
-
-		if !found {
+		sourceTable := findSourceTable(source, target, targetTable)
+		if sourceTable == nil {
 			// Table exists in target but not in source, create it
 			changes = append(changes, &CreateTableChange{
 				TableDef: targetTable,
 			})
+			continue
 		}
+
+		// Table exists in both source and target, diff it
+		renames, renamedTable := diffRenames(sourceTable, targetTable)
+		matchUniqueIndexes(renamedTable, targetTable)
+		tableChanges := diffTable(renamedTable, targetTable)
+		setTableRebuilds(tableChanges, renamedTable, source, target)
+		rebuildSources[qualifiedTableName(targetTable)] = renamedTable
+		changes = append(changes, renames...)
+		changes = append(changes, tableChanges...)
 	}
 
 	// Diff triggers (after tables to ensure proper dependencies)
 	changes = append(changes, diffTriggers(source, target)...)
 
+	changes = orderChanges(changes, source, target)
+	if config.filter != nil && config.filter.neverDrop {
+		changes = withoutDrops(changes)
+		resetTableRebuilds(changes, rebuildSources, source, target)
+	}
+	if config.deferValidation {
+		changes = deferValidation(changes, source, target)
+	}
+	classifyHazards(changes, source, target, config)
 	return changes
 }
 
+// DiffOption configures Diff
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	deferValidation bool
+	reversal        bool
+	filter          *Filter
+}
+
+// WithDeferredValidation makes Diff add foreign keys and check constraints to
+// existing tables NOT VALID, and validate them with ValidateConstraintChanges
+// after the other changes. NOT NULL is set on existing columns through a check
+// constraint validated the same way. PostgreSQL then holds its ACCESS
+// EXCLUSIVE lock only briefly, and scans the table under a lock that allows
+// writes, provided each statement runs in its own transaction. Only
+// PostgreSQL supports NOT VALID constraints.
+func WithDeferredValidation() DiffOption {
+	return func(config *diffConfig) {
+		config.deferValidation = true
+	}
+}
+
+// deferValidation splits the constraints added by changes into a NOT VALID
+// constraint and a ValidateConstraintChange at the end
+func deferValidation(changes []Change, source, target *Schema) []Change {
+	var result, validations []Change
+	for _, change := range changes {
+		switch c := change.(type) {
+		case *AddForeignKeyChange:
+			c.NotValid = true
+			validations = append(validations, &ValidateConstraintChange{TableName: c.TableName, ConstraintName: c.ForeignKey.Name})
+		case *AddCheckChange:
+			c.NotValid = true
+			validations = append(validations, &ValidateConstraintChange{TableName: c.TableName, ConstraintName: c.Check.Name})
+		case *AlterColumnChange:
+			if col := sourceColumn(source, target, c.TableName, c.Column.Name); col != nil && col.Nullable && !c.Column.Nullable {
+				// SET NOT NULL skips its table scan when a validated check constraint proves it
+				tableName := c.TableName[strings.LastIndex(c.TableName, ".")+1:]
+				check := &CheckConstraint{
+					Name:       fmt.Sprintf("%s_%s_not_null", tableName, c.Column.Name),
+					Expression: fmt.Sprintf(`"%s" IS NOT NULL`, c.Column.Name),
+				}
+				result = append(result,
+					&AddCheckChange{TableName: c.TableName, Check: check, NotValid: true},
+					&ValidateConstraintChange{TableName: c.TableName, ConstraintName: check.Name},
+					c,
+					&DropCheckChange{TableName: c.TableName, CheckName: check.Name},
+				)
+				continue
+			}
+		}
+		result = append(result, change)
+	}
+	return append(result, validations...)
+}
+
+// sourceColumn returns the source column that a column of a target table
+// given by its qualified name is diffed against
+func sourceColumn(source, target *Schema, tableName, columnName string) *Column {
+	for _, table := range target.Tables {
+		if qualifiedTableName(table) != tableName {
+			continue
+		}
+		sourceTable := findSourceTable(source, target, table)
+		column := findColumn(table, columnName)
+		if sourceTable == nil || column == nil {
+			return nil
+		}
+		if col := findColumn(sourceTable, column.Name); col != nil {
+			return col
+		}
+		return findColumn(sourceTable, column.RenamedFrom)
+	}
+	return nil
+}
+
 // diffSchemaNames compares schema names and returns create/drop schema changes
 func diffSchemaNames(source, target *Schema) []Change {
 	var changes []Change
@@ -86,6 +188,15 @@ func diffSchemaNames(source, target *Schema) []Change {
 		})
 	}
 
+	// Create the schemas that target objects live in
+	for _, name := range objectSchemas(target) {
+		if name != source.Name && !slices.Contains(objectSchemas(source), name) {
+			changes = append(changes, &CreateSchemaChange{
+				SchemaName: name,
+			})
+		}
+	}
+
 	// Check if we need to drop a schema
 	if source.Name != "" && source.Name != "public" && source.Name != target.Name {
 		// Only drop if there are no tables left in that schema
@@ -106,6 +217,36 @@ func diffSchemaNames(source, target *Schema) []Change {
 	return changes
 }
 
+// objectSchemas returns the namespaces of a schema and the other database
+// schemas that its tables, views, functions, sequences and enums live in
+func objectSchemas(s *Schema) []string {
+	var names []string
+	add := func(name string) {
+		if name != "" && name != "public" && name != s.Name && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range s.Namespaces {
+		add(name)
+	}
+	for _, table := range s.Tables {
+		add(table.Schema)
+	}
+	for _, view := range s.Views {
+		add(view.Schema)
+	}
+	for _, fn := range s.Functions {
+		add(fn.Schema)
+	}
+	for _, seq := range s.Sequences {
+		add(seq.Schema)
+	}
+	for _, enum := range s.Enums {
+		add(enum.Schema)
+	}
+	return names
+}
+
 // diffExtensions compares extensions and returns create/drop extension changes
 func diffExtensions(source, target *Schema) []Change {
 	var changes []Change
@@ -145,6 +286,118 @@ func diffExtensions(source, target *Schema) []Change {
 	return changes
 }
 
+// diffEnums compares enum types and returns create/drop enum changes, and
+// changes for the values added to or removed from existing enum types
+func diffEnums(source, target *Schema) []Change {
+	var changes []Change
+
+	// Find enums to drop
+	for _, sourceEnum := range source.Enums {
+		if findEnum(target, sourceEnum.QualifiedName()) == nil {
+			changes = append(changes, &DropEnumChange{
+				SchemaName: sourceEnum.Schema,
+				EnumName:   sourceEnum.Name,
+			})
+		}
+	}
+
+	// Find enums to create or alter
+	for _, targetEnum := range target.Enums {
+		sourceEnum := findEnum(source, targetEnum.QualifiedName())
+		if sourceEnum == nil {
+			changes = append(changes, &CreateEnumChange{
+				Enum: targetEnum,
+			})
+			continue
+		}
+		changes = append(changes, diffEnumValues(source, target, sourceEnum, targetEnum)...)
+	}
+
+	return changes
+}
+
+// diffEnumValues returns the changes that turn the values of sourceEnum into
+// those of targetEnum. Values added in any position become
+// AddEnumValueChanges. Values that are removed or reordered cannot be altered
+// in place, so the type is recreated by an AlterEnumChange marked unsafe.
+func diffEnumValues(source, target *Schema, sourceEnum, targetEnum *Enum) []Change {
+	var kept []string
+	for _, value := range targetEnum.Values {
+		if slices.Contains(sourceEnum.Values, value) {
+			kept = append(kept, value)
+		}
+	}
+
+	if !equalStringSlices(kept, sourceEnum.Values) {
+		change := &AlterEnumChange{
+			Enum:    targetEnum,
+			Columns: enumColumns(source, target, sourceEnum, targetEnum),
+		}
+		change.SetUnsafe(true)
+		return []Change{change}
+	}
+
+	var changes []Change
+	for i, value := range targetEnum.Values {
+		if slices.Contains(sourceEnum.Values, value) {
+			continue
+		}
+		change := &AddEnumValueChange{Enum: targetEnum, Value: value}
+		// Values added after every existing value are appended, the others
+		// are placed next to a value that already exists
+		if next := slices.IndexFunc(targetEnum.Values[i+1:], func(v string) bool {
+			return slices.Contains(sourceEnum.Values, v)
+		}); next >= 0 {
+			if i > 0 {
+				change.After = targetEnum.Values[i-1]
+			} else {
+				change.Before = targetEnum.Values[i+1+next]
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// enumColumns returns the source columns of an enum type that keep the type
+// in the target, with their target defaults
+func enumColumns(source, target *Schema, sourceEnum, targetEnum *Enum) []EnumColumn {
+	var columns []EnumColumn
+	for _, targetTable := range target.Tables {
+		sourceTable := findSourceTable(source, target, targetTable)
+		if sourceTable == nil {
+			continue
+		}
+		for _, targetCol := range targetTable.Columns {
+			if !targetEnum.isTypeOf(targetCol) {
+				continue
+			}
+			sourceCol := findColumn(sourceTable, targetCol.Name)
+			if sourceCol == nil && targetCol.RenamedFrom != "" {
+				sourceCol = findColumn(sourceTable, targetCol.RenamedFrom)
+			}
+			if sourceCol != nil && sourceEnum.isTypeOf(sourceCol) {
+				columns = append(columns, EnumColumn{
+					TableName:  qualifiedTableName(sourceTable),
+					ColumnName: sourceCol.Name,
+					Default:    targetCol.Default,
+				})
+			}
+		}
+	}
+	return columns
+}
+
+// findEnum returns the enum type of a schema with the given qualified name
+func findEnum(s *Schema, name string) *Enum {
+	for _, enum := range s.Enums {
+		if enum.QualifiedName() == name {
+			return enum
+		}
+	}
+	return nil
+}
+
 // diffSequences compares sequences and returns create/alter/drop sequence changes
 func diffSequences(source, target *Schema) []Change {
 	var changes []Change
@@ -183,6 +436,8 @@ func diffSequences(source, target *Schema) []Change {
 					sourceSeq.Cycle != targetSeq.Cycle {
 					changes = append(changes, &AlterSequenceChange{
 						Sequence: targetSeq,
+						Source:   sourceSeq,
+						Changed:  changedAttributes(sourceSeq, targetSeq),
 					})
 				}
 				break
@@ -232,6 +487,8 @@ func diffFunctions(source, target *Schema) []Change {
 				if !isSameFunctionDefinition(sourceFunc, targetFunc) {
 					changes = append(changes, &AlterFunctionChange{
 						Function: targetFunc,
+						Source:   sourceFunc,
+						Changed:  changedAttributes(sourceFunc, targetFunc),
 					})
 				}
 				break
@@ -325,7 +582,9 @@ func diffViews(source, target *Schema) []Change {
 					!stringsEqual(targetView.Options, sourceView.Options) ||
 					!stringsEqual(targetView.Columns, sourceView.Columns) {
 					changes = append(changes, &AlterViewChange{
-						View: targetView,
+						View:    targetView,
+						Source:  sourceView,
+						Changed: changedAttributes(sourceView, targetView),
 					})
 				}
 				break
@@ -407,6 +666,8 @@ func diffTriggers(source, target *Schema) []Change {
 				if !isSameTriggerDefinition(sourceTrigger, targetTrigger) {
 					changes = append(changes, &AlterTriggerChange{
 						Trigger: targetTrigger,
+						Source:  sourceTrigger,
+						Changed: changedAttributes(sourceTrigger, targetTrigger),
 					})
 				}
 				break
@@ -459,6 +720,222 @@ func isSameTriggerDefinition(t1, t2 *Trigger) bool {
 	return true
 }
 
+// qualifiedTableName returns the name of a table in table changes, prefixed
+// with its schema unless it is in the default schema
+func qualifiedTableName(table *Table) string {
+	if table.Schema == "" || table.Schema == "public" {
+		return table.Name
+	}
+	return table.Schema + "." + table.Name
+}
+
+// findTable returns the table with the given schema and name
+func findTable(tables []*Table, schema, name string) *Table {
+	for _, table := range tables {
+		if table.Name == name && table.Schema == schema {
+			return table
+		}
+	}
+	return nil
+}
+
+// findSourceTable returns the source table that a target table is diffed
+// against: the table with the same name, or the table it was renamed from
+func findSourceTable(source, target *Schema, targetTable *Table) *Table {
+	if table := findTable(source.Tables, targetTable.Schema, targetTable.Name); table != nil {
+		return table
+	}
+	if targetTable.RenamedFrom == "" || findTable(target.Tables, targetTable.Schema, targetTable.RenamedFrom) != nil {
+		return nil
+	}
+	return findTable(source.Tables, targetTable.Schema, targetTable.RenamedFrom)
+}
+
+// findTargetTable returns the target table that a source table is kept or renamed as
+func findTargetTable(source, target *Schema, sourceTable *Table) *Table {
+	for _, table := range target.Tables {
+		if findSourceTable(source, target, table) == sourceTable {
+			return table
+		}
+	}
+	return nil
+}
+
+// diffRenames returns the changes that rename a table and its columns and
+// indexes as hinted by RenamedFrom in the target table, and the source table
+// as it is after these changes
+func diffRenames(sourceTable, targetTable *Table) ([]Change, *Table) {
+	var changes []Change
+	table := copyTable(sourceTable)
+
+	if table.Name != targetTable.Name {
+		changes = append(changes, &RenameTableChange{
+			SchemaName: table.Schema,
+			OldName:    table.Name,
+			NewName:    targetTable.Name,
+		})
+		table.Name = targetTable.Name
+	}
+
+	for _, targetCol := range targetTable.Columns {
+		if targetCol.RenamedFrom == "" || findColumn(table, targetCol.Name) != nil || findColumn(table, targetCol.RenamedFrom) == nil {
+			continue
+		}
+		changes = append(changes, &RenameColumnChange{
+			TableName: qualifiedTableName(table),
+			OldName:   targetCol.RenamedFrom,
+			NewName:   targetCol.Name,
+		})
+		renameColumn(table, targetCol.RenamedFrom, targetCol.Name)
+	}
+
+	for _, targetIdx := range targetTable.Indexes {
+		if targetIdx.RenamedFrom == "" || findIndex(table, targetIdx.Name) != nil {
+			continue
+		}
+		for i, idx := range table.Indexes {
+			if idx.Name == targetIdx.RenamedFrom {
+				changes = append(changes, &RenameIndexChange{
+					TableName: qualifiedTableName(table),
+					OldName:   idx.Name,
+					NewName:   targetIdx.Name,
+					Index:     targetIdx,
+				})
+				renamed := *idx
+				renamed.Name = targetIdx.Name
+				table.Indexes[i] = &renamed
+			}
+		}
+	}
+
+	return changes, table
+}
+
+// findColumn returns the column of a table with the given name
+func findColumn(table *Table, name string) *Column {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// findIndex returns the index of a table with the given name
+func findIndex(table *Table, name string) *Index {
+	for _, idx := range table.Indexes {
+		if idx.Name == name {
+			return idx
+		}
+	}
+	return nil
+}
+
+// renameColumn renames a column of a table copied with copyTable, along
+// with the references to it in the primary key, indexes and constraints
+func renameColumn(table *Table, oldName, newName string) {
+	rename := func(columns []string) []string {
+		renamed := slices.Clone(columns)
+		for i, col := range renamed {
+			if col == oldName {
+				renamed[i] = newName
+			}
+		}
+		return renamed
+	}
+
+	for i, col := range table.Columns {
+		if col.Name == oldName {
+			renamed := *col
+			renamed.Name = newName
+			table.Columns[i] = &renamed
+		}
+	}
+	if table.PrimaryKey != nil {
+		pk := *table.PrimaryKey
+		pk.Columns = rename(pk.Columns)
+		table.PrimaryKey = &pk
+	}
+	for i, idx := range table.Indexes {
+		renamed := *idx
+		renamed.Columns = rename(idx.Columns)
+		renamed.Include = rename(idx.Include)
+		renamed.KeyOptions = nil
+		for _, option := range idx.KeyOptions {
+			keyOption := *option
+			if keyOption.Column == oldName {
+				keyOption.Column = newName
+			}
+			renamed.KeyOptions = append(renamed.KeyOptions, &keyOption)
+		}
+		table.Indexes[i] = &renamed
+	}
+	for i, fk := range table.ForeignKeys {
+		renamed := *fk
+		renamed.Columns = rename(fk.Columns)
+		table.ForeignKeys[i] = &renamed
+	}
+	for i, unique := range table.Uniques {
+		renamed := *unique
+		renamed.Columns = rename(unique.Columns)
+		table.Uniques[i] = &renamed
+	}
+}
+
+// SuggestColumnRenames returns the columns that Diff would drop and add in
+// place of a column of the same type. Diff never renames columns by itself;
+// a suggestion is applied by setting RenamedFrom on the target column.
+func SuggestColumnRenames(source, target *Schema) []*RenameColumnChange {
+	var suggestions []*RenameColumnChange
+	for _, targetTable := range target.Tables {
+		sourceTable := findSourceTable(source, target, targetTable)
+		if sourceTable == nil {
+			continue
+		}
+		_, table := diffRenames(sourceTable, targetTable)
+
+		paired := map[string]bool{}
+		for _, sourceCol := range table.Columns {
+			if findColumn(targetTable, sourceCol.Name) != nil {
+				continue
+			}
+			for _, targetCol := range targetTable.Columns {
+				if paired[targetCol.Name] || findColumn(table, targetCol.Name) != nil ||
+					!areColumnTypesEqual(sourceCol.Type, targetCol.Type) {
+					continue
+				}
+				paired[targetCol.Name] = true
+				suggestions = append(suggestions, &RenameColumnChange{
+					TableName: qualifiedTableName(targetTable),
+					OldName:   sourceCol.Name,
+					NewName:   targetCol.Name,
+				})
+				break
+			}
+		}
+	}
+	return suggestions
+}
+
+// matchUniqueIndexes turns the unique indexes of a source table copied with
+// copyTable into unique constraints where the target table declares a unique
+// constraint with the same name and columns. Databases such as MySQL report
+// unique constraints as unique indexes.
+func matchUniqueIndexes(sourceTable, targetTable *Table) {
+	for _, unique := range targetTable.Uniques {
+		if slices.ContainsFunc(sourceTable.Uniques, func(u *UniqueConstraint) bool { return u.Name == unique.Name }) {
+			continue
+		}
+		for i, idx := range sourceTable.Indexes {
+			if idx.Name == unique.Name && idx.Unique && idx.Where == "" && equalStringSlices(idx.Columns, unique.Columns) {
+				sourceTable.Indexes = slices.Delete(sourceTable.Indexes, i, i+1)
+				sourceTable.Uniques = append(sourceTable.Uniques, &UniqueConstraint{Name: idx.Name, Columns: idx.Columns})
+				break
+			}
+		}
+	}
+}
+
 // diffTable compares two tables and returns changes to migrate from source to target
 func diffTable(sourceTable, targetTable *Table) []Change {
 	var changes []Change
@@ -475,9 +952,183 @@ func diffTable(sourceTable, targetTable *Table) []Change {
 	// Compare foreign keys
 	changes = append(changes, diffForeignKeys(sourceTable, targetTable)...)
 
+	// Compare check and unique constraints
+	changes = append(changes, diffChecks(sourceTable, targetTable)...)
+	changes = append(changes, diffUniques(sourceTable, targetTable)...)
+
+	// Drop constraints and indexes before changing the columns they use, and
+	// add them once the columns exist
+	sort.SliceStable(changes, func(i, j int) bool {
+		return tableChangeOrder(changes[i]) < tableChangeOrder(changes[j])
+	})
+
 	return changes
 }
 
+// tableChangeOrder returns the position of a change in the list of changes for a table
+func tableChangeOrder(change Change) int {
+	switch change.(type) {
+	case *DropForeignKeyChange:
+		return 0
+	case *DropIndexChange, *DropCheckChange, *DropUniqueConstraintChange:
+		return 1
+	case *DropPrimaryKeyChange:
+		return 2
+	case *AddPrimaryKeyChange:
+		return 4
+	case *AddIndexChange, *AddCheckChange, *AddUniqueConstraintChange:
+		return 5
+	case *AddForeignKeyChange:
+		return 6
+	default:
+		return 3
+	}
+}
+
+// setTableRebuilds fills the Rebuild field of the changes that SQLite applies
+// by rebuilding the table, with the table as it is right after each change.
+// Only the last of them rebuilds the table, the others are superseded by it.
+func setTableRebuilds(changes []Change, sourceTable *Table, source, target *Schema) {
+	var triggers []*Trigger
+	for _, trigger := range source.Triggers {
+		if trigger.Table == sourceTable.Name && trigger.Schema == sourceTable.Schema {
+			triggers = append(triggers, trigger)
+		}
+	}
+
+	views := rebuildViews(sourceTable.Name, source, target)
+
+	table := copyTable(sourceTable)
+	var rebuilds []*TableRebuild
+	for _, change := range changes {
+		var rebuild **TableRebuild
+
+		switch c := change.(type) {
+		case *DropForeignKeyChange:
+			table.ForeignKeys = slices.DeleteFunc(table.ForeignKeys, func(fk *ForeignKey) bool {
+				return fk.Name == c.FKName
+			})
+			rebuild = &c.Rebuild
+		case *DropIndexChange:
+			table.Indexes = slices.DeleteFunc(table.Indexes, func(idx *Index) bool {
+				return idx.Name == c.IndexName
+			})
+		case *DropPrimaryKeyChange:
+			table.PrimaryKey = nil
+			rebuild = &c.Rebuild
+		case *DropColumnChange:
+			table.Columns = slices.DeleteFunc(table.Columns, func(col *Column) bool {
+				return col.Name == c.ColumnName
+			})
+			rebuild = &c.Rebuild
+		case *AlterColumnChange:
+			for i, col := range table.Columns {
+				if col.Name == c.Column.Name {
+					table.Columns[i] = c.Column
+				}
+			}
+			rebuild = &c.Rebuild
+		case *AddColumnChange:
+			table.Columns = append(table.Columns, c.Column)
+		case *AddPrimaryKeyChange:
+			table.PrimaryKey = c.PrimaryKey
+			rebuild = &c.Rebuild
+		case *AddIndexChange:
+			table.Indexes = append(table.Indexes, c.Index)
+		case *AddForeignKeyChange:
+			table.ForeignKeys = append(table.ForeignKeys, c.ForeignKey)
+			rebuild = &c.Rebuild
+		case *DropCheckChange:
+			table.Checks = slices.DeleteFunc(table.Checks, func(check *CheckConstraint) bool {
+				return check.Name == c.CheckName
+			})
+			rebuild = &c.Rebuild
+		case *AddCheckChange:
+			table.Checks = append(table.Checks, c.Check)
+			rebuild = &c.Rebuild
+		case *DropUniqueConstraintChange:
+			table.Uniques = slices.DeleteFunc(table.Uniques, func(unique *UniqueConstraint) bool {
+				return unique.Name == c.ConstraintName
+			})
+			rebuild = &c.Rebuild
+		case *AddUniqueConstraintChange:
+			table.Uniques = append(table.Uniques, c.Unique)
+			rebuild = &c.Rebuild
+		}
+
+		if rebuild != nil {
+			*rebuild = &TableRebuild{
+				Table:    copyTable(table),
+				Triggers: triggers,
+				Views:    views,
+			}
+			rebuilds = append(rebuilds, *rebuild)
+		}
+	}
+
+	for i := 0; i < len(rebuilds)-1; i++ {
+		rebuilds[i].Superseded = true
+	}
+}
+
+// resetTableRebuilds sets the Rebuild fields of changes again after some of
+// the changes to their tables were removed, so that the rebuilt tables keep
+// what the removed changes would have changed. sources holds the table the
+// changes to each table start from, by qualified name.
+func resetTableRebuilds(changes []Change, sources map[string]*Table, source, target *Schema) {
+	tableChanges := map[string][]Change{}
+	for _, change := range changes {
+		if name, ok := tableChangeName(change); ok && sources[name] != nil {
+			tableChanges[name] = append(tableChanges[name], change)
+		}
+	}
+
+	for name, changes := range tableChanges {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return tableChangeOrder(changes[i]) < tableChangeOrder(changes[j])
+		})
+		setTableRebuilds(changes, sources[name], source, target)
+	}
+}
+
+// copyTable returns a copy of a table whose column, index and constraint
+// lists can be modified without affecting the original
+func copyTable(table *Table) *Table {
+	c := *table
+	c.Columns = slices.Clone(table.Columns)
+	c.Indexes = slices.Clone(table.Indexes)
+	c.ForeignKeys = slices.Clone(table.ForeignKeys)
+	c.Checks = slices.Clone(table.Checks)
+	c.Uniques = slices.Clone(table.Uniques)
+	return &c
+}
+
+// viewReferencesTable reports whether a view definition mentions a table name
+func viewReferencesTable(view *View, tableName string) bool {
+	pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(tableName) + `\b`)
+	return pattern.MatchString(view.Definition)
+}
+
+// rebuildViews returns the views that a rebuild of a table drops and
+// recreates: those that reference it in source and still exist in target,
+// with their target definition. Views added by target are left to their own
+// CreateViewChange, since they do not exist yet when the table is rebuilt.
+func rebuildViews(tableName string, source, target *Schema) []*View {
+	var views []*View
+	for _, sourceView := range source.Views {
+		if !viewReferencesTable(sourceView, tableName) {
+			continue
+		}
+		view := findObject(target.Views, func(v *View) bool {
+			return v.Name == sourceView.Name && v.Schema == sourceView.Schema
+		})
+		if view != nil {
+			views = append(views, view)
+		}
+	}
+	return views
+}
+
 // diffColumns compares columns between two tables and returns changes
 func diffColumns(sourceTable, targetTable *Table) []Change {
 	var changes []Change
@@ -493,7 +1144,7 @@ func diffColumns(sourceTable, targetTable *Table) []Change {
 		}
 		if !found {
 			changes = append(changes, &DropColumnChange{
-				TableName:  sourceTable.Name,
+				TableName:  qualifiedTableName(sourceTable),
 				ColumnName: sourceCol.Name,
 			})
 		}
@@ -510,10 +1161,14 @@ func diffColumns(sourceTable, targetTable *Table) []Change {
 					sourceCol.Nullable != targetCol.Nullable ||
 					sourceCol.Default != targetCol.Default ||
 					sourceCol.Comment != targetCol.Comment {
-					changes = append(changes, &AlterColumnChange{
-						TableName: targetTable.Name,
+					change := &AlterColumnChange{
+						TableName: qualifiedTableName(targetTable),
 						Column:    targetCol,
-					})
+						Source:    sourceCol,
+						Changed:   changedColumnAttributes(sourceCol, targetCol),
+					}
+					change.SetUnsafe(isUnsafeValuesChange(sourceCol.Type, targetCol.Type))
+					changes = append(changes, change)
 				}
 				break
 			}
@@ -521,7 +1176,7 @@ func diffColumns(sourceTable, targetTable *Table) []Change {
 		if !found {
 			// Column doesn't exist in source, add it
 			changes = append(changes, &AddColumnChange{
-				TableName: targetTable.Name,
+				TableName: qualifiedTableName(targetTable),
 				Column:    targetCol,
 			})
 		}
@@ -530,6 +1185,51 @@ func diffColumns(sourceTable, targetTable *Table) []Change {
 	return changes
 }
 
+// changedColumnAttributes returns the attributes of a column that Diff
+// compares and that differ
+func changedColumnAttributes(source, target *Column) Attributes {
+	var changed Attributes
+	if !areColumnTypesEqual(source.Type, target.Type) {
+		changed = append(changed, "Type")
+	}
+	if source.Nullable != target.Nullable {
+		changed = append(changed, "Nullable")
+	}
+	if source.Default != target.Default {
+		changed = append(changed, "Default")
+	}
+	if source.Comment != target.Comment {
+		changed = append(changed, "Comment")
+	}
+	return changed
+}
+
+// changedAttributes returns the exported fields of two objects of the same
+// type that differ, except their names
+func changedAttributes(source, target any) Attributes {
+	var changed Attributes
+	for _, difference := range compareAttributes(source, target) {
+		changed = append(changed, difference.Attribute)
+	}
+	return changed
+}
+
+// isUnsafeValuesChange reports whether the values of an enumerated column
+// type are removed or reordered, which fails or rewrites existing rows
+func isUnsafeValuesChange(source, target ColumnType) bool {
+	sourceType, ok := source.(EnumeratedType)
+	if !ok {
+		return false
+	}
+	targetType, ok := target.(EnumeratedType)
+	if !ok || reflect.TypeOf(sourceType) != reflect.TypeOf(targetType) {
+		return false
+	}
+	sourceValues := sourceType.EnumValues()
+	targetValues := targetType.EnumValues()
+	return len(targetValues) < len(sourceValues) || !equalStringSlices(sourceValues, targetValues[:len(sourceValues)])
+}
+
 // diffPrimaryKeys compares primary keys between two tables
 func diffPrimaryKeys(sourceTable, targetTable *Table) []Change {
 	var changes []Change
@@ -537,7 +1237,7 @@ func diffPrimaryKeys(sourceTable, targetTable *Table) []Change {
 	// If source has primary key but target doesn't, drop it
 	if sourceTable.PrimaryKey != nil && targetTable.PrimaryKey == nil {
 		changes = append(changes, &DropPrimaryKeyChange{
-			TableName: sourceTable.Name,
+			TableName: qualifiedTableName(sourceTable),
 			PKName:    sourceTable.PrimaryKey.Name,
 		})
 		return changes
@@ -546,7 +1246,7 @@ func diffPrimaryKeys(sourceTable, targetTable *Table) []Change {
 	// If target has primary key but source doesn't, add it
 	if sourceTable.PrimaryKey == nil && targetTable.PrimaryKey != nil {
 		changes = append(changes, &AddPrimaryKeyChange{
-			TableName:  targetTable.Name,
+			TableName:  qualifiedTableName(targetTable),
 			PrimaryKey: targetTable.PrimaryKey,
 		})
 		return changes
@@ -558,11 +1258,11 @@ func diffPrimaryKeys(sourceTable, targetTable *Table) []Change {
 			sourceTable.PrimaryKey.Name != targetTable.PrimaryKey.Name {
 			// Drop the old one and add the new one
 			changes = append(changes, &DropPrimaryKeyChange{
-				TableName: sourceTable.Name,
+				TableName: qualifiedTableName(sourceTable),
 				PKName:    sourceTable.PrimaryKey.Name,
 			})
 			changes = append(changes, &AddPrimaryKeyChange{
-				TableName:  targetTable.Name,
+				TableName:  qualifiedTableName(targetTable),
 				PrimaryKey: targetTable.PrimaryKey,
 			})
 		}
@@ -586,7 +1286,7 @@ func diffIndexes(sourceTable, targetTable *Table) []Change {
 		}
 		if !found {
 			changes = append(changes, &DropIndexChange{
-				TableName: sourceTable.Name,
+				TableName: qualifiedTableName(sourceTable),
 				IndexName: sourceIdx.Name,
 			})
 		}
@@ -598,16 +1298,15 @@ func diffIndexes(sourceTable, targetTable *Table) []Change {
 		for _, sourceIdx := range sourceTable.Indexes {
 			if sourceIdx.Name == targetIdx.Name {
 				found = true
-				// Index exists in both, check if they're different
-				if !equalStringSlices(sourceIdx.Columns, targetIdx.Columns) ||
-					sourceIdx.Unique != targetIdx.Unique {
+				// Index exists in both, check if they're different or left invalid
+				if sourceIdx.Invalid || !isSameIndex(sourceIdx, targetIdx) {
 					// Drop the old one and add the new one
 					changes = append(changes, &DropIndexChange{
-						TableName: sourceTable.Name,
+						TableName: qualifiedTableName(sourceTable),
 						IndexName: sourceIdx.Name,
 					})
 					changes = append(changes, &AddIndexChange{
-						TableName: targetTable.Name,
+						TableName: qualifiedTableName(targetTable),
 						Index:     targetIdx,
 					})
 				}
@@ -617,7 +1316,7 @@ func diffIndexes(sourceTable, targetTable *Table) []Change {
 		if !found {
 			// Index doesn't exist in source, add it
 			changes = append(changes, &AddIndexChange{
-				TableName: targetTable.Name,
+				TableName: qualifiedTableName(targetTable),
 				Index:     targetIdx,
 			})
 		}
@@ -626,6 +1325,49 @@ func diffIndexes(sourceTable, targetTable *Table) []Change {
 	return changes
 }
 
+// isSameIndex reports whether two indexes with the same name have the same definition
+func isSameIndex(a, b *Index) bool {
+	if len(a.Columns) != len(b.Columns) ||
+		a.Unique != b.Unique ||
+		indexMethod(a) != indexMethod(b) ||
+		!isSameExpression(a.Where, b.Where) ||
+		!equalStringSlices(a.Include, b.Include) {
+		return false
+	}
+
+	for i, column := range a.Columns {
+		if column != b.Columns[i] && (!strings.HasPrefix(column, "(") || !isSameExpression(column, b.Columns[i])) {
+			return false
+		}
+		if indexKeyOption(a, column) != indexKeyOption(b, b.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexMethod returns the lowercase method of an index, empty for the default btree
+func indexMethod(idx *Index) string {
+	method := strings.ToLower(idx.Method)
+	if method == "btree" {
+		return ""
+	}
+	return method
+}
+
+// indexKeyOption returns the settings of an index key column in a form that
+// can be compared, without the column and with the default nulls order left out
+func indexKeyOption(idx *Index, column string) IndexKeyOption {
+	option := idx.KeyOption(column)
+	option.Column = ""
+	option.Nulls = strings.ToUpper(option.Nulls)
+	option.OpClass = strings.ToLower(option.OpClass)
+	if option.Nulls == "LAST" && !option.Desc || option.Nulls == "FIRST" && option.Desc {
+		option.Nulls = ""
+	}
+	return option
+}
+
 // diffForeignKeys compares foreign keys between two tables and returns changes
 func diffForeignKeys(sourceTable, targetTable *Table) []Change {
 	var changes []Change
@@ -641,7 +1383,7 @@ func diffForeignKeys(sourceTable, targetTable *Table) []Change {
 		}
 		if !found {
 			changes = append(changes, &DropForeignKeyChange{
-				TableName: sourceTable.Name,
+				TableName: qualifiedTableName(sourceTable),
 				FKName:    sourceFk.Name,
 			})
 		}
@@ -661,13 +1403,19 @@ func diffForeignKeys(sourceTable, targetTable *Table) []Change {
 					sourceFk.OnUpdate != targetFk.OnUpdate {
 					// Drop the old one and add the new one
 					changes = append(changes, &DropForeignKeyChange{
-						TableName: sourceTable.Name,
+						TableName: qualifiedTableName(sourceTable),
 						FKName:    sourceFk.Name,
 					})
 					changes = append(changes, &AddForeignKeyChange{
-						TableName:  targetTable.Name,
+						TableName:  qualifiedTableName(targetTable),
 						ForeignKey: targetFk,
 					})
+				} else if sourceFk.NotValid && !targetFk.NotValid {
+					// Finish a rollout that added the foreign key NOT VALID
+					changes = append(changes, &ValidateConstraintChange{
+						TableName:      qualifiedTableName(targetTable),
+						ConstraintName: targetFk.Name,
+					})
 				}
 				break
 			}
@@ -675,7 +1423,7 @@ func diffForeignKeys(sourceTable, targetTable *Table) []Change {
 		if !found {
 			// Foreign key doesn't exist in source, add it
 			changes = append(changes, &AddForeignKeyChange{
-				TableName:  targetTable.Name,
+				TableName:  qualifiedTableName(targetTable),
 				ForeignKey: targetFk,
 			})
 		}
@@ -684,6 +1432,78 @@ func diffForeignKeys(sourceTable, targetTable *Table) []Change {
 	return changes
 }
 
+// diffChecks compares check constraints between two tables and returns changes
+func diffChecks(sourceTable, targetTable *Table) []Change {
+	var changes []Change
+
+	// Find check constraints to drop or modify
+	for _, sourceCheck := range sourceTable.Checks {
+		i := slices.IndexFunc(targetTable.Checks, func(check *CheckConstraint) bool {
+			return check.Name == sourceCheck.Name
+		})
+		if i < 0 || !isSameExpression(sourceCheck.Expression, targetTable.Checks[i].Expression) {
+			changes = append(changes, &DropCheckChange{
+				TableName: qualifiedTableName(sourceTable),
+				CheckName: sourceCheck.Name,
+			})
+		}
+	}
+
+	// Find check constraints to add or modify
+	for _, targetCheck := range targetTable.Checks {
+		i := slices.IndexFunc(sourceTable.Checks, func(check *CheckConstraint) bool {
+			return check.Name == targetCheck.Name
+		})
+		if i < 0 || !isSameExpression(sourceTable.Checks[i].Expression, targetCheck.Expression) {
+			changes = append(changes, &AddCheckChange{
+				TableName: qualifiedTableName(targetTable),
+				Check:     targetCheck,
+			})
+		} else if sourceTable.Checks[i].NotValid && !targetCheck.NotValid {
+			// Finish a rollout that added the check constraint NOT VALID
+			changes = append(changes, &ValidateConstraintChange{
+				TableName:      qualifiedTableName(targetTable),
+				ConstraintName: targetCheck.Name,
+			})
+		}
+	}
+
+	return changes
+}
+
+// diffUniques compares unique constraints between two tables and returns changes
+func diffUniques(sourceTable, targetTable *Table) []Change {
+	var changes []Change
+
+	// Find unique constraints to drop or modify
+	for _, sourceUnique := range sourceTable.Uniques {
+		i := slices.IndexFunc(targetTable.Uniques, func(unique *UniqueConstraint) bool {
+			return unique.Name == sourceUnique.Name
+		})
+		if i < 0 || !equalStringSlices(sourceUnique.Columns, targetTable.Uniques[i].Columns) {
+			changes = append(changes, &DropUniqueConstraintChange{
+				TableName:      qualifiedTableName(sourceTable),
+				ConstraintName: sourceUnique.Name,
+			})
+		}
+	}
+
+	// Find unique constraints to add or modify
+	for _, targetUnique := range targetTable.Uniques {
+		i := slices.IndexFunc(sourceTable.Uniques, func(unique *UniqueConstraint) bool {
+			return unique.Name == targetUnique.Name
+		})
+		if i < 0 || !equalStringSlices(sourceTable.Uniques[i].Columns, targetUnique.Columns) {
+			changes = append(changes, &AddUniqueConstraintChange{
+				TableName: qualifiedTableName(targetTable),
+				Unique:    targetUnique,
+			})
+		}
+	}
+
+	return changes
+}
+
 // equalStringSlices checks if two string slices are equal
 func equalStringSlices(a, b []string) bool {
 	if len(a) != len(b) {
@@ -739,6 +1559,8 @@ func diffRowPolicies(source, target *Schema) []Change {
 					targetPolicy.Permissive != sourcePolicy.Permissive {
 					changes = append(changes, &AlterRowPolicyChange{
 						RowPolicy: targetPolicy,
+						Source:    sourcePolicy,
+						Changed:   changedAttributes(sourcePolicy, targetPolicy),
 					})
 				}
 				break
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -107,6 +109,13 @@ func TestDiff(t *testing.T) {
 				&DropColumnChange{
 					TableName:  "users",
 					ColumnName: "name",
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name:    "users",
+							Columns: []*Column{{Name: "id", Type: &IntegerType{}}},
+							Indexes: []*Index{},
+						},
+					},
 				},
 			},
 		},
@@ -135,6 +144,18 @@ func TestDiff(t *testing.T) {
 						Name: "name",
 						Type: &TextType{},
 					},
+					Source:  &Column{Name: "name", Type: &VarcharType{}},
+					Changed: Attributes{"Type"},
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name: "users",
+							Columns: []*Column{
+								{Name: "id", Type: &IntegerType{}},
+								{Name: "name", Type: &TextType{}},
+							},
+							Indexes: []*Index{},
+						},
+					},
 				},
 			},
 		},
@@ -162,6 +183,14 @@ func TestDiff(t *testing.T) {
 						Name:    "pk_users",
 						Columns: []string{"id"},
 					},
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name:       "users",
+							Columns:    []*Column{{Name: "id", Type: &IntegerType{}}},
+							Indexes:    []*Index{},
+							PrimaryKey: &PrimaryKey{Name: "pk_users", Columns: []string{"id"}},
+						},
+					},
 				},
 			},
 		},
@@ -186,6 +215,13 @@ func TestDiff(t *testing.T) {
 				&DropPrimaryKeyChange{
 					TableName: "users",
 					PKName:    "pk_users",
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name:    "users",
+							Columns: []*Column{{Name: "id", Type: &IntegerType{}}},
+							Indexes: []*Index{},
+						},
+					},
 				},
 			},
 		},
@@ -278,6 +314,19 @@ func TestDiff(t *testing.T) {
 						RefTable:   "users",
 						RefColumns: []string{"id"},
 					},
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name: "posts",
+							Columns: []*Column{
+								{Name: "id", Type: &IntegerType{}},
+								{Name: "user_id", Type: &IntegerType{}},
+							},
+							Indexes: []*Index{},
+							ForeignKeys: []*ForeignKey{
+								{Name: "fk_user", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -310,6 +359,17 @@ func TestDiff(t *testing.T) {
 				&DropForeignKeyChange{
 					TableName: "posts",
 					FKName:    "fk_user",
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name: "posts",
+							Columns: []*Column{
+								{Name: "id", Type: &IntegerType{}},
+								{Name: "user_id", Type: &IntegerType{}},
+							},
+							Indexes:     []*Index{},
+							ForeignKeys: []*ForeignKey{},
+						},
+					},
 				},
 			},
 		},
@@ -337,6 +397,17 @@ func TestDiff(t *testing.T) {
 				&DropPrimaryKeyChange{
 					TableName: "users",
 					PKName:    "pk_users_id",
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name: "users",
+							Columns: []*Column{
+								{Name: "id", Type: &IntegerType{}},
+								{Name: "uuid", Type: &VarcharType{}},
+							},
+							Indexes: []*Index{},
+						},
+						Superseded: true,
+					},
 				},
 				&AddPrimaryKeyChange{
 					TableName: "users",
@@ -344,6 +415,17 @@ func TestDiff(t *testing.T) {
 						Name:    "pk_users_uuid",
 						Columns: []string{"uuid"},
 					},
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name: "users",
+							Columns: []*Column{
+								{Name: "id", Type: &IntegerType{}},
+								{Name: "uuid", Type: &VarcharType{}},
+							},
+							Indexes:    []*Index{},
+							PrimaryKey: &PrimaryKey{Name: "pk_users_uuid", Columns: []string{"uuid"}},
+						},
+					},
 				},
 			},
 		},
@@ -379,6 +461,14 @@ func TestDiff(t *testing.T) {
 				&DropColumnChange{
 					TableName:  "users",
 					ColumnName: "old_name",
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name:    "users",
+							Columns: []*Column{{Name: "id", Type: &IntegerType{}}},
+							Indexes: []*Index{},
+						},
+						Superseded: true,
+					},
 				},
 				&AddColumnChange{
 					TableName: "users",
@@ -393,6 +483,17 @@ func TestDiff(t *testing.T) {
 						Name:    "pk_users",
 						Columns: []string{"id"},
 					},
+					Rebuild: &TableRebuild{
+						Table: &Table{
+							Name: "users",
+							Columns: []*Column{
+								{Name: "id", Type: &IntegerType{}},
+								{Name: "name", Type: &VarcharType{}},
+							},
+							Indexes:    []*Index{},
+							PrimaryKey: &PrimaryKey{Name: "pk_users", Columns: []string{"id"}},
+						},
+					},
 				},
 				&CreateTableChange{
 					TableDef: &Table{
@@ -406,7 +507,704 @@ func TestDiff(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		changes := Diff(tt.source, tt.target)
+		changes := withoutHazards(Diff(tt.source, tt.target))
 		require.Equal(t, tt.expected, changes, tt.name)
 	}
 }
+
+func TestDiffTableRebuild(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &VarcharType{})
+		t.Index("users_name_idx", []string{"name"})
+	})
+	source.CreateTrigger("users_audit", "users", "audit_user")
+	source.CreateView("user_ids", "SELECT id FROM users")
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	target.CreateTrigger("users_audit", "users", "audit_user")
+	target.CreateView("user_ids", "SELECT id FROM users")
+	target.CreateView("other_ids", "SELECT id FROM users_archive")
+
+	require.Equal(t, []Change{
+		&CreateViewChange{
+			View: target.Views[1],
+		},
+		&DropIndexChange{
+			TableName: "users",
+			IndexName: "users_name_idx",
+		},
+		&DropColumnChange{
+			TableName:  "users",
+			ColumnName: "name",
+			Rebuild: &TableRebuild{
+				Table: &Table{
+					Name:    "users",
+					Columns: []*Column{{Name: "id", Type: &IntegerType{}}},
+					Indexes: []*Index{},
+				},
+				Triggers: source.Triggers,
+				Views:    target.Views[:1],
+			},
+		},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffOrderCreates(t *testing.T) {
+	target := NewSchema()
+	target.CreateView("active_users", "SELECT id FROM users WHERE active")
+	target.CreateRowPolicy("users", "users_owner")
+	target.CreateSequence("user_ids")
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{}, Default("nextval('user_ids')"))
+		t.Column("active", &BooleanType{})
+	})
+
+	require.Equal(t, []Change{
+		&CreateSequenceChange{Sequence: target.Sequences[0]},
+		&CreateTableChange{TableDef: target.Tables[0]},
+		&CreateViewChange{View: target.Views[0]},
+		&CreateRowPolicyChange{RowPolicy: target.RowPolicies[0]},
+	}, Diff(NewSchema(), target))
+}
+
+func TestDiffOrderDrops(t *testing.T) {
+	source := NewSchema()
+	source.CreateSequence("user_ids")
+	source.CreateFunction("audit_user", "trigger", "BEGIN RETURN NEW; END;")
+	source.CreateView("user_posts", "SELECT * FROM users JOIN posts ON posts.user_id = users.id")
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{}, Default("nextval('user_ids')"))
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"})
+	})
+	source.CreateTrigger("users_audit", "users", "audit_user()")
+
+	require.Equal(t, []Change{
+		&DropViewChange{ViewName: "user_posts"},
+		&DropTableChange{TableName: "posts"},
+		&DropTriggerChange{TriggerName: "users_audit", TriggerTable: "users"},
+		&DropFunctionChange{FunctionName: "audit_user", FunctionArgs: []FunctionArg{}},
+		&DropTableChange{TableName: "users"},
+		&DropSequenceChange{SequenceName: "user_ids"},
+	}, withoutHazards(Diff(source, NewSchema())))
+}
+
+func TestDiffOrderDropReferencedTable(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"})
+	})
+
+	target := NewSchema()
+	target.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	changes := withoutHazards(Diff(source, target))
+	require.Len(t, changes, 3)
+	require.IsType(t, &DropForeignKeyChange{}, changes[0])
+	require.IsType(t, &DropColumnChange{}, changes[1])
+	require.Equal(t, &DropTableChange{TableName: "users"}, changes[2])
+}
+
+func TestDiffOrderForeignKeyCycle(t *testing.T) {
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("team_id", &IntegerType{})
+		t.ForeignKey("users_team_id_fkey", []string{"team_id"}, "teams", []string{"id"})
+	})
+	target.CreateTable("teams", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("owner_id", &IntegerType{})
+		t.ForeignKey("teams_owner_id_fkey", []string{"owner_id"}, "users", []string{"id"})
+	})
+	target.CreateTable("memberships", func(t *Table) {
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("memberships_user_id_fkey", []string{"user_id"}, "users", []string{"id"})
+	})
+
+	require.Equal(t, []Change{
+		&CreateTableChange{
+			TableDef: &Table{
+				Name:    "users",
+				Columns: target.Tables[0].Columns,
+				Indexes: []*Index{},
+			},
+		},
+		&CreateTableChange{TableDef: target.Tables[1]},
+		&CreateTableChange{TableDef: target.Tables[2]},
+		&AddForeignKeyChange{
+			TableName:  "users",
+			ForeignKey: target.Tables[0].ForeignKeys[0],
+			Rebuild:    &TableRebuild{Table: target.Tables[0]},
+		},
+	}, Diff(NewSchema(), target))
+}
+
+func TestDiffRenameColumn(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &VarcharType{Length: 100})
+		t.Index("users_name_idx", []string{"name"})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("full_name", &TextType{}, RenamedFrom("name"))
+		t.Index("users_full_name_idx", []string{"full_name"}, IndexRenamedFrom("users_name_idx"))
+	})
+
+	require.Equal(t, []Change{
+		&RenameColumnChange{TableName: "users", OldName: "name", NewName: "full_name"},
+		&RenameIndexChange{
+			TableName: "users",
+			OldName:   "users_name_idx",
+			NewName:   "users_full_name_idx",
+			Index:     target.Tables[0].Indexes[0],
+		},
+		&AlterColumnChange{
+			TableName: "users",
+			Column:    target.Tables[0].Columns[1],
+			Source:    &Column{Name: "full_name", Type: &VarcharType{Length: 100}},
+			Changed:   Attributes{"Type"},
+			Rebuild: &TableRebuild{
+				Table: &Table{
+					Name: "users",
+					Columns: []*Column{
+						{Name: "id", Type: &IntegerType{}},
+						target.Tables[0].Columns[1],
+					},
+					Indexes: []*Index{
+						{Name: "users_full_name_idx", Columns: []string{"full_name"}},
+					},
+				},
+			},
+		},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffRenameTable(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("accounts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &TextType{}, Nullable)
+	}, TableRenamedFrom("users"))
+
+	require.Equal(t, []Change{
+		&RenameTableChange{OldName: "users", NewName: "accounts"},
+		&AddColumnChange{TableName: "accounts", Column: target.Tables[0].Columns[1]},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffRenameHintIgnored(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	target.CreateTable("accounts", func(t *Table) {
+		t.Column("id", &IntegerType{}, RenamedFrom("account_id"))
+	}, TableRenamedFrom("users"))
+
+	require.Equal(t, []Change{
+		&CreateTableChange{TableDef: target.Tables[1]},
+	}, Diff(source, target))
+}
+
+func TestSuggestColumnRenames(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &TextType{})
+		t.Column("age", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("born_on", &DateType{})
+		t.Column("full_name", &TextType{})
+	})
+
+	require.Equal(t, []*RenameColumnChange{
+		{TableName: "users", OldName: "name", NewName: "full_name"},
+	}, SuggestColumnRenames(source, target))
+
+	changes := Diff(source, target)
+	require.IsType(t, &DropColumnChange{}, changes[0])
+}
+
+func TestDiffTablesInSchemas(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("events", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	source.CreateTable("events", func(t *Table) {
+		t.Schema = "audit"
+		t.Column("id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("events", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	target.CreateTable("events", func(t *Table) {
+		t.Schema = "audit"
+		t.Column("id", &IntegerType{})
+		t.Column("created_at", &TimestampType{})
+		t.Index("events_created_at_idx", []string{"created_at"})
+	})
+	target.CreateTable("invoices", func(t *Table) {
+		t.Schema = "billing"
+		t.Column("id", &IntegerType{})
+		t.ForeignKey("invoices_event_id_fkey", []string{"id"}, "audit.events", []string{"id"})
+	})
+
+	require.Equal(t, []Change{
+		&CreateSchemaChange{SchemaName: "billing"},
+		&AddColumnChange{TableName: "audit.events", Column: target.Tables[1].Columns[1]},
+		&AddIndexChange{TableName: "audit.events", Index: target.Tables[1].Indexes[0]},
+		&CreateTableChange{TableDef: target.Tables[2]},
+	}, withoutHazards(Diff(source, target)))
+
+	source.Namespaces = []string{"billing"}
+	require.IsType(t, &AddColumnChange{}, Diff(source, target)[0])
+}
+
+func TestDiffChecks(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("products", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("price", &IntegerType{})
+		t.Column("stock", &IntegerType{})
+		t.Check("products_price_check", "(`price` > 0)")
+		t.Check("products_stock_check", "stock >= 0")
+	})
+
+	target := NewSchema()
+	target.CreateTable("products", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("price", &IntegerType{})
+		t.Column("stock", &IntegerType{})
+		t.Check("products_price_check", "price > 0")
+		t.Check("products_stock_check", "stock > 0")
+		t.Check("products_id_check", "id > 0")
+	})
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 3)
+	require.Equal(t, "products_stock_check", changes[0].(*DropCheckChange).CheckName)
+	require.Equal(t, target.Tables[0].Checks[1], changes[1].(*AddCheckChange).Check)
+	require.Equal(t, target.Tables[0].Checks[2], changes[2].(*AddCheckChange).Check)
+
+	require.Equal(t, []*CheckConstraint{
+		source.Tables[0].Checks[0],
+		target.Tables[0].Checks[1],
+		target.Tables[0].Checks[2],
+	}, changes[2].(*AddCheckChange).Rebuild.Table.Checks)
+}
+
+func TestDiffCheckGrouping(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("products", func(t *Table) {
+		t.Column("a", &IntegerType{})
+		t.Column("b", &IntegerType{})
+		t.Column("c", &IntegerType{})
+		t.Check("products_any_check", "(a > 0 OR b > 0) AND c > 0")
+		t.Check("products_sum_check", "(a + b) * c > 0")
+	})
+
+	target := NewSchema()
+	target.CreateTable("products", func(t *Table) {
+		t.Column("a", &IntegerType{})
+		t.Column("b", &IntegerType{})
+		t.Column("c", &IntegerType{})
+		t.Check("products_any_check", "a > 0 OR (b > 0 AND c > 0)")
+		t.Check("products_sum_check", "a + (b * c) > 0")
+	})
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 4)
+	require.Equal(t, "products_any_check", changes[0].(*DropCheckChange).CheckName)
+	require.Equal(t, "products_sum_check", changes[1].(*DropCheckChange).CheckName)
+	require.Equal(t, target.Tables[0].Checks[0], changes[2].(*AddCheckChange).Check)
+	require.Equal(t, target.Tables[0].Checks[1], changes[3].(*AddCheckChange).Check)
+
+	source.Tables[0].Checks[0].Expression = "(((a > 0) OR (b > 0)) AND (c > 0))"
+	source.Tables[0].Checks[1].Expression = "(((a + b) * c) > 0)"
+	target.Tables[0].Checks[0].Expression = "(a > 0 OR b > 0) AND c > 0"
+	target.Tables[0].Checks[1].Expression = "(a + b) * c > 0"
+	require.Empty(t, Diff(source, target))
+}
+
+func TestDiffDeferredValidation(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &TextType{}, Nullable)
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &TextType{})
+		t.Check("users_id_check", "id > 0")
+	})
+	target.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"})
+	})
+
+	changes := Diff(source, target, WithDeferredValidation())
+	require.Len(t, changes, 8)
+
+	notNull := changes[0].(*AddCheckChange)
+	require.Equal(t, &CheckConstraint{Name: "users_email_not_null", Expression: `"email" IS NOT NULL`}, notNull.Check)
+	require.True(t, notNull.NotValid)
+	require.Equal(t, &ValidateConstraintChange{TableName: "users", ConstraintName: "users_email_not_null"}, changes[1])
+	require.Equal(t, "email", changes[2].(*AlterColumnChange).Column.Name)
+	require.Equal(t, &DropCheckChange{TableName: "users", CheckName: "users_email_not_null"}, changes[3])
+
+	require.Equal(t, target.Tables[0].Checks[0], changes[4].(*AddCheckChange).Check)
+	require.True(t, changes[4].(*AddCheckChange).NotValid)
+	require.Equal(t, target.Tables[1].ForeignKeys[0], changes[5].(*AddForeignKeyChange).ForeignKey)
+	require.True(t, changes[5].(*AddForeignKeyChange).NotValid)
+
+	require.Equal(t, []Change{
+		&ValidateConstraintChange{TableName: "users", ConstraintName: "users_id_check"},
+		&ValidateConstraintChange{TableName: "posts", ConstraintName: "posts_user_id_fkey"},
+	}, changes[6:])
+}
+
+func TestDiffValidatesNotValidConstraints(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Check("users_id_check", "id > 0").NotValid = true
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"}).NotValid = true
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Check("users_id_check", "id > 0")
+	})
+	target.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"})
+	})
+
+	require.Equal(t, []Change{
+		&ValidateConstraintChange{TableName: "users", ConstraintName: "users_id_check"},
+		&ValidateConstraintChange{TableName: "posts", ConstraintName: "posts_user_id_fkey"},
+	}, Diff(source, target))
+}
+
+func TestDiffUniques(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("products", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("sku", &TextType{})
+		t.Column("vendor", &TextType{})
+		t.Unique("products_sku_key", "sku")
+	})
+
+	target := NewSchema()
+	target.CreateTable("products", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("sku", &TextType{})
+		t.Column("vendor", &TextType{})
+		t.Unique("products_sku_key", "vendor", "sku")
+	})
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 2)
+	require.Equal(t, "products_sku_key", changes[0].(*DropUniqueConstraintChange).ConstraintName)
+	require.Equal(t, target.Tables[0].Uniques[0], changes[1].(*AddUniqueConstraintChange).Unique)
+	require.Equal(t, target.Tables[0].Uniques, changes[1].(*AddUniqueConstraintChange).Rebuild.Table.Uniques)
+}
+
+func TestDiffUniqueReportedAsIndex(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("products", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("sku", &TextType{})
+		t.Index("products_sku_key", []string{"sku"}, Unique)
+	})
+
+	target := NewSchema()
+	target.CreateTable("products", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("sku", &TextType{})
+		t.Unique("products_sku_key", "sku")
+	})
+
+	require.Empty(t, Diff(source, target))
+	require.Len(t, source.Tables[0].Indexes, 1)
+}
+
+func TestDiffIndexOptions(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("email", &TextType{})
+		t.Column("created_at", &TextType{})
+		t.Index("users_email_idx", []string{"(lower(email))"}, Using("btree"), NullsLast("(lower(email))"))
+		t.Index("users_created_at_idx", []string{"created_at"}, Where("created_at IS NOT NULL"))
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("email", &TextType{})
+		t.Column("created_at", &TextType{})
+		t.Index("users_email_idx", []string{"(LOWER(email))"})
+		t.Index("users_created_at_idx", []string{"created_at"}, Where("created_at IS NOT NULL"), Desc("created_at"))
+	})
+
+	require.Equal(t, []Change{
+		&DropIndexChange{TableName: "users", IndexName: "users_created_at_idx"},
+		&AddIndexChange{TableName: "users", Index: target.Tables[0].Indexes[1]},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffIndexWhereLiteral(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("status", &TextType{})
+		t.Column("name", &TextType{})
+		t.Index("users_status_idx", []string{"status"}, Where(`("status" = 'Active')`))
+		t.Index("users_name_idx", []string{"name"}, Where("name <> 'x y'"))
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("status", &TextType{})
+		t.Column("name", &TextType{})
+		t.Index("users_status_idx", []string{"status"}, Where("STATUS = 'Active'"))
+		t.Index("users_name_idx", []string{"name"}, Where("name <> 'xy'"))
+	})
+	require.Equal(t, []Change{
+		&DropIndexChange{TableName: "users", IndexName: "users_name_idx"},
+		&AddIndexChange{TableName: "users", Index: target.Tables[0].Indexes[1]},
+	}, withoutHazards(Diff(source, target)))
+
+	target.Tables[0].Indexes[0].Where = "status = 'active'"
+	target.Tables[0].Indexes[1].Where = "name <> 'x y'"
+	require.Equal(t, []Change{
+		&DropIndexChange{TableName: "users", IndexName: "users_status_idx"},
+		&AddIndexChange{TableName: "users", Index: target.Tables[0].Indexes[0]},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffInvalidIndex(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("email", &TextType{})
+		t.Index("users_email_idx", []string{"email"})
+	})
+	source.Tables[0].Indexes[0].Invalid = true
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("email", &TextType{})
+		t.Index("users_email_idx", []string{"email"})
+	})
+
+	require.Equal(t, []Change{
+		&DropIndexChange{TableName: "users", IndexName: "users_email_idx"},
+		&AddIndexChange{TableName: "users", Index: target.Tables[0].Indexes[0]},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffEnums(t *testing.T) {
+	source := NewSchema()
+	source.CreateEnum("mood", "sad", "happy")
+	source.CreateEnum("status", "draft")
+
+	target := NewSchema()
+	target.CreateEnum("mood", "awful", "sad", "ok", "happy", "ecstatic")
+	target.CreateEnum("size", "small", "large")
+
+	require.Equal(t, []Change{
+		&DropEnumChange{EnumName: "status"},
+		&AddEnumValueChange{Enum: target.Enums[0], Value: "awful", Before: "sad"},
+		&AddEnumValueChange{Enum: target.Enums[0], Value: "ok", After: "sad"},
+		&AddEnumValueChange{Enum: target.Enums[0], Value: "ecstatic"},
+		&CreateEnumChange{Enum: target.Enums[1]},
+	}, withoutHazards(Diff(source, target)))
+}
+
+func TestDiffEnumRemovedValue(t *testing.T) {
+	source := NewSchema()
+	source.CreateEnum("mood", "sad", "ok", "happy")
+	source.CreateTable("people", func(t *Table) {
+		t.Column("name", &TextType{})
+		t.Column("mood", &testEnumType{"mood"}, Default("'ok'::mood"))
+	})
+
+	target := NewSchema()
+	target.CreateEnum("mood", "happy", "sad")
+	target.CreateTable("people", func(t *Table) {
+		t.Column("name", &TextType{})
+		t.Column("mood", &testEnumType{"mood"}, Default("'sad'::mood"))
+	})
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 2)
+	require.Equal(t, &AlterEnumChange{
+		Enum:    target.Enums[0],
+		Columns: []EnumColumn{{TableName: "people", ColumnName: "mood", Default: "'sad'::mood"}},
+	}, withoutUnsafe(changes[0]))
+	require.True(t, changes[0].IsUnsafe())
+	require.IsType(t, &AlterColumnChange{}, changes[1])
+}
+
+func TestDiffOrderEnums(t *testing.T) {
+	source := NewSchema()
+	source.CreateEnum("status", "draft")
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("status", &testEnumType{"status"})
+	})
+
+	target := NewSchema()
+	target.CreateTable("people", func(t *Table) {
+		t.Column("moods", &testEnumType{"mood[]"})
+	})
+	target.CreateEnum("mood", "sad", "happy")
+
+	require.Equal(t, []Change{
+		&CreateEnumChange{Enum: target.Enums[0]},
+		&DropTableChange{TableName: "posts"},
+		&DropEnumChange{EnumName: "status"},
+		&CreateTableChange{TableDef: target.Tables[0]},
+	}, withoutHazards(Diff(source, target)))
+}
+
+// testEnumType is a column type named after an enum type
+type testEnumType struct {
+	name string
+}
+
+func (t *testEnumType) SQL() string {
+	return t.name
+}
+
+// withoutUnsafe returns a copy of an AlterEnumChange that is not marked unsafe
+func withoutUnsafe(change Change) *AlterEnumChange {
+	c := *change.(*AlterEnumChange)
+	c.SetUnsafe(false)
+	c.hazards = nil
+	return &c
+}
+
+func TestDiffEnumeratedColumnValues(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("status", &testValuesType{[]string{"draft", "published"}})
+		t.Column("visibility", &testValuesType{[]string{"public", "private"}})
+		t.Column("format", &testValuesType{[]string{"html", "markdown"}})
+	})
+
+	target := NewSchema()
+	target.CreateTable("posts", func(t *Table) {
+		t.Column("status", &testValuesType{[]string{"draft", "published", "archived"}})
+		t.Column("visibility", &testValuesType{[]string{"private", "public"}})
+		t.Column("format", &testValuesType{[]string{"markdown"}})
+	})
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 3)
+	require.False(t, changes[0].IsUnsafe())
+	require.True(t, changes[1].IsUnsafe())
+	require.True(t, changes[2].IsUnsafe())
+}
+
+// testValuesType is an enumerated column type like MySQL ENUM
+type testValuesType struct {
+	values []string
+}
+
+func (t *testValuesType) SQL() string {
+	return "enum(" + strings.Join(t.values, ",") + ")"
+}
+
+func (t *testValuesType) EnumValues() []string {
+	return t.values
+}
+
+// withoutHazards clears the hazards recorded by Diff, for tests that compare
+// the changes themselves
+func withoutHazards(changes []Change) []Change {
+	for _, change := range changes {
+		reflect.ValueOf(change).Elem().FieldByName("BaseChange").Addr().Interface().(*BaseChange).hazards = nil
+	}
+	return changes
+}
+
+func TestDiffChangedAttributes(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("status", &VarcharType{Length: 20}, Comment("Account status"))
+		t.Column("name", &VarcharType{Length: 100})
+	})
+	source.CreateSequence("order_id_seq", Increment(1), Cache(1))
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("status", &VarcharType{Length: 20}, Default("'active'"), Comment("Account status"))
+		t.Column("name", &TextType{}, Nullable)
+	})
+	target.CreateSequence("order_id_seq", Increment(1), Cache(20))
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 3)
+
+	require.IsType(t, &AlterSequenceChange{}, changes[0])
+	seq := changes[0].(*AlterSequenceChange)
+	require.Equal(t, Attributes{"Cache"}, seq.Changed)
+	require.Equal(t, source.Sequences[0], seq.Source)
+	require.True(t, seq.Changed.Only("Cache", "Increment"))
+	require.False(t, seq.Changed.Only("Increment"))
+
+	require.IsType(t, &AlterColumnChange{}, changes[1])
+	status := changes[1].(*AlterColumnChange)
+	require.Equal(t, Attributes{"Default"}, status.Changed)
+	require.Equal(t, source.Tables[0].Columns[0], status.Source)
+
+	require.IsType(t, &AlterColumnChange{}, changes[2])
+	require.Equal(t, Attributes{"Type", "Nullable"}, changes[2].(*AlterColumnChange).Changed)
+}
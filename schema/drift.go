@@ -0,0 +1,329 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ObjectKind names a kind of schema object in a drift report or a Filter
+type ObjectKind string
+
+const (
+	ObjectSchema     ObjectKind = "schema"
+	ObjectExtension  ObjectKind = "extension"
+	ObjectTable      ObjectKind = "table"
+	ObjectColumn     ObjectKind = "column"
+	ObjectPrimaryKey ObjectKind = "primary_key"
+	ObjectIndex      ObjectKind = "index"
+	ObjectForeignKey ObjectKind = "foreign_key"
+	ObjectCheck      ObjectKind = "check"
+	ObjectUnique     ObjectKind = "unique_constraint"
+	ObjectEnum       ObjectKind = "enum"
+	ObjectSequence   ObjectKind = "sequence"
+	ObjectFunction   ObjectKind = "function"
+	ObjectView       ObjectKind = "view"
+	ObjectTrigger    ObjectKind = "trigger"
+	ObjectRowPolicy  ObjectKind = "row_policy"
+)
+
+// DriftObject identifies an object of a schema. Table is the qualified name
+// of the table for columns, keys, indexes, constraints, triggers and row
+// policies, and is empty otherwise.
+type DriftObject struct {
+	Kind  ObjectKind `json:"kind"`
+	Table string     `json:"table,omitempty"`
+	Name  string     `json:"name"`
+}
+
+func (o DriftObject) String() string {
+	if o.Table != "" {
+		return fmt.Sprintf("%s %s.%s", o.Kind, o.Table, o.Name)
+	}
+	return fmt.Sprintf("%s %s", o.Kind, o.Name)
+}
+
+// AttributeDrift is an attribute of an object that differs, such as the Type
+// or Nullable of a column
+type AttributeDrift struct {
+	Attribute string `json:"attribute"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+}
+
+// ObjectDrift is an object that exists in both schemas with different attributes
+type ObjectDrift struct {
+	DriftObject
+	Differences []AttributeDrift `json:"differences"`
+}
+
+func (o ObjectDrift) String() string {
+	differences := make([]string, len(o.Differences))
+	for i, d := range o.Differences {
+		differences[i] = fmt.Sprintf("%s expected %q, actual %q", d.Attribute, d.Expected, d.Actual)
+	}
+	return fmt.Sprintf("%s: %s", o.DriftObject, strings.Join(differences, ", "))
+}
+
+// DriftReport lists the differences between the schema a database is expected
+// to have and the schema it actually has
+type DriftReport struct {
+	// Extra objects exist in the database but not in the expected schema
+	Extra []DriftObject `json:"extra"`
+	// Missing objects are in the expected schema but not in the database
+	Missing []DriftObject `json:"missing"`
+	// Changed objects exist in both with different attributes
+	Changed []ObjectDrift `json:"changed"`
+}
+
+// HasDrift reports whether the database differs from the expected schema
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Extra) > 0 || len(r.Missing) > 0 || len(r.Changed) > 0
+}
+
+// String returns the report with one difference per line
+func (r *DriftReport) String() string {
+	var lines []string
+	for _, o := range r.Missing {
+		lines = append(lines, "missing "+o.String())
+	}
+	for _, o := range r.Extra {
+		lines = append(lines, "extra "+o.String())
+	}
+	for _, o := range r.Changed {
+		lines = append(lines, "changed "+o.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Drift compares the schema a database is expected to have with the schema
+// inspected from it. The objects that Diff(expected, actual) creates are
+// extra, those it drops are missing, and those it alters, or drops and
+// recreates under the same name, are changed attribute by attribute.
+func Drift(expected, actual *Schema) *DriftReport {
+	report := &DriftReport{Extra: []DriftObject{}, Missing: []DriftObject{}, Changed: []ObjectDrift{}}
+
+	// Objects dropped and added under the same name are paired as changes
+	var added, dropped []DriftObject
+	addedObjects := map[DriftObject]any{}
+	changed := func(object DriftObject, expectedObject, actualObject any) {
+		for _, o := range report.Changed {
+			if o.DriftObject == object {
+				return
+			}
+		}
+		report.Changed = append(report.Changed, ObjectDrift{
+			DriftObject: object,
+			Differences: compareAttributes(expectedObject, actualObject),
+		})
+	}
+	add := func(object DriftObject, actualObject any) {
+		added = append(added, object)
+		addedObjects[object] = actualObject
+	}
+
+	for _, change := range Diff(expected, actual) {
+		switch c := change.(type) {
+		case *CreateSchemaChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectSchema, Name: c.SchemaName})
+		case *DropSchemaChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectSchema, Name: c.SchemaName})
+		case *EnableExtensionChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectExtension, Name: c.Extension})
+		case *DisableExtensionChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectExtension, Name: c.Extension})
+		case *CreateTableChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectTable, Name: qualifiedTableName(c.TableDef)})
+		case *DropTableChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectTable, Name: qualifiedTableName(&Table{Schema: c.SchemaName, Name: c.TableName})})
+		case *AddColumnChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectColumn, Table: c.TableName, Name: c.Column.Name})
+		case *DropColumnChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectColumn, Table: c.TableName, Name: c.ColumnName})
+		case *AlterColumnChange:
+			changed(DriftObject{Kind: ObjectColumn, Table: c.TableName, Name: c.Column.Name},
+				sourceColumn(expected, actual, c.TableName, c.Column.Name), c.Column)
+		case *AddPrimaryKeyChange:
+			add(DriftObject{Kind: ObjectPrimaryKey, Table: c.TableName}, c.PrimaryKey)
+		case *DropPrimaryKeyChange:
+			dropped = append(dropped, DriftObject{Kind: ObjectPrimaryKey, Table: c.TableName})
+		case *AddIndexChange:
+			add(DriftObject{Kind: ObjectIndex, Table: c.TableName, Name: c.Index.Name}, c.Index)
+		case *DropIndexChange:
+			dropped = append(dropped, DriftObject{Kind: ObjectIndex, Table: c.TableName, Name: c.IndexName})
+		case *AddForeignKeyChange:
+			add(DriftObject{Kind: ObjectForeignKey, Table: c.TableName, Name: c.ForeignKey.Name}, c.ForeignKey)
+		case *DropForeignKeyChange:
+			dropped = append(dropped, DriftObject{Kind: ObjectForeignKey, Table: c.TableName, Name: c.FKName})
+		case *AddCheckChange:
+			add(DriftObject{Kind: ObjectCheck, Table: c.TableName, Name: c.Check.Name}, c.Check)
+		case *DropCheckChange:
+			dropped = append(dropped, DriftObject{Kind: ObjectCheck, Table: c.TableName, Name: c.CheckName})
+		case *AddUniqueConstraintChange:
+			add(DriftObject{Kind: ObjectUnique, Table: c.TableName, Name: c.Unique.Name}, c.Unique)
+		case *DropUniqueConstraintChange:
+			dropped = append(dropped, DriftObject{Kind: ObjectUnique, Table: c.TableName, Name: c.ConstraintName})
+		case *CreateEnumChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectEnum, Name: c.Enum.QualifiedName()})
+		case *DropEnumChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectEnum, Name: qualifiedName(c.SchemaName, c.EnumName)})
+		case *AddEnumValueChange:
+			changed(DriftObject{Kind: ObjectEnum, Name: c.Enum.QualifiedName()}, findEnum(expected, c.Enum.QualifiedName()), c.Enum)
+		case *AlterEnumChange:
+			changed(DriftObject{Kind: ObjectEnum, Name: c.Enum.QualifiedName()}, findEnum(expected, c.Enum.QualifiedName()), c.Enum)
+		case *CreateSequenceChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectSequence, Name: qualifiedName(c.Sequence.Schema, c.Sequence.Name)})
+		case *DropSequenceChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectSequence, Name: qualifiedName(c.SchemaName, c.SequenceName)})
+		case *AlterSequenceChange:
+			changed(DriftObject{Kind: ObjectSequence, Name: qualifiedName(c.Sequence.Schema, c.Sequence.Name)},
+				findObject(expected.Sequences, func(s *Sequence) bool { return s.Schema == c.Sequence.Schema && s.Name == c.Sequence.Name }), c.Sequence)
+		case *CreateFunctionChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectFunction, Name: qualifiedName(c.Function.Schema, c.Function.Name)})
+		case *DropFunctionChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectFunction, Name: qualifiedName(c.SchemaName, c.FunctionName)})
+		case *AlterFunctionChange:
+			changed(DriftObject{Kind: ObjectFunction, Name: qualifiedName(c.Function.Schema, c.Function.Name)},
+				findObject(expected.Functions, func(f *Function) bool { return isSameFunction(f, c.Function) }), c.Function)
+		case *CreateViewChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectView, Name: qualifiedName(c.View.Schema, c.View.Name)})
+		case *DropViewChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectView, Name: qualifiedName(c.SchemaName, c.ViewName)})
+		case *AlterViewChange:
+			changed(DriftObject{Kind: ObjectView, Name: qualifiedName(c.View.Schema, c.View.Name)},
+				findObject(expected.Views, func(v *View) bool { return v.Schema == c.View.Schema && v.Name == c.View.Name }), c.View)
+		case *CreateTriggerChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectTrigger, Table: c.Trigger.Table, Name: c.Trigger.Name})
+		case *DropTriggerChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectTrigger, Table: c.TriggerTable, Name: c.TriggerName})
+		case *AlterTriggerChange:
+			changed(DriftObject{Kind: ObjectTrigger, Table: c.Trigger.Table, Name: c.Trigger.Name},
+				findObject(expected.Triggers, func(t *Trigger) bool { return t.Table == c.Trigger.Table && t.Name == c.Trigger.Name }), c.Trigger)
+		case *CreateRowPolicyChange:
+			report.Extra = append(report.Extra, DriftObject{Kind: ObjectRowPolicy, Table: c.RowPolicy.TableName, Name: c.RowPolicy.PolicyName})
+		case *DropRowPolicyChange:
+			report.Missing = append(report.Missing, DriftObject{Kind: ObjectRowPolicy, Table: c.TableName, Name: c.PolicyName})
+		case *AlterRowPolicyChange:
+			changed(DriftObject{Kind: ObjectRowPolicy, Table: c.RowPolicy.TableName, Name: c.RowPolicy.PolicyName},
+				findObject(expected.RowPolicies, func(p *RowPolicy) bool {
+					return p.TableName == c.RowPolicy.TableName && p.PolicyName == c.RowPolicy.PolicyName
+				}), c.RowPolicy)
+		}
+	}
+
+	for _, object := range dropped {
+		actualObject, ok := addedObjects[object]
+		if !ok {
+			report.Missing = append(report.Missing, object)
+			continue
+		}
+		delete(addedObjects, object)
+		changed(object, findTableObject(expected, actual, object), actualObject)
+	}
+	for _, object := range added {
+		if _, ok := addedObjects[object]; ok {
+			report.Extra = append(report.Extra, object)
+		}
+	}
+
+	return report
+}
+
+// qualifiedName prefixes a name with its schema, if any
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// findObject returns the first object that matches
+func findObject[T any](objects []*T, match func(*T) bool) *T {
+	for _, object := range objects {
+		if match(object) {
+			return object
+		}
+	}
+	return nil
+}
+
+// findTableObject returns the key, index or constraint of the expected schema
+// that a drift object refers to
+func findTableObject(expected, actual *Schema, object DriftObject) any {
+	var table *Table
+	for _, t := range actual.Tables {
+		if qualifiedTableName(t) == object.Table {
+			table = findSourceTable(expected, actual, t)
+		}
+	}
+	if table == nil {
+		return nil
+	}
+
+	switch object.Kind {
+	case ObjectPrimaryKey:
+		return table.PrimaryKey
+	case ObjectIndex:
+		return findIndex(table, object.Name)
+	case ObjectForeignKey:
+		return findObject(table.ForeignKeys, func(fk *ForeignKey) bool { return fk.Name == object.Name })
+	case ObjectCheck:
+		return findObject(table.Checks, func(c *CheckConstraint) bool { return c.Name == object.Name })
+	case ObjectUnique:
+		return findObject(table.Uniques, func(u *UniqueConstraint) bool { return u.Name == object.Name })
+	}
+	return nil
+}
+
+// compareAttributes returns the exported fields of two objects of the same
+// type that differ, skipping names and rename hints
+func compareAttributes(expected, actual any) []AttributeDrift {
+	e, a := reflect.ValueOf(expected), reflect.ValueOf(actual)
+	if !e.IsValid() || !a.IsValid() || e.Type() != a.Type() || e.Kind() != reflect.Ptr || e.IsNil() || a.IsNil() {
+		return nil
+	}
+	e, a = e.Elem(), a.Elem()
+
+	var differences []AttributeDrift
+	for i := 0; i < e.NumField(); i++ {
+		field := e.Type().Field(i)
+		if !field.IsExported() || field.Name == "Name" || field.Name == "RenamedFrom" || field.Name == "PolicyName" {
+			continue
+		}
+		expectedValue, actualValue := formatAttribute(e.Field(i)), formatAttribute(a.Field(i))
+		if expectedValue != actualValue {
+			differences = append(differences, AttributeDrift{Attribute: field.Name, Expected: expectedValue, Actual: actualValue})
+		}
+	}
+	return differences
+}
+
+// formatAttribute formats a field value for a drift report, column types as their SQL
+func formatAttribute(v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if columnType, ok := v.Interface().(ColumnType); ok {
+		return columnType.SQL()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatAttribute(v.Index(i))
+		}
+		return strings.Join(items, ", ")
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		return formatAttribute(v.Elem())
+	case reflect.Struct:
+		return fmt.Sprintf("%+v", v.Interface())
+	}
+	return fmt.Sprint(v.Interface())
+}
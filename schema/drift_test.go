@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrift(t *testing.T) {
+	expected := NewSchema()
+	expected.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &TextType{})
+		t.Column("name", &TextType{})
+		t.SetPrimaryKey("users_pkey", []string{"id"})
+		t.Index("users_email_idx", []string{"email"}, Unique)
+	})
+	expected.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"}, OnDelete("CASCADE"))
+	})
+	expected.CreateTable("comments", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	actual := NewSchema()
+	actual.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &VarcharType{Length: 255}, Nullable, Default("''"))
+		t.Column("nickname", &TextType{}, Nullable)
+		t.SetPrimaryKey("users_pkey", []string{"id"})
+		t.Index("users_email_idx", []string{"email", "id"})
+		t.Index("users_nickname_idx", []string{"nickname"})
+	})
+	actual.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"}, OnDelete("SET NULL"))
+	})
+	actual.CreateTable("audit_log", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	report := Drift(expected, actual)
+	require.True(t, report.HasDrift())
+	require.Equal(t, []DriftObject{
+		{Kind: ObjectTable, Name: "comments"},
+		{Kind: ObjectColumn, Table: "users", Name: "name"},
+	}, report.Missing)
+	require.Equal(t, []DriftObject{
+		{Kind: ObjectColumn, Table: "users", Name: "nickname"},
+		{Kind: ObjectTable, Name: "audit_log"},
+		{Kind: ObjectIndex, Table: "users", Name: "users_nickname_idx"},
+	}, report.Extra)
+	require.Equal(t, []ObjectDrift{
+		{
+			DriftObject: DriftObject{Kind: ObjectColumn, Table: "users", Name: "email"},
+			Differences: []AttributeDrift{
+				{Attribute: "Type", Expected: "text", Actual: "varchar(255)"},
+				{Attribute: "Nullable", Expected: "false", Actual: "true"},
+				{Attribute: "Default", Expected: "", Actual: "''"},
+			},
+		},
+		{
+			DriftObject: DriftObject{Kind: ObjectIndex, Table: "users", Name: "users_email_idx"},
+			Differences: []AttributeDrift{
+				{Attribute: "Columns", Expected: "email", Actual: "email, id"},
+				{Attribute: "Unique", Expected: "true", Actual: "false"},
+			},
+		},
+		{
+			DriftObject: DriftObject{Kind: ObjectForeignKey, Table: "posts", Name: "posts_user_id_fkey"},
+			Differences: []AttributeDrift{
+				{Attribute: "OnDelete", Expected: "CASCADE", Actual: "SET NULL"},
+			},
+		},
+	}, report.Changed)
+}
+
+func TestDriftNone(t *testing.T) {
+	build := func() *Schema {
+		s := NewSchema()
+		s.CreateTable("users", func(t *Table) {
+			t.Column("id", &IntegerType{})
+			t.Index("users_id_idx", []string{"id"})
+		})
+		return s
+	}
+
+	report := Drift(build(), build())
+	require.False(t, report.HasDrift())
+	require.Empty(t, report.String())
+}
+
+func TestDriftString(t *testing.T) {
+	expected := NewSchema()
+	expected.CreateEnum("mood", "sad", "happy")
+	expected.CreateTable("users", func(t *Table) {
+		t.Column("name", &TextType{})
+	})
+
+	actual := NewSchema()
+	actual.CreateEnum("mood", "sad", "ok", "happy")
+	actual.CreateTable("users", func(t *Table) {
+		t.Column("name", &TextType{}, Nullable)
+		t.Column("bio", &TextType{}, Nullable)
+	})
+	actual.CreateView("active_users", "SELECT * FROM users")
+
+	require.Equal(t, `extra column users.bio
+extra view active_users
+changed enum mood: Values expected "sad, happy", actual "sad, ok, happy"
+changed column users.name: Nullable expected "false", actual "true"`, Drift(expected, actual).String())
+}
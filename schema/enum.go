@@ -0,0 +1,42 @@
+package schema
+
+import "strings"
+
+// Enum represents a PostgreSQL enum type
+type Enum struct {
+	Schema string   `json:"schema,omitempty"`
+	Name   string   `json:"name"`
+	Values []string `json:"values,omitempty"`
+}
+
+// CreateEnum adds a new enum type with the given values to the schema
+func (s *Schema) CreateEnum(name string, values ...string) *Enum {
+	enum := &Enum{
+		Schema: s.Name,
+		Name:   name,
+		Values: values,
+	}
+
+	s.Enums = append(s.Enums, enum)
+	return enum
+}
+
+// QualifiedName returns the name of the enum type, qualified with its schema
+// unless it lives in the default schema
+func (e *Enum) QualifiedName() string {
+	if e.Schema == "" || e.Schema == "public" {
+		return e.Name
+	}
+	return e.Schema + "." + e.Name
+}
+
+// isTypeOf reports whether the enum is the type of a column
+func (e *Enum) isTypeOf(col *Column) bool {
+	return e.hasTypeName(strings.ReplaceAll(col.TypeSQL(), `"`, ""))
+}
+
+// hasTypeName reports whether a type name, which may be qualified with a
+// schema name, refers to the enum
+func (e *Enum) hasTypeName(name string) bool {
+	return name == e.QualifiedName() || name == e.Schema+"."+e.Name
+}
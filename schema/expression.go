@@ -0,0 +1,230 @@
+package schema
+
+import (
+	"slices"
+	"strings"
+)
+
+// exprItem is a token of an SQL expression, or a parenthesized group of
+// items when token is "("
+type exprItem struct {
+	token string
+	group []exprItem
+}
+
+// comparisonOperators are the operators that bind tighter than NOT and looser
+// than arithmetic
+var comparisonOperators = []string{"is", "like", "ilike", "in", "between", "similar"}
+
+// isSameExpression compares SQL expressions, ignoring the case, whitespace and
+// quoting outside of string literals, and the parentheses that databases add
+// when they report them as long as they do not change the grouping
+func isSameExpression(a, b string) bool {
+	return slices.Equal(normalizeExpression(a), normalizeExpression(b))
+}
+
+// normalizeExpression returns the tokens of an expression without the
+// parentheses that can be removed without changing its meaning
+func normalizeExpression(expr string) []string {
+	tokens := tokenizeExpression(expr)
+	depth := 0
+	for _, token := range tokens {
+		if token == "(" {
+			depth++
+		} else if token == ")" {
+			depth--
+		}
+		if depth < 0 {
+			return tokens
+		}
+	}
+	if depth > 0 {
+		return tokens
+	}
+	items, _ := parseExpression(tokens)
+	return flattenExpression(simplifyExpression(items), nil)
+}
+
+// tokenizeExpression splits an expression into lowercased words and
+// operators, keeping string literals as they are and unquoting identifiers
+func tokenizeExpression(expr string) []string {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		j := i + 1
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i = j
+			continue
+		case c == '\'':
+			for j < len(expr) && (expr[j] != '\'' || j+1 < len(expr) && expr[j+1] == '\'') {
+				if expr[j] == '\'' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(expr))
+			tokens = append(tokens, expr[i:j])
+			i = j
+			continue
+		case c == '"' || c == '`':
+			for j < len(expr) && expr[j] != c {
+				j++
+			}
+			tokens = append(tokens, strings.ToLower(expr[i+1:j]))
+			i = min(j+1, len(expr))
+			continue
+		case isWordByte(c):
+			for j < len(expr) && isWordByte(expr[j]) {
+				j++
+			}
+		case strings.IndexByte("<>=!~", c) >= 0:
+			for j < len(expr) && strings.IndexByte("<>=!~*", expr[j]) >= 0 {
+				j++
+			}
+		case (c == ':' || c == '|') && j < len(expr) && expr[j] == c:
+			j++
+		}
+		tokens = append(tokens, strings.ToLower(expr[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+// isWordByte reports whether a byte belongs to an identifier, keyword or
+// number
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '$' || c >= 0x80
+}
+
+// parseExpression groups tokens by their parentheses, up to the parenthesis
+// that closes the current group
+func parseExpression(tokens []string) (items []exprItem, rest []string) {
+	for len(tokens) > 0 {
+		token := tokens[0]
+		tokens = tokens[1:]
+		switch token {
+		case "(":
+			var group []exprItem
+			group, tokens = parseExpression(tokens)
+			items = append(items, exprItem{token: "(", group: group})
+		case ")":
+			return items, tokens
+		default:
+			items = append(items, exprItem{token: token})
+		}
+	}
+	return items, nil
+}
+
+// simplifyExpression removes the groups whose operators bind tighter than
+// the operators around them
+func simplifyExpression(items []exprItem) []exprItem {
+	var result []exprItem
+	for i, item := range items {
+		if item.token != "(" {
+			result = append(result, item)
+			continue
+		}
+		item.group = simplifyExpression(item.group)
+
+		inner := groupPrecedence(item.group)
+		left, right := 0, 0
+		if i > 0 {
+			var prev *exprItem
+			if i > 1 {
+				prev = &items[i-2]
+			}
+			left = neighbourPrecedence(prev, items[i-1])
+		}
+		if i+1 < len(items) {
+			right = neighbourPrecedence(&item, items[i+1])
+		}
+		if left >= 0 && right >= 0 && inner > left && inner >= right {
+			result = append(result, item.group...)
+		} else {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// groupPrecedence returns the precedence of the loosest operator at the top
+// level of a group, or -1 for a list that must keep its parentheses
+func groupPrecedence(items []exprItem) int {
+	precedence := 100
+	for i, item := range items {
+		if item.token == "," {
+			return -1
+		}
+		var prev *exprItem
+		if i > 0 {
+			prev = &items[i-1]
+		}
+		if p := operatorPrecedence(prev, item); p > 0 {
+			precedence = min(precedence, p)
+		}
+	}
+	return precedence
+}
+
+// neighbourPrecedence returns the precedence of the operator next to a group,
+// 0 for a separator, or -1 for a function name or keyword that the group
+// belongs to
+func neighbourPrecedence(prev *exprItem, item exprItem) int {
+	if item.token == "," {
+		return 0
+	}
+	if p := operatorPrecedence(prev, item); p > 0 {
+		return p
+	}
+	return -1
+}
+
+// operatorPrecedence returns the precedence of a token used as an operator
+// after prev, or 0 if it is an operand
+func operatorPrecedence(prev *exprItem, item exprItem) int {
+	operand := prev != nil && (prev.token == "(" || operatorPrecedence(nil, *prev) == 0 && prev.token != ",")
+	switch token := item.token; {
+	case token == "or":
+		return 1
+	case token == "and":
+		return 2
+	case token == "not":
+		if prev != nil && prev.token == "is" {
+			return 0
+		}
+		if operand {
+			return 4
+		}
+		return 3
+	case slices.Contains(comparisonOperators, token) || strings.IndexByte("<>=!~", token[0]) >= 0:
+		return 4
+	case token == "+" || token == "-" || token == "||":
+		if !operand {
+			return 7
+		}
+		return 5
+	case token == "*" || token == "/" || token == "%":
+		return 6
+	case token == "::":
+		return 8
+	}
+	return 0
+}
+
+// flattenExpression appends the tokens of items to tokens, with the
+// parentheses of the groups that are left
+func flattenExpression(items []exprItem, tokens []string) []string {
+	for _, item := range items {
+		if item.token == "(" {
+			tokens = append(tokens, "(")
+			tokens = flattenExpression(item.group, tokens)
+			tokens = append(tokens, ")")
+		} else {
+			tokens = append(tokens, item.token)
+		}
+	}
+	return tokens
+}
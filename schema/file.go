@@ -0,0 +1,426 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the encoding of a schema file
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+var (
+	columnTypesMu   sync.RWMutex
+	columnTypes     = map[string]reflect.Type{}
+	columnTypeNames = map[reflect.Type]string{}
+)
+
+func init() {
+	RegisterColumnType("text", &TextType{})
+	RegisterColumnType("integer", &IntegerType{})
+	RegisterColumnType("bigint", &BigIntType{})
+	RegisterColumnType("smallint", &SmallIntType{})
+	RegisterColumnType("boolean", &BooleanType{})
+	RegisterColumnType("float", &FloatType{})
+	RegisterColumnType("decimal", &DecimalType{})
+	RegisterColumnType("varchar", &VarcharType{})
+	RegisterColumnType("timestamp", &TimestampType{})
+	RegisterColumnType("date", &DateType{})
+	RegisterColumnType("time", &TimeType{})
+	RegisterColumnType("uuid", &UUIDType{})
+	RegisterColumnType("blob", &BlobType{})
+}
+
+// RegisterColumnType makes a column type available to schema files under
+// name. Dialects register their own types with the dialect as prefix, such
+// as "postgresql.jsonb". The type must be a pointer to a struct whose fields
+// are strings, booleans, integers, string slices or column types. If
+// RegisterColumnType is called twice with the same name or type, it panics.
+func RegisterColumnType(name string, columnType ColumnType) {
+	columnTypesMu.Lock()
+	defer columnTypesMu.Unlock()
+
+	t := reflect.TypeOf(columnType)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		panic("schema: RegisterColumnType type is not a pointer to a struct")
+	}
+	if _, dup := columnTypes[name]; dup {
+		panic("schema: RegisterColumnType called twice for " + name)
+	}
+	if _, dup := columnTypeNames[t]; dup {
+		panic("schema: RegisterColumnType called twice for type " + t.String())
+	}
+	columnTypes[name] = t
+	columnTypeNames[t] = name
+}
+
+// Marshal encodes s as a schema file. Objects are written with the fields of
+// their Go structs in snake case, leaving out empty fields. A column type is
+// written as its registered name, or as a mapping of its name under "kind"
+// and its fields when any is set:
+//
+//	columns:
+//	  - name: email
+//	    type:
+//	      kind: varchar
+//	      length: 255
+//	  - name: data
+//	    type: postgresql.jsonb
+//	    nullable: true
+func Marshal(s *Schema, format Format) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatYAML:
+		// JSON is YAML, so the document is parsed back as YAML to keep the
+		// order of the fields and written in block style
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		blockStyle(&node)
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("schema: unknown format %q", format)
+}
+
+// Unmarshal decodes a schema file written by Marshal or by hand. Fields that
+// are not part of the format are rejected, and fields left out take the
+// defaults of the Create methods. Column types of a dialect are only known
+// once its package is imported.
+func Unmarshal(data []byte, format Format) (*Schema, error) {
+	switch format {
+	case FormatJSON:
+	case FormatYAML:
+		var document any
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("schema: unknown format %q", format)
+	}
+
+	s := NewSchema()
+	if err := decodeStrict(data, s); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	return s, nil
+}
+
+// blockStyle clears the flow and quoting styles of a YAML document parsed
+// from JSON, so that the encoder picks the plain or literal style where it
+// can. Strings starting with whitespace stay quoted, since block scalars
+// lose leading line breaks.
+func blockStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || strings.TrimLeft(node.Value, " \t\n") == node.Value {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// decodeStrict decodes JSON data into v, rejecting unknown fields
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// UnmarshalJSON decodes a table, with empty columns and indexes when the
+// document has none, as CreateTable creates them
+func (t *Table) UnmarshalJSON(data []byte) error {
+	type table Table
+	decoded := table{Columns: []*Column{}, Indexes: []*Index{}}
+	if err := decodeStrict(data, &decoded); err != nil {
+		return err
+	}
+	*t = Table(decoded)
+	return nil
+}
+
+// UnmarshalJSON decodes a function, with the arguments, language,
+// volatility, security and cost of CreateFunction when the document has none
+func (f *Function) UnmarshalJSON(data []byte) error {
+	type function Function
+	decoded := function{
+		Arguments:  []FunctionArg{},
+		Language:   "plpgsql",
+		Volatility: "VOLATILE",
+		Security:   "INVOKER",
+		Cost:       100,
+	}
+	if err := decodeStrict(data, &decoded); err != nil {
+		return err
+	}
+	*f = Function(decoded)
+	return nil
+}
+
+// UnmarshalJSON decodes a sequence, with the options of CreateSequence when
+// the document has none
+func (s *Sequence) UnmarshalJSON(data []byte) error {
+	type sequence Sequence
+	decoded := sequence{
+		Start:     1,
+		Increment: 1,
+		MinValue:  1,
+		MaxValue:  9223372036854775807,
+		Cache:     1,
+	}
+	if err := decodeStrict(data, &decoded); err != nil {
+		return err
+	}
+	*s = Sequence(decoded)
+	return nil
+}
+
+// UnmarshalJSON decodes a trigger, with empty arguments when the document
+// has none, as CreateTrigger creates them
+func (t *Trigger) UnmarshalJSON(data []byte) error {
+	type trigger Trigger
+	decoded := trigger{Arguments: []string{}}
+	if err := decodeStrict(data, &decoded); err != nil {
+		return err
+	}
+	*t = Trigger(decoded)
+	return nil
+}
+
+// columnJSON is the JSON representation of a column
+type columnJSON struct {
+	Name          string          `json:"name"`
+	Type          json.RawMessage `json:"type"`
+	Nullable      bool            `json:"nullable,omitempty"`
+	Default       string          `json:"default,omitempty"`
+	Comment       string          `json:"comment,omitempty"`
+	AutoIncrement bool            `json:"auto_increment,omitempty"`
+	RenamedFrom   string          `json:"renamed_from,omitempty"`
+}
+
+// MarshalJSON encodes a column with its type as described by Marshal
+func (c Column) MarshalJSON() ([]byte, error) {
+	typeData, err := encodeColumnType(c.Type)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", c.Name, err)
+	}
+	return json.Marshal(columnJSON{
+		Name:          c.Name,
+		Type:          typeData,
+		Nullable:      c.Nullable,
+		Default:       c.Default,
+		Comment:       c.Comment,
+		AutoIncrement: c.AutoIncrement,
+		RenamedFrom:   c.RenamedFrom,
+	})
+}
+
+// UnmarshalJSON decodes a column encoded by MarshalJSON
+func (c *Column) UnmarshalJSON(data []byte) error {
+	var decoded columnJSON
+	if err := decodeStrict(data, &decoded); err != nil {
+		return err
+	}
+
+	var typeValue any
+	if err := json.Unmarshal(decoded.Type, &typeValue); err != nil {
+		return fmt.Errorf("column %s: %w", decoded.Name, err)
+	}
+	columnType, err := decodeColumnType(typeValue)
+	if err != nil {
+		return fmt.Errorf("column %s: %w", decoded.Name, err)
+	}
+
+	*c = Column{
+		Name:          decoded.Name,
+		Type:          columnType,
+		Nullable:      decoded.Nullable,
+		Default:       decoded.Default,
+		Comment:       decoded.Comment,
+		AutoIncrement: decoded.AutoIncrement,
+		RenamedFrom:   decoded.RenamedFrom,
+	}
+	return nil
+}
+
+// encodeColumnType returns the JSON of the name of a registered column type,
+// or of an object of its name under "kind" followed by its fields that are set
+func encodeColumnType(columnType ColumnType) (json.RawMessage, error) {
+	if columnType == nil {
+		return nil, fmt.Errorf("missing column type")
+	}
+
+	columnTypesMu.RLock()
+	name, ok := columnTypeNames[reflect.TypeOf(columnType)]
+	columnTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("column type %T is not registered", columnType)
+	}
+
+	var fields []string
+	v := reflect.ValueOf(columnType).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if !field.IsExported() || value.IsZero() {
+			continue
+		}
+
+		var data []byte
+		var err error
+		if nested, ok := value.Interface().(ColumnType); ok && field.Type.Kind() == reflect.Interface {
+			data, err = encodeColumnType(nested)
+		} else {
+			data, err = json.Marshal(value.Interface())
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, fmt.Sprintf("%q:%s", snakeCase(field.Name), data))
+	}
+
+	kind, err := json.Marshal(name)
+	if err != nil || len(fields) == 0 {
+		return kind, err
+	}
+	return json.RawMessage(fmt.Sprintf(`{"kind":%s,%s}`, kind, strings.Join(fields, ","))), nil
+}
+
+// decodeColumnType returns the column type encoded by encodeColumnType
+func decodeColumnType(value any) (ColumnType, error) {
+	var name string
+	var fields map[string]any
+	switch v := value.(type) {
+	case string:
+		name = v
+	case map[string]any:
+		name, _ = v["kind"].(string)
+		fields = v
+	default:
+		return nil, fmt.Errorf("invalid column type %v", value)
+	}
+
+	columnTypesMu.RLock()
+	t, ok := columnTypes[name]
+	columnTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown column type %q", name)
+	}
+
+	columnType := reflect.New(t.Elem())
+	v := columnType.Elem()
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "kind" {
+			continue
+		}
+		field, ok := findField(v, key)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q of column type %s", key, name)
+		}
+		if err := setField(field, fields[key]); err != nil {
+			return nil, fmt.Errorf("field %q of column type %s: %w", key, name, err)
+		}
+	}
+	return columnType.Interface().(ColumnType), nil
+}
+
+// findField returns the exported field of v named key in snake case
+func findField(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.IsExported() && snakeCase(field.Name) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// setField sets a field of a column type to a value decoded from JSON
+func setField(field reflect.Value, value any) error {
+	switch field.Kind() {
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %v", value)
+		}
+		field.SetString(s)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %v", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("expected an integer, got %v", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("expected a list of strings, got %v", value)
+		}
+		values := make([]string, len(items))
+		for i, item := range items {
+			if values[i], ok = item.(string); !ok {
+				return fmt.Errorf("expected a list of strings, got %v", value)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	case reflect.Interface:
+		columnType, err := decodeColumnType(value)
+		if err != nil {
+			return err
+		}
+		if !reflect.TypeOf(columnType).AssignableTo(field.Type()) {
+			return fmt.Errorf("%s is not assignable to %s", reflect.TypeOf(columnType), field.Type())
+		}
+		field.Set(reflect.ValueOf(columnType))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+var snakeCaseBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])|([A-Z])([A-Z][a-z])`)
+
+// snakeCase converts a Go field name such as WithTimeZone to with_time_zone
+func snakeCase(name string) string {
+	return strings.ToLower(snakeCaseBoundary.ReplaceAllString(name, "${1}${3}_${2}${4}"))
+}
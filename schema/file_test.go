@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fileTestSchema() *Schema {
+	s := NewSchema()
+	s.Namespaces = []string{"audit"}
+	s.EnableExtension("pgcrypto")
+	s.CreateEnum("status", "active", "inactive")
+	s.CreateSequence("order_id_seq", Start(100), MinValue(0), Cache(10))
+	s.CreateTable("users", func(t *Table) {
+		t.Column("id", &BigIntType{}, func(c *Column) { c.AutoIncrement = true })
+		t.Column("email", &VarcharType{Length: 255}, Comment("Login email"))
+		t.Column("balance", &DecimalType{Precision: 10, Scale: 2}, Default("0"))
+		t.Column("created_at", &TimestampType{WithTimeZone: true}, Nullable)
+		t.SetPrimaryKey("users_pkey", []string{"id"})
+		t.Index("users_email_idx", []string{"(lower(email))"}, Unique, Where("deleted_at IS NULL"))
+		t.Index("users_created_at_idx", []string{"created_at"}, Desc("created_at"))
+		t.Check("users_balance_check", "balance >= 0")
+		t.Unique("users_email_key", "email")
+	})
+	s.CreateTable("posts", func(t *Table) {
+		t.Schema = "audit"
+		t.Column("user_id", &BigIntType{})
+		t.Column("body", &TextType{}, RenamedFrom("content"))
+		t.ForeignKey("posts_user_id_fkey", []string{"user_id"}, "users", []string{"id"}, OnDelete("CASCADE"))
+	})
+	s.CreateFunction("touch", "trigger", "\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n", Language("plpgsql"))
+	s.CreateTrigger("users_touch", "users", "touch")
+	s.CreateView("active_users", "SELECT * FROM users WHERE balance > 0", ViewOptions("security_barrier=true"))
+	s.CreateRowPolicy("users", "users_owner", RowPolicyUsingExpr("id = current_user_id()"))
+	return s
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	s := fileTestSchema()
+
+	for _, format := range []Format{FormatJSON, FormatYAML} {
+		data, err := Marshal(s, format)
+		require.NoError(t, err)
+
+		decoded, err := Unmarshal(data, format)
+		require.NoError(t, err)
+		require.Equal(t, s, decoded, string(format))
+		require.Empty(t, Diff(s, decoded), string(format))
+
+		again, err := Marshal(decoded, format)
+		require.NoError(t, err)
+		require.Equal(t, string(data), string(again), string(format))
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	s := NewSchema()
+	s.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &VarcharType{Length: 255}, Nullable, Default("'0'"))
+		t.SetPrimaryKey("users_pkey", []string{"id"})
+	})
+
+	data, err := Marshal(s, FormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, `tables:
+  - name: users
+    columns:
+      - name: id
+        type: integer
+      - name: email
+        type:
+          kind: varchar
+          length: 255
+        nullable: true
+        default: '''0'''
+    primary_key:
+      name: users_pkey
+      columns:
+        - id
+`, string(data))
+}
+
+func TestUnmarshal(t *testing.T) {
+	s, err := Unmarshal([]byte(`
+tables:
+  - name: users
+    columns:
+      - name: id
+        type: bigint
+        auto_increment: true
+      - name: created_at
+        type: {kind: timestamp, with_time_zone: true}
+`), FormatYAML)
+	require.NoError(t, err)
+
+	expected := NewSchema()
+	expected.CreateTable("users", func(t *Table) {
+		t.Column("id", &BigIntType{}, func(c *Column) { c.AutoIncrement = true })
+		t.Column("created_at", &TimestampType{WithTimeZone: true})
+	})
+	require.Equal(t, expected, s)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	_, err := Unmarshal([]byte(`{"tables": [{"name": "users", "columns": [{"name": "id", "type": "money"}]}]}`), FormatJSON)
+	require.ErrorContains(t, err, `column id: unknown column type "money"`)
+
+	_, err = Unmarshal([]byte(`{"tables": [{"name": "users", "colums": []}]}`), FormatJSON)
+	require.ErrorContains(t, err, `unknown field "colums"`)
+
+	_, err = Unmarshal([]byte(`{"tables": [{"name": "users", "columns": [{"name": "id", "type": {"kind": "varchar", "size": 10}}]}]}`), FormatJSON)
+	require.ErrorContains(t, err, `unknown field "size" of column type varchar`)
+
+	_, err = Marshal(NewSchema(), Format("toml"))
+	require.ErrorContains(t, err, `unknown format "toml"`)
+}
+
+func TestSnakeCase(t *testing.T) {
+	require.Equal(t, "length", snakeCase("Length"))
+	require.Equal(t, "with_time_zone", snakeCase("WithTimeZone"))
+	require.Equal(t, "element_type", snakeCase("ElementType"))
+	require.Equal(t, "ref_table", snakeCase("RefTable"))
+}
@@ -0,0 +1,282 @@
+package schema
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Filter selects the objects of a schema that Diff compares and that dialects
+// inspect, so that tables and other objects owned by other tools are left
+// alone. An object is kept unless an IgnoreObjects rule matches it, and, when
+// IncludeObjects rules apply to its kind, unless one of them matches it.
+// Objects in a database schema that is not kept are not kept either.
+type Filter struct {
+	rules     []filterRule
+	neverDrop bool
+}
+
+type filterRule struct {
+	include bool
+	kinds   []ObjectKind
+	pattern *regexp.Regexp
+}
+
+// FilterOption configures a Filter
+type FilterOption func(*Filter)
+
+// defaultFilterKinds are the kinds a rule applies to when it lists none
+var defaultFilterKinds = []ObjectKind{
+	ObjectTable, ObjectView, ObjectFunction, ObjectTrigger, ObjectSequence, ObjectRowPolicy, ObjectEnum,
+}
+
+// NewFilter creates a filter from ignore and include rules
+func NewFilter(options ...FilterOption) *Filter {
+	f := &Filter{}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// IgnoreObjects leaves out the objects whose name matches the glob pattern, in
+// which * matches any characters and ? a single character. A pattern matches
+// either the name or the name qualified by its database schema, such as
+// "audit.events". The rule applies to objects of the given kinds, or to
+// tables, views, functions, triggers, sequences, row policies and enums when
+// no kind is given. Database schemas and extensions are only filtered by
+// rules for ObjectSchema and ObjectExtension.
+func IgnoreObjects(pattern string, kinds ...ObjectKind) FilterOption {
+	return IgnoreObjectsRegexp(globRegexp(pattern), kinds...)
+}
+
+// IgnoreObjectsRegexp is like IgnoreObjects with a regular expression
+func IgnoreObjectsRegexp(pattern *regexp.Regexp, kinds ...ObjectKind) FilterOption {
+	return func(f *Filter) {
+		f.rules = append(f.rules, filterRule{kinds: kinds, pattern: pattern})
+	}
+}
+
+// IncludeObjects keeps only the objects whose name matches one of the include
+// rules of their kind, with patterns and kinds as for IgnoreObjects
+func IncludeObjects(pattern string, kinds ...ObjectKind) FilterOption {
+	return IncludeObjectsRegexp(globRegexp(pattern), kinds...)
+}
+
+// IncludeObjectsRegexp is like IncludeObjects with a regular expression
+func IncludeObjectsRegexp(pattern *regexp.Regexp, kinds ...ObjectKind) FilterOption {
+	return func(f *Filter) {
+		f.rules = append(f.rules, filterRule{include: true, kinds: kinds, pattern: pattern})
+	}
+}
+
+// NeverDrop makes Diff leave out the changes that drop objects, such as
+// DropTableChange or DropColumnChange, except those that drop an index,
+// constraint or function to recreate it with a new definition
+func NeverDrop() FilterOption {
+	return func(f *Filter) {
+		f.neverDrop = true
+	}
+}
+
+// WithFilter makes Diff compare only the objects of both schemas that filter
+// keeps
+func WithFilter(filter *Filter) DiffOption {
+	return func(config *diffConfig) {
+		config.filter = filter
+	}
+}
+
+// globRegexp converts a glob pattern to an anchored regular expression
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func (r filterRule) appliesTo(kind ObjectKind) bool {
+	if len(r.kinds) == 0 {
+		return slices.Contains(defaultFilterKinds, kind)
+	}
+	return slices.Contains(r.kinds, kind)
+}
+
+// Match reports whether the filter keeps an object of kind named name in the
+// database schema schemaName. A nil filter keeps every object.
+func (f *Filter) Match(kind ObjectKind, schemaName, name string) bool {
+	if f == nil {
+		return true
+	}
+	if kind != ObjectSchema && schemaName != "" && !f.Match(ObjectSchema, "", schemaName) {
+		return false
+	}
+
+	qualified := qualifiedName(schemaName, name)
+	hasInclude, included := false, false
+	for _, rule := range f.rules {
+		if !rule.appliesTo(kind) {
+			continue
+		}
+		matched := rule.pattern.MatchString(name) || rule.pattern.MatchString(qualified)
+		if !rule.include && matched {
+			return false
+		}
+		if rule.include {
+			hasInclude = true
+			included = included || matched
+		}
+	}
+	return !hasInclude || included
+}
+
+// Apply returns a copy of s without the objects the filter leaves out.
+// Triggers and row policies of tables left out are left out too. A nil
+// filter returns s.
+func (f *Filter) Apply(s *Schema) *Schema {
+	if f == nil {
+		return s
+	}
+
+	schemaOf := func(name string) string {
+		if name == "" {
+			return s.Name
+		}
+		return name
+	}
+	tableKept := func(schemaName, name string) bool {
+		return f.Match(ObjectTable, schemaOf(schemaName), name)
+	}
+
+	filtered := *s
+	filtered.Namespaces = filterObjects(s.Namespaces, func(name string) bool {
+		return f.Match(ObjectSchema, "", name)
+	})
+	filtered.Extensions = filterObjects(s.Extensions, func(name string) bool {
+		return f.Match(ObjectExtension, "", name)
+	})
+	filtered.Tables = filterObjects(s.Tables, func(t *Table) bool {
+		return f.Match(ObjectTable, schemaOf(t.Schema), t.Name)
+	})
+	filtered.Enums = filterObjects(s.Enums, func(e *Enum) bool {
+		return f.Match(ObjectEnum, schemaOf(e.Schema), e.Name)
+	})
+	filtered.Sequences = filterObjects(s.Sequences, func(seq *Sequence) bool {
+		return f.Match(ObjectSequence, schemaOf(seq.Schema), seq.Name)
+	})
+	filtered.Functions = filterObjects(s.Functions, func(fn *Function) bool {
+		return f.Match(ObjectFunction, schemaOf(fn.Schema), fn.Name)
+	})
+	filtered.Views = filterObjects(s.Views, func(v *View) bool {
+		return f.Match(ObjectView, schemaOf(v.Schema), v.Name)
+	})
+	filtered.Triggers = filterObjects(s.Triggers, func(t *Trigger) bool {
+		return f.Match(ObjectTrigger, schemaOf(t.Schema), t.Name) && tableKept(t.Schema, t.Table)
+	})
+	filtered.RowPolicies = filterObjects(s.RowPolicies, func(p *RowPolicy) bool {
+		return f.Match(ObjectRowPolicy, schemaOf(p.Schema), p.PolicyName) && tableKept(p.Schema, p.TableName)
+	})
+	return &filtered
+}
+
+// filterObjects returns the objects that keep reports true for, or objects
+// itself when it is nil
+func filterObjects[T any](objects []T, keep func(T) bool) []T {
+	if objects == nil {
+		return nil
+	}
+	result := make([]T, 0, len(objects))
+	for _, object := range objects {
+		if keep(object) {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+// withoutDrops removes the changes that drop objects, unless another change
+// adds an object of the same kind and name back
+func withoutDrops(changes []Change) []Change {
+	recreated := map[DriftObject]bool{}
+	for _, change := range changes {
+		if object, ok := addedObject(change); ok {
+			recreated[object] = true
+		}
+	}
+
+	result := []Change{}
+	for _, change := range changes {
+		if object, ok := droppedObject(change); ok && !recreated[object] {
+			continue
+		}
+		result = append(result, change)
+	}
+	return result
+}
+
+// droppedObject returns the object a change drops
+func droppedObject(change Change) (DriftObject, bool) {
+	switch c := change.(type) {
+	case *DropSchemaChange:
+		return DriftObject{Kind: ObjectSchema, Name: c.SchemaName}, true
+	case *DisableExtensionChange:
+		return DriftObject{Kind: ObjectExtension, Name: c.Extension}, true
+	case *DropTableChange:
+		return DriftObject{Kind: ObjectTable, Name: qualifiedTableName(&Table{Schema: c.SchemaName, Name: c.TableName})}, true
+	case *DropColumnChange:
+		return DriftObject{Kind: ObjectColumn, Table: c.TableName, Name: c.ColumnName}, true
+	case *DropPrimaryKeyChange:
+		return DriftObject{Kind: ObjectPrimaryKey, Table: c.TableName}, true
+	case *DropIndexChange:
+		return DriftObject{Kind: ObjectIndex, Table: c.TableName, Name: c.IndexName}, true
+	case *DropForeignKeyChange:
+		return DriftObject{Kind: ObjectForeignKey, Table: c.TableName, Name: c.FKName}, true
+	case *DropCheckChange:
+		return DriftObject{Kind: ObjectCheck, Table: c.TableName, Name: c.CheckName}, true
+	case *DropUniqueConstraintChange:
+		return DriftObject{Kind: ObjectUnique, Table: c.TableName, Name: c.ConstraintName}, true
+	case *DropEnumChange:
+		return DriftObject{Kind: ObjectEnum, Name: qualifiedName(c.SchemaName, c.EnumName)}, true
+	case *DropSequenceChange:
+		return DriftObject{Kind: ObjectSequence, Name: qualifiedName(c.SchemaName, c.SequenceName)}, true
+	case *DropFunctionChange:
+		return DriftObject{Kind: ObjectFunction, Name: qualifiedName(c.SchemaName, c.FunctionName)}, true
+	case *DropViewChange:
+		return DriftObject{Kind: ObjectView, Name: qualifiedName(c.SchemaName, c.ViewName)}, true
+	case *DropTriggerChange:
+		return DriftObject{Kind: ObjectTrigger, Table: c.TriggerTable, Name: c.TriggerName}, true
+	case *DropRowPolicyChange:
+		return DriftObject{Kind: ObjectRowPolicy, Table: c.TableName, Name: c.PolicyName}, true
+	}
+	return DriftObject{}, false
+}
+
+// addedObject returns the index, constraint or function a change adds, which
+// Diff may drop first to recreate it
+func addedObject(change Change) (DriftObject, bool) {
+	switch c := change.(type) {
+	case *AddPrimaryKeyChange:
+		return DriftObject{Kind: ObjectPrimaryKey, Table: c.TableName}, true
+	case *AddIndexChange:
+		return DriftObject{Kind: ObjectIndex, Table: c.TableName, Name: c.Index.Name}, true
+	case *AddForeignKeyChange:
+		return DriftObject{Kind: ObjectForeignKey, Table: c.TableName, Name: c.ForeignKey.Name}, true
+	case *AddCheckChange:
+		return DriftObject{Kind: ObjectCheck, Table: c.TableName, Name: c.Check.Name}, true
+	case *AddUniqueConstraintChange:
+		return DriftObject{Kind: ObjectUnique, Table: c.TableName, Name: c.Unique.Name}, true
+	case *CreateFunctionChange:
+		return DriftObject{Kind: ObjectFunction, Name: qualifiedName(c.Function.Schema, c.Function.Name)}, true
+	}
+	return DriftObject{}, false
+}
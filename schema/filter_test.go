@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatch(t *testing.T) {
+	filter := NewFilter(
+		IgnoreObjects("_tmp_*"),
+		IgnoreObjects("schema_migrations", ObjectTable),
+		IgnoreObjectsRegexp(regexp.MustCompile(`_p\d{8}$`), ObjectTable),
+		IgnoreObjects("partman", ObjectSchema),
+		IncludeObjects("api_*", ObjectFunction),
+	)
+
+	require.True(t, filter.Match(ObjectTable, "", "users"))
+	require.False(t, filter.Match(ObjectTable, "", "_tmp_users"))
+	require.False(t, filter.Match(ObjectView, "", "_tmp_active_users"))
+	require.False(t, filter.Match(ObjectTable, "", "schema_migrations"))
+	require.True(t, filter.Match(ObjectView, "", "schema_migrations"))
+	require.False(t, filter.Match(ObjectTable, "", "events_p20240101"))
+	require.False(t, filter.Match(ObjectTable, "partman", "template"))
+	require.False(t, filter.Match(ObjectSchema, "", "partman"))
+	require.True(t, filter.Match(ObjectSchema, "", "_tmp_schema"))
+	require.True(t, filter.Match(ObjectFunction, "", "api_login"))
+	require.False(t, filter.Match(ObjectFunction, "", "internal_login"))
+
+	qualified := NewFilter(IgnoreObjects("audit.*"))
+	require.False(t, qualified.Match(ObjectTable, "audit", "events"))
+	require.True(t, qualified.Match(ObjectTable, "", "events"))
+
+	var none *Filter
+	require.True(t, none.Match(ObjectTable, "", "_tmp_users"))
+}
+
+func TestFilterApply(t *testing.T) {
+	s := NewSchema()
+	s.Namespaces = []string{"audit", "partman"}
+	s.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	s.CreateTable("_tmp_users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	s.CreateTable("template", func(t *Table) {
+		t.Schema = "partman"
+		t.Column("id", &IntegerType{})
+	})
+	s.CreateView("_tmp_active_users", "SELECT * FROM users")
+	s.CreateTrigger("users_audit", "users", "audit_users")
+	s.CreateTrigger("tmp_users_audit", "_tmp_users", "audit_users")
+
+	filtered := NewFilter(IgnoreObjects("_tmp_*"), IgnoreObjects("partman", ObjectSchema)).Apply(s)
+	require.Equal(t, []string{"audit"}, filtered.Namespaces)
+	require.Len(t, filtered.Tables, 1)
+	require.Equal(t, "users", filtered.Tables[0].Name)
+	require.Empty(t, filtered.Views)
+	require.Len(t, filtered.Triggers, 1)
+	require.Equal(t, "users_audit", filtered.Triggers[0].Name)
+
+	// The original schema is unchanged
+	require.Len(t, s.Tables, 3)
+	require.Len(t, s.Triggers, 2)
+}
+
+func TestDiffWithFilter(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	source.CreateTable("schema_migrations", func(t *Table) {
+		t.Column("version", &VarcharType{Length: 255})
+	})
+	source.CreateTable("_tmp_users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	filter := NewFilter(IgnoreObjects("schema_migrations"), IgnoreObjects("_tmp_*"))
+	require.Empty(t, Diff(source, target, WithFilter(filter)))
+	require.Len(t, Diff(source, target), 2)
+}
+
+func TestDiffNeverDrop(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &VarcharType{Length: 255})
+		t.Column("legacy", &TextType{})
+		t.Index("users_email_idx", []string{"email"})
+	})
+	source.CreateTable("audit_log", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	source.CreateView("active_users", "SELECT * FROM users")
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &VarcharType{Length: 255})
+		t.Column("name", &TextType{})
+		t.Index("users_email_idx", []string{"email"}, Unique)
+	})
+
+	changes := Diff(source, target, WithFilter(NewFilter(NeverDrop())))
+	var types []ChangeType
+	for _, change := range changes {
+		types = append(types, change.Type())
+	}
+	require.ElementsMatch(t, []ChangeType{AddColumn, DropIndex, AddIndex}, types)
+}
+
+func TestDiffNeverDropRebuild(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	source.CreateTable("c", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("secret", &TextType{})
+		t.Check("c_secret_check", "secret <> ''")
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+	target.CreateTable("c", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.ForeignKey("c_id_fkey", []string{"id"}, "users", []string{"id"})
+	})
+
+	changes := Diff(source, target, WithFilter(NewFilter(NeverDrop())))
+	require.Len(t, changes, 1)
+	rebuild := changes[0].(*AddForeignKeyChange).Rebuild
+	require.False(t, rebuild.Superseded)
+	require.Equal(t, []*Column{
+		{Name: "id", Type: &IntegerType{}},
+		{Name: "secret", Type: &TextType{}},
+	}, rebuild.Table.Columns)
+	require.Equal(t, source.Tables[1].Checks, rebuild.Table.Checks)
+	require.Equal(t, target.Tables[1].ForeignKeys, rebuild.Table.ForeignKeys)
+}
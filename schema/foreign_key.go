@@ -2,12 +2,13 @@ package schema
 
 // ForeignKey represents a foreign key relationship
 type ForeignKey struct {
-	Name       string
-	Columns    []string
-	RefTable   string
-	RefColumns []string
-	OnDelete   string
-	OnUpdate   string
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns,omitempty"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns,omitempty"`
+	OnDelete   string   `json:"on_delete,omitempty"`
+	OnUpdate   string   `json:"on_update,omitempty"`
+	NotValid   bool     `json:"not_valid,omitempty"` // Not validated against existing rows yet (PostgreSQL NOT VALID)
 }
 
 // ForeignKeyOption is a function type for foreign key options
@@ -2,24 +2,24 @@ package schema
 
 // Function represents a database function
 type Function struct {
-	Schema     string
-	Name       string
-	Arguments  []FunctionArg
-	Returns    string
-	Language   string
-	Body       string
-	Volatility string
-	Strict     bool
-	Security   string
-	Cost       int
+	Schema     string        `json:"schema,omitempty"`
+	Name       string        `json:"name"`
+	Arguments  []FunctionArg `json:"arguments,omitempty"`
+	Returns    string        `json:"returns"`
+	Language   string        `json:"language"`
+	Body       string        `json:"body,omitempty"`
+	Volatility string        `json:"volatility"`
+	Strict     bool          `json:"strict,omitempty"`
+	Security   string        `json:"security"`
+	Cost       int           `json:"cost"`
 }
 
 // FunctionArg represents a function argument
 type FunctionArg struct {
-	Name    string
-	Type    string
-	Mode    string // IN, OUT, INOUT, or VARIADIC
-	Default string
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type"`
+	Mode    string `json:"mode,omitempty"` // IN, OUT, INOUT, or VARIADIC
+	Default string `json:"default,omitempty"`
 }
 
 // FunctionOption represents an option for creating a function
@@ -0,0 +1,332 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// HazardType classifies what can go wrong when a change is applied
+type HazardType string
+
+const (
+	// HazardDataLoss means the change deletes or truncates existing data
+	HazardDataLoss HazardType = "data_loss"
+	// HazardTableRewrite means the database may rewrite every row of a table
+	HazardTableRewrite HazardType = "table_rewrite"
+	// HazardLongLock means the change holds a lock that blocks writes or reads
+	// for a time that grows with the size of the table
+	HazardLongLock HazardType = "long_lock"
+	// HazardIndexBuild means the change builds an index over existing rows
+	HazardIndexBuild HazardType = "index_build"
+	// HazardBreakingChange means queries written against the old schema fail
+	// or behave differently
+	HazardBreakingChange HazardType = "breaking_change"
+	// HazardIrreversible means reverting the change does not restore the data
+	HazardIrreversible HazardType = "irreversible"
+)
+
+// Hazard describes a risk of applying a change
+type Hazard struct {
+	Type   HazardType `json:"type"`
+	Reason string     `json:"reason"`
+}
+
+func (h Hazard) String() string {
+	return fmt.Sprintf("%s: %s", h.Type, h.Reason)
+}
+
+// ChangeHazard is a hazard of a change
+type ChangeHazard struct {
+	Change Change
+	Hazard Hazard
+}
+
+// HazardError is returned by CheckHazards when changes have hazards that are not allowed
+type HazardError struct {
+	Hazards []ChangeHazard
+}
+
+func (e *HazardError) Error() string {
+	reasons := make([]string, len(e.Hazards))
+	for i, h := range e.Hazards {
+		reasons[i] = fmt.Sprintf("%s (%s)", h.Hazard, h.Change.Type())
+	}
+	return "changes have hazards that are not allowed: " + strings.Join(reasons, "; ")
+}
+
+// CheckHazards returns a *HazardError listing the hazards of changes whose
+// type is not allowed, or nil when every hazard is allowed
+func CheckHazards(changes []Change, allowed ...HazardType) error {
+	var hazards []ChangeHazard
+	for _, change := range changes {
+		for _, hazard := range change.Hazards() {
+			if !slices.Contains(allowed, hazard.Type) {
+				hazards = append(hazards, ChangeHazard{Change: change, Hazard: hazard})
+			}
+		}
+	}
+	if len(hazards) > 0 {
+		return &HazardError{Hazards: hazards}
+	}
+	return nil
+}
+
+// HazardRecorder is implemented by the pointers to changes, which embed
+// BaseChange, so that dialects can adjust the hazards Diff records
+type HazardRecorder interface {
+	Change
+	AddHazard(hazardType HazardType, reason string)
+	HasHazard(hazardType HazardType) bool
+	RemoveHazards(hazardType HazardType)
+}
+
+// classifyHazards records the hazards of changes returned by Diff. Changes
+// to tables created by the same diff have none, since the tables are empty.
+func classifyHazards(changes []Change, source, target *Schema, config *diffConfig) {
+	created := map[string]bool{}
+	for _, change := range changes {
+		if c, ok := change.(*CreateTableChange); ok {
+			created[qualifiedTableName(c.TableDef)] = true
+		}
+	}
+
+	for _, change := range changes {
+		c, ok := change.(HazardRecorder)
+		if !ok || created[changeTableName(change)] {
+			continue
+		}
+
+		switch change := change.(type) {
+		case *DropSchemaChange:
+			c.AddHazard(HazardDataLoss, fmt.Sprintf("dropping schema %s deletes the objects in it", change.SchemaName))
+			c.AddHazard(HazardIrreversible, fmt.Sprintf("recreating schema %s does not restore its objects", change.SchemaName))
+		case *DisableExtensionChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("disabling extension %s removes the types and functions it provides", change.Extension))
+		case *DropTableChange:
+			table := qualifiedTableName(&Table{Schema: change.SchemaName, Name: change.TableName})
+			c.AddHazard(HazardDataLoss, fmt.Sprintf("dropping table %s deletes its rows", table))
+			c.AddHazard(HazardIrreversible, fmt.Sprintf("recreating table %s does not restore its rows", table))
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries on table %s fail", table))
+		case *RenameTableChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries on table %s fail once it is renamed to %s", change.OldName, change.NewName))
+		case *DropColumnChange:
+			c.AddHazard(HazardDataLoss, fmt.Sprintf("dropping column %s.%s deletes its values", change.TableName, change.ColumnName))
+			c.AddHazard(HazardIrreversible, fmt.Sprintf("adding column %s.%s again does not restore its values", change.TableName, change.ColumnName))
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries on column %s.%s fail", change.TableName, change.ColumnName))
+		case *RenameColumnChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries on column %s.%s fail once it is renamed to %s", change.TableName, change.OldName, change.NewName))
+		case *AlterColumnChange:
+			if col := sourceColumn(source, target, change.TableName, change.Column.Name); col != nil {
+				classifyColumnHazards(c, change.TableName, col, change.Column, config)
+			}
+		case *AddPrimaryKeyChange:
+			c.AddHazard(HazardIndexBuild, fmt.Sprintf("adding primary key %s builds an index on %s", change.PrimaryKey.Name, change.TableName))
+			c.AddHazard(HazardLongLock, fmt.Sprintf("writes to %s are blocked while the primary key is built", change.TableName))
+		case *DropPrimaryKeyChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("foreign keys and upserts relying on primary key %s of %s fail", change.PKName, change.TableName))
+		case *AddIndexChange:
+			c.AddHazard(HazardIndexBuild, fmt.Sprintf("adding index %s builds it on %s", change.Index.Name, change.TableName))
+			if !change.Concurrently {
+				c.AddHazard(HazardLongLock, fmt.Sprintf("writes to %s are blocked while index %s is built", change.TableName, change.Index.Name))
+			}
+		case *AddForeignKeyChange:
+			if !change.NotValid {
+				c.AddHazard(HazardLongLock, fmt.Sprintf("foreign key %s checks the existing rows of %s while holding a lock", change.ForeignKey.Name, change.TableName))
+			}
+		case *AddCheckChange:
+			if !change.NotValid {
+				c.AddHazard(HazardLongLock, fmt.Sprintf("check constraint %s checks the existing rows of %s while holding a lock", change.Check.Name, change.TableName))
+			}
+		case *AddUniqueConstraintChange:
+			c.AddHazard(HazardIndexBuild, fmt.Sprintf("adding unique constraint %s builds an index on %s", change.Unique.Name, change.TableName))
+			c.AddHazard(HazardLongLock, fmt.Sprintf("writes to %s are blocked while unique constraint %s is built", change.TableName, change.Unique.Name))
+		case *DropEnumChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries using enum type %s fail", change.EnumName))
+		case *AlterEnumChange:
+			c.AddHazard(HazardTableRewrite, fmt.Sprintf("recreating enum type %s converts the columns that use it", change.Enum.Name))
+			c.AddHazard(HazardLongLock, fmt.Sprintf("the tables using enum type %s are locked while their columns are converted", change.Enum.Name))
+			if sourceEnum := findEnum(source, change.Enum.QualifiedName()); sourceEnum != nil && slices.ContainsFunc(sourceEnum.Values, func(v string) bool {
+				return !slices.Contains(change.Enum.Values, v)
+			}) {
+				c.AddHazard(HazardDataLoss, fmt.Sprintf("rows holding a value removed from enum type %s fail the conversion", change.Enum.Name))
+			}
+		case *DropSequenceChange:
+			c.AddHazard(HazardDataLoss, fmt.Sprintf("dropping sequence %s loses its current value", change.SequenceName))
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("defaults and queries using sequence %s fail", change.SequenceName))
+		case *DropFunctionChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries calling function %s fail", change.FunctionName))
+		case *DropViewChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("queries on view %s fail", change.ViewName))
+		case *DropTriggerChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("trigger %s no longer runs on %s", change.TriggerName, change.TriggerTable))
+		case *DropRowPolicyChange:
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("dropping policy %s changes which rows of %s are visible", change.PolicyName, change.TableName))
+		}
+
+		if config.reversal {
+			classifyIrreversible(c, source, target)
+		}
+	}
+}
+
+// changeTableName returns the qualified name of the table a change applies to,
+// or an empty string for changes to other objects
+func changeTableName(change Change) string {
+	switch c := change.(type) {
+	case *AddIndexChange:
+		return c.TableName
+	case *AddForeignKeyChange:
+		return c.TableName
+	case *AddCheckChange:
+		return c.TableName
+	case *AddUniqueConstraintChange:
+		return c.TableName
+	case *AddPrimaryKeyChange:
+		return c.TableName
+	}
+	return ""
+}
+
+// classifyColumnHazards records the hazards of altering a column from source to target
+func classifyColumnHazards(c HazardRecorder, tableName string, source, target *Column, config *diffConfig) {
+	column := tableName + "." + target.Name
+
+	if !areColumnTypesEqual(source.Type, target.Type) {
+		from, to := source.Type.SQL(), target.Type.SQL()
+		switch {
+		case isValuesChange(source.Type, target.Type):
+			if isUnsafeValuesChange(source.Type, target.Type) {
+				c.AddHazard(HazardDataLoss, fmt.Sprintf("rows of %s holding a removed value fail the conversion from %s to %s", column, from, to))
+				c.AddHazard(HazardTableRewrite, fmt.Sprintf("changing the values of %s rewrites %s", column, tableName))
+				c.AddHazard(HazardLongLock, fmt.Sprintf("%s is locked while it is rewritten", tableName))
+			}
+		case isWideningType(source.Type, target.Type):
+			c.AddHazard(HazardTableRewrite, fmt.Sprintf("changing %s from %s to %s may rewrite %s", column, from, to, tableName))
+			c.AddHazard(HazardLongLock, fmt.Sprintf("%s is locked while it is rewritten", tableName))
+		case isNarrowingType(source.Type, target.Type):
+			c.AddHazard(HazardDataLoss, fmt.Sprintf("changing %s from %s to %s truncates or rejects values that do not fit", column, from, to))
+			c.AddHazard(HazardIrreversible, fmt.Sprintf("changing %s back to %s does not restore truncated values", column, from))
+			c.AddHazard(HazardTableRewrite, fmt.Sprintf("changing %s from %s to %s rewrites %s", column, from, to, tableName))
+			c.AddHazard(HazardLongLock, fmt.Sprintf("%s is locked while it is rewritten", tableName))
+		default:
+			c.AddHazard(HazardDataLoss, fmt.Sprintf("converting %s from %s to %s may fail or lose values", column, from, to))
+			c.AddHazard(HazardTableRewrite, fmt.Sprintf("changing %s from %s to %s rewrites %s", column, from, to, tableName))
+			c.AddHazard(HazardLongLock, fmt.Sprintf("%s is locked while it is rewritten", tableName))
+			c.AddHazard(HazardBreakingChange, fmt.Sprintf("readers of %s receive %s values instead of %s", column, to, from))
+		}
+	}
+
+	// With deferred validation, SET NOT NULL relies on a validated check
+	// constraint and skips its table scan
+	if source.Nullable && !target.Nullable && !config.deferValidation {
+		c.AddHazard(HazardLongLock, fmt.Sprintf("setting %s NOT NULL scans %s while holding a lock", column, tableName))
+	}
+}
+
+// isValuesChange reports whether both types are the same enumerated type with different values
+func isValuesChange(source, target ColumnType) bool {
+	s, ok := source.(EnumeratedType)
+	if !ok {
+		return false
+	}
+	t, ok := target.(EnumeratedType)
+	return ok && baseTypeName(s.SQL()) == baseTypeName(t.SQL())
+}
+
+var (
+	typeArgumentsPattern = regexp.MustCompile(`^([a-z ]+?)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)`)
+	integerTypeSizes     = map[string]int{
+		"tinyint":   1,
+		"smallint":  2,
+		"mediumint": 3,
+		"int":       4,
+		"integer":   4,
+		"bigint":    8,
+	}
+	lengthTypes = map[string]bool{
+		"varchar":           true,
+		"character varying": true,
+		"char":              true,
+		"character":         true,
+		"varbinary":         true,
+		"binary":            true,
+	}
+)
+
+// baseTypeName returns the lowercase name of a type without its arguments
+func baseTypeName(sql string) string {
+	name := strings.ToLower(strings.TrimSpace(sql))
+	if i := strings.Index(name, "("); i != -1 {
+		name = name[:i]
+	}
+	return strings.TrimSpace(name)
+}
+
+// typeArguments returns the length or precision and scale of a type, such as 10 and 2 for decimal(10,2)
+func typeArguments(sql string) (int, int, bool) {
+	match := typeArgumentsPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(sql)))
+	if match == nil {
+		return 0, 0, false
+	}
+	first, _ := strconv.Atoi(match[2])
+	second, _ := strconv.Atoi(match[3])
+	return first, second, true
+}
+
+// isWideningType reports whether every value of source fits in target
+func isWideningType(source, target ColumnType) bool {
+	return compareTypeSizes(source, target) < 0
+}
+
+// isNarrowingType reports whether some values of source do not fit in target
+func isNarrowingType(source, target ColumnType) bool {
+	return compareTypeSizes(source, target) > 0
+}
+
+// compareTypeSizes compares two integer, length or decimal types of the same
+// kind, and returns 0 when they are of different kinds
+func compareTypeSizes(source, target ColumnType) int {
+	from, to := source.SQL(), target.SQL()
+	fromName, toName := baseTypeName(from), baseTypeName(to)
+
+	if fromSize, ok := integerTypeSizes[fromName]; ok {
+		if toSize, ok := integerTypeSizes[toName]; ok {
+			return fromSize - toSize
+		}
+		return 0
+	}
+
+	if fromName == "text" && lengthTypes[toName] {
+		return 1
+	}
+	if lengthTypes[fromName] && toName == "text" {
+		return -1
+	}
+	if lengthTypes[fromName] && lengthTypes[toName] {
+		fromLength, _, fromOK := typeArguments(from)
+		toLength, _, toOK := typeArguments(to)
+		if fromOK && toOK {
+			return fromLength - toLength
+		}
+		return 0
+	}
+
+	if (fromName == "decimal" || fromName == "numeric") && (toName == "decimal" || toName == "numeric") {
+		fromPrecision, fromScale, fromOK := typeArguments(from)
+		toPrecision, toScale, toOK := typeArguments(to)
+		if !fromOK || !toOK {
+			return 0
+		}
+		// Both the integer digits and the fractional digits must fit
+		if toScale < fromScale || toPrecision-toScale < fromPrecision-fromScale {
+			return 1
+		}
+		if toPrecision > fromPrecision || toScale > fromScale {
+			return -1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// hazardTypes returns the types of the hazards of a change
+func hazardTypes(change Change) []HazardType {
+	var types []HazardType
+	for _, hazard := range change.Hazards() {
+		types = append(types, hazard.Type)
+	}
+	return types
+}
+
+func TestDiffHazards(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &TextType{})
+		t.Column("age", &IntegerType{}, Nullable)
+		t.Column("score", &DecimalType{Precision: 10, Scale: 2})
+		t.Column("email", &TextType{})
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &VarcharType{Length: 100})
+		t.Column("age", &BigIntType{})
+		t.Column("score", &UUIDType{})
+		t.Index("idx_users_name", []string{"name"})
+	})
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 6)
+
+	require.IsType(t, &DropTableChange{}, changes[0])
+	require.Equal(t, []HazardType{HazardDataLoss, HazardIrreversible, HazardBreakingChange}, hazardTypes(changes[0]))
+	require.Equal(t, Hazard{Type: HazardDataLoss, Reason: "dropping table posts deletes its rows"}, changes[0].Hazards()[0])
+
+	require.IsType(t, &DropColumnChange{}, changes[1])
+	require.Equal(t, []HazardType{HazardDataLoss, HazardIrreversible, HazardBreakingChange}, hazardTypes(changes[1]))
+
+	require.IsType(t, &AlterColumnChange{}, changes[2])
+	require.Equal(t, []HazardType{HazardDataLoss, HazardIrreversible, HazardTableRewrite, HazardLongLock}, hazardTypes(changes[2]))
+	require.Equal(t, "changing users.name from text to varchar(100) truncates or rejects values that do not fit", changes[2].Hazards()[0].Reason)
+
+	require.IsType(t, &AlterColumnChange{}, changes[3])
+	require.Equal(t, []HazardType{HazardTableRewrite, HazardLongLock, HazardLongLock}, hazardTypes(changes[3]))
+
+	require.IsType(t, &AlterColumnChange{}, changes[4])
+	require.Equal(t, []HazardType{HazardDataLoss, HazardTableRewrite, HazardLongLock, HazardBreakingChange}, hazardTypes(changes[4]))
+
+	require.IsType(t, &AddIndexChange{}, changes[5])
+	require.Equal(t, []HazardType{HazardIndexBuild, HazardLongLock}, hazardTypes(changes[5]))
+	require.True(t, changes[5].IsUnsafe())
+}
+
+func TestDiffHazardsSafeChanges(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &VarcharType{Length: 100})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &VarcharType{Length: 100}, Nullable, Default("''"))
+		t.Column("bio", &TextType{}, Nullable)
+	})
+	target.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("user_id", &IntegerType{})
+		t.Index("idx_posts_user_id", []string{"user_id"})
+		t.ForeignKey("fk_posts_user", []string{"user_id"}, "users", []string{"id"})
+	})
+
+	for _, change := range Diff(source, target) {
+		require.Empty(t, change.Hazards(), change.Type())
+		require.False(t, change.IsUnsafe(), change.Type())
+	}
+}
+
+func TestDiffHazardsDeferredValidation(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("email", &TextType{}, Nullable)
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("email", &TextType{})
+		t.Check("users_email_check", "email <> ''")
+	})
+
+	for _, change := range Diff(source, target, WithDeferredValidation()) {
+		require.Empty(t, change.Hazards(), change.Type())
+	}
+
+	changes := Diff(source, target)
+	require.Len(t, changes, 2)
+	require.Equal(t, []HazardType{HazardLongLock}, hazardTypes(changes[0]))
+	require.Equal(t, []HazardType{HazardLongLock}, hazardTypes(changes[1]))
+}
+
+func TestCheckHazards(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &TextType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	changes := Diff(source, target)
+	require.NoError(t, CheckHazards(changes, HazardDataLoss, HazardIrreversible, HazardBreakingChange))
+
+	err := CheckHazards(changes, HazardBreakingChange)
+	var hazardErr *HazardError
+	require.ErrorAs(t, err, &hazardErr)
+	require.Len(t, hazardErr.Hazards, 2)
+	require.Same(t, changes[0], hazardErr.Hazards[0].Change)
+	require.EqualError(t, err, "changes have hazards that are not allowed: "+
+		"data_loss: dropping column users.email deletes its values (drop_column); "+
+		"irreversible: adding column users.email again does not restore its values (drop_column)")
+}
@@ -0,0 +1,476 @@
+package schema
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// changeNode is a group of changes that Diff keeps together when it orders
+// changes by their dependencies
+type changeNode struct {
+	changes  []Change
+	index    int      // Position in the category order, used to break ties
+	key      string   // Object created, altered or dropped by the changes
+	drop     bool     // Whether the changes drop the object
+	oldKey   string   // Key of a renamed object in the source schema
+	requires []string // Objects that must exist before the changes run
+}
+
+var nextvalPattern = regexp.MustCompile(`(?i)nextval\(\s*'([^']+)'`)
+
+// objectKey identifies a database object in the dependency graph
+func objectKey(kind, schema, name string) string {
+	return kind + ":" + schema + "." + name
+}
+
+// orderChanges sorts changes so that objects are created after the objects
+// they depend on and dropped before them. Foreign keys of new tables that
+// reference each other are moved to AddForeignKeyChanges at the end.
+func orderChanges(changes []Change, source, target *Schema) []Change {
+	sourceDeps := objectDependencies(source, true)
+	targetDeps := objectDependencies(target, false)
+	nodes := changeNodes(changes, target, targetDeps)
+
+	for {
+		sorted, remaining := sortChangeNodes(nodes, sourceDeps)
+		if len(remaining) > 0 {
+			deferred := deferForeignKeys(remaining, source, target, targetDeps, len(changes)+len(nodes))
+			if len(deferred) > 0 {
+				nodes = append(nodes, deferred...)
+				continue
+			}
+			sorted = append(sorted, remaining...)
+		}
+
+		ordered := make([]Change, 0, len(changes))
+		for _, node := range sorted {
+			ordered = append(ordered, node.changes...)
+		}
+		return ordered
+	}
+}
+
+// objectDependencies returns the objects each object of a schema depends on
+func objectDependencies(s *Schema, foreignKeys bool) map[string][]string {
+	deps := map[string][]string{}
+	add := func(key, schema string, refs ...string) {
+		if schema != "" {
+			deps[key] = append(deps[key], objectKey("schema", "", schema))
+		}
+		for _, ref := range refs {
+			if ref != "" && ref != key {
+				deps[key] = append(deps[key], ref)
+			}
+		}
+	}
+
+	for _, table := range s.Tables {
+		key := objectKey("table", table.Schema, table.Name)
+		add(key, table.Schema, sequenceReferences(s, table)...)
+		add(key, "", enumReferences(s, table)...)
+		if foreignKeys {
+			add(key, "", foreignKeyReferences(s, table)...)
+		}
+	}
+	for _, view := range s.Views {
+		key := objectKey("view", view.Schema, view.Name)
+		add(key, view.Schema)
+		for _, table := range s.Tables {
+			if viewReferencesTable(view, table.Name) {
+				add(key, "", objectKey("table", table.Schema, table.Name))
+			}
+		}
+		for _, other := range s.Views {
+			if viewReferencesTable(view, other.Name) {
+				add(key, "", objectKey("view", other.Schema, other.Name))
+			}
+		}
+	}
+	for _, trigger := range s.Triggers {
+		add(objectKey("trigger", trigger.Schema, trigger.Table+"."+trigger.Name), trigger.Schema,
+			relationKey(s, trigger.Table), functionKey(s, trigger.Function))
+	}
+	for _, policy := range s.RowPolicies {
+		add(objectKey("policy", policy.Schema, policy.TableName+"."+policy.PolicyName), policy.Schema,
+			relationKey(s, policy.TableName))
+	}
+	for _, seq := range s.Sequences {
+		add(objectKey("sequence", seq.Schema, seq.Name), seq.Schema)
+	}
+	for _, enum := range s.Enums {
+		add(enumKey(enum.Schema, enum.Name), enum.Schema)
+	}
+	for _, fn := range s.Functions {
+		add(objectKey("function", fn.Schema, fn.Name), fn.Schema)
+	}
+
+	return deps
+}
+
+// foreignKeyReferences returns the tables referenced by the foreign keys of a table
+func foreignKeyReferences(s *Schema, table *Table) []string {
+	var refs []string
+	for _, fk := range table.ForeignKeys {
+		refs = append(refs, relationKey(s, fk.RefTable))
+	}
+	return refs
+}
+
+// sequenceReferences returns the sequences used by the column defaults of a table
+func sequenceReferences(s *Schema, table *Table) []string {
+	var refs []string
+	for _, col := range table.Columns {
+		for _, match := range nextvalPattern.FindAllStringSubmatch(col.Default, -1) {
+			name := match[1][strings.LastIndex(match[1], ".")+1:]
+			for _, seq := range s.Sequences {
+				if seq.Name == strings.Trim(name, `"`) {
+					refs = append(refs, objectKey("sequence", seq.Schema, seq.Name))
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// enumReferences returns the enum types used by the columns of a table
+func enumReferences(s *Schema, table *Table) []string {
+	var refs []string
+	for _, col := range table.Columns {
+		// Arrays of an enum type depend on it as well
+		name := strings.TrimSuffix(strings.ReplaceAll(col.TypeSQL(), `"`, ""), "[]")
+		for _, enum := range s.Enums {
+			if enum.hasTypeName(name) {
+				refs = append(refs, enumKey(enum.Schema, enum.Name))
+			}
+		}
+	}
+	return refs
+}
+
+// enumKey returns the key of an enum type, which does not depend on whether
+// the default schema is spelled out
+func enumKey(schema, name string) string {
+	return objectKey("enum", "", (&Enum{Schema: schema, Name: name}).QualifiedName())
+}
+
+// relationKey returns the key of the table or view with the given name, which
+// may be qualified with a schema name
+func relationKey(s *Schema, name string) string {
+	for _, table := range s.Tables {
+		if table.Name == name || table.Schema+"."+table.Name == name {
+			return objectKey("table", table.Schema, table.Name)
+		}
+	}
+	for _, view := range s.Views {
+		if view.Name == name || view.Schema+"."+view.Name == name {
+			return objectKey("view", view.Schema, view.Name)
+		}
+	}
+	return objectKey("table", "", name)
+}
+
+// functionKey returns the key of the function called by a trigger, or an
+// empty string when the trigger body is not a function of the schema
+func functionKey(s *Schema, call string) string {
+	name, _, _ := strings.Cut(call, "(")
+	name = strings.TrimSpace(name)
+	for _, fn := range s.Functions {
+		if fn.Name == name || fn.Schema+"."+fn.Name == name {
+			return objectKey("function", fn.Schema, fn.Name)
+		}
+	}
+	return ""
+}
+
+// changeNodes groups changes into the nodes of the dependency graph. The
+// changes to an existing table stay together, in the order diffTable put
+// them, except for foreign keys which get a node of their own.
+func changeNodes(changes []Change, target *Schema, targetDeps map[string][]string) []*changeNode {
+	var nodes []*changeNode
+	for i, change := range changes {
+		node := &changeNode{changes: []Change{change}, index: i}
+
+		switch c := change.(type) {
+		case *CreateSchemaChange:
+			node.key = objectKey("schema", "", c.SchemaName)
+		case *DropSchemaChange:
+			node.key, node.drop = objectKey("schema", "", c.SchemaName), true
+		case *EnableExtensionChange:
+			node.key = objectKey("extension", "", c.Extension)
+		case *DisableExtensionChange:
+			node.key, node.drop = objectKey("extension", "", c.Extension), true
+		case *CreateEnumChange:
+			node.key = enumKey(c.Enum.Schema, c.Enum.Name)
+		case *AddEnumValueChange:
+			node.key = enumKey(c.Enum.Schema, c.Enum.Name)
+		case *AlterEnumChange:
+			node.key = enumKey(c.Enum.Schema, c.Enum.Name)
+		case *DropEnumChange:
+			node.key, node.drop = enumKey(c.SchemaName, c.EnumName), true
+		case *CreateSequenceChange:
+			node.key = objectKey("sequence", c.Sequence.Schema, c.Sequence.Name)
+		case *AlterSequenceChange:
+			node.key = objectKey("sequence", c.Sequence.Schema, c.Sequence.Name)
+		case *DropSequenceChange:
+			node.key, node.drop = objectKey("sequence", c.SchemaName, c.SequenceName), true
+		case *CreateFunctionChange:
+			node.key = objectKey("function", c.Function.Schema, c.Function.Name)
+		case *AlterFunctionChange:
+			node.key = objectKey("function", c.Function.Schema, c.Function.Name)
+		case *DropFunctionChange:
+			node.key, node.drop = objectKey("function", c.SchemaName, c.FunctionName), true
+		case *CreateViewChange:
+			node.key = objectKey("view", c.View.Schema, c.View.Name)
+		case *AlterViewChange:
+			node.key = objectKey("view", c.View.Schema, c.View.Name)
+		case *DropViewChange:
+			node.key, node.drop = objectKey("view", c.SchemaName, c.ViewName), true
+		case *CreateTriggerChange:
+			node.key = objectKey("trigger", c.Trigger.Schema, c.Trigger.Table+"."+c.Trigger.Name)
+		case *AlterTriggerChange:
+			node.key = objectKey("trigger", c.Trigger.Schema, c.Trigger.Table+"."+c.Trigger.Name)
+		case *DropTriggerChange:
+			node.key, node.drop = objectKey("trigger", c.SchemaName, c.TriggerTable+"."+c.TriggerName), true
+		case *CreateRowPolicyChange:
+			node.key = objectKey("policy", c.RowPolicy.Schema, c.RowPolicy.TableName+"."+c.RowPolicy.PolicyName)
+		case *AlterRowPolicyChange:
+			node.key = objectKey("policy", c.RowPolicy.Schema, c.RowPolicy.TableName+"."+c.RowPolicy.PolicyName)
+		case *DropRowPolicyChange:
+			node.key, node.drop = objectKey("policy", c.SchemaName, c.TableName+"."+c.PolicyName), true
+		case *CreateTableChange:
+			node.key = objectKey("table", c.TableDef.Schema, c.TableDef.Name)
+			node.requires = foreignKeyReferences(target, c.TableDef)
+		case *DropTableChange:
+			node.key, node.drop = objectKey("table", c.SchemaName, c.TableName), true
+		case *AddForeignKeyChange:
+			node.requires = []string{relationKey(target, c.TableName), relationKey(target, c.ForeignKey.RefTable)}
+		case *RenameTableChange:
+			node.key = objectKey("table", c.SchemaName, c.NewName)
+			node.oldKey = objectKey("table", c.SchemaName, c.OldName)
+		default:
+			tableName, ok := tableChangeName(change)
+			if !ok {
+				break
+			}
+			key := relationKey(target, tableName)
+			if last := len(nodes) - 1; last >= 0 && nodes[last].key == key && !nodes[last].drop &&
+				len(nodes[last].changes) > 0 && isTableChange(nodes[last].changes[0]) {
+				nodes[last].changes = append(nodes[last].changes, change)
+				continue
+			}
+			node.key = key
+		}
+
+		if !node.drop {
+			node.requires = append(node.requires, targetDeps[node.key]...)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// tableChangeName returns the table changed by a column, primary key, index
+// or constraint change
+func tableChangeName(change Change) (string, bool) {
+	switch c := change.(type) {
+	case *RenameTableChange:
+		return c.NewName, true
+	case *RenameColumnChange:
+		return c.TableName, true
+	case *RenameIndexChange:
+		return c.TableName, true
+	case *AddColumnChange:
+		return c.TableName, true
+	case *DropColumnChange:
+		return c.TableName, true
+	case *AlterColumnChange:
+		return c.TableName, true
+	case *AddPrimaryKeyChange:
+		return c.TableName, true
+	case *DropPrimaryKeyChange:
+		return c.TableName, true
+	case *AddIndexChange:
+		return c.TableName, true
+	case *DropIndexChange:
+		return c.TableName, true
+	case *AddForeignKeyChange:
+		return c.TableName, true
+	case *DropForeignKeyChange:
+		return c.TableName, true
+	case *AddCheckChange:
+		return c.TableName, true
+	case *DropCheckChange:
+		return c.TableName, true
+	case *AddUniqueConstraintChange:
+		return c.TableName, true
+	case *DropUniqueConstraintChange:
+		return c.TableName, true
+	case *ValidateConstraintChange:
+		return c.TableName, true
+	}
+	return "", false
+}
+
+// isTableChange reports whether a change is grouped with the other changes to its table
+func isTableChange(change Change) bool {
+	_, ok := tableChangeName(change)
+	_, isForeignKey := change.(*AddForeignKeyChange)
+	return ok && !isForeignKey
+}
+
+// sortChangeNodes sorts nodes topologically, picking the node that comes first
+// in the category order whenever several are ready. Nodes that are part of a
+// cycle are returned as remaining.
+func sortChangeNodes(nodes []*changeNode, sourceDeps map[string][]string) (sorted, remaining []*changeNode) {
+	creators := map[string]*changeNode{}
+	droppers := map[string]*changeNode{}
+	for _, node := range nodes {
+		if node.key == "" {
+			continue
+		}
+		if node.drop {
+			droppers[node.key] = node
+		} else {
+			creators[node.key] = node
+		}
+	}
+
+	next := map[*changeNode][]*changeNode{}
+	blockers := map[*changeNode]int{}
+	addEdge := func(before, after *changeNode) {
+		if before != nil && after != nil && before != after {
+			next[before] = append(next[before], after)
+			blockers[after]++
+		}
+	}
+	for _, node := range nodes {
+		if !node.drop {
+			for _, key := range node.requires {
+				addEdge(creators[key], node)
+			}
+		}
+		if node.key != "" {
+			// An object has to be dropped or stop using its dependencies
+			// before they are dropped
+			sourceKey := node.key
+			if node.oldKey != "" {
+				sourceKey = node.oldKey
+			}
+			for _, key := range sourceDeps[sourceKey] {
+				addEdge(node, droppers[key])
+			}
+		}
+	}
+
+	done := map[*changeNode]bool{}
+	for len(sorted) < len(nodes) {
+		var ready *changeNode
+		for _, node := range nodes {
+			if !done[node] && blockers[node] == 0 && (ready == nil || node.index < ready.index) {
+				ready = node
+			}
+		}
+		if ready == nil {
+			break
+		}
+		done[ready] = true
+		sorted = append(sorted, ready)
+		for _, node := range next[ready] {
+			blockers[node]--
+		}
+	}
+
+	for _, node := range nodes {
+		if !done[node] {
+			remaining = append(remaining, node)
+		}
+	}
+	return sorted, remaining
+}
+
+// deferForeignKeys breaks a cycle among the remaining nodes: it removes the
+// foreign keys that lead back to their own table from the first
+// CreateTableChange in such a cycle, and returns nodes that add them once
+// both tables exist
+func deferForeignKeys(remaining []*changeNode, source, target *Schema, targetDeps map[string][]string, index int) []*changeNode {
+	creators := map[string]*changeNode{}
+	for _, node := range remaining {
+		if !node.drop && node.key != "" {
+			creators[node.key] = node
+		}
+	}
+
+	for _, node := range remaining {
+		c, ok := node.changes[0].(*CreateTableChange)
+		if !ok {
+			continue
+		}
+
+		var deferred []*changeNode
+		table := copyTable(c.TableDef)
+		table.ForeignKeys = nil
+		for _, fk := range c.TableDef.ForeignKeys {
+			ref := relationKey(target, fk.RefTable)
+			if ref == node.key || !requiresNode(creators[ref], node, creators, map[*changeNode]bool{}) {
+				table.ForeignKeys = append(table.ForeignKeys, fk)
+				continue
+			}
+			deferred = append(deferred, &changeNode{
+				changes: []Change{&AddForeignKeyChange{
+					TableName:  qualifiedTableName(table),
+					ForeignKey: fk,
+					Rebuild:    newTableRebuild(c.TableDef, source, target),
+				}},
+				index:    index + len(deferred),
+				requires: []string{node.key, ref},
+			})
+		}
+
+		if len(deferred) > 0 {
+			// Each rebuild creates the table with all of its foreign keys
+			for _, d := range deferred[1:] {
+				d.changes[0].(*AddForeignKeyChange).Rebuild.Superseded = true
+			}
+			// The new views and triggers of the table are created once it is
+			// rebuilt, since the rebuild drops the table they depend on
+			deferred[0].key = objectKey("rebuild", table.Schema, table.Name)
+			for _, other := range remaining {
+				switch other.changes[0].(type) {
+				case *CreateViewChange, *CreateTriggerChange:
+					if slices.Contains(other.requires, node.key) {
+						other.requires = append(other.requires, deferred[0].key)
+					}
+				}
+			}
+			node.changes = []Change{&CreateTableChange{BaseChange: c.BaseChange, TableDef: table}}
+			node.requires = append(foreignKeyReferences(target, table), targetDeps[node.key]...)
+			return deferred
+		}
+	}
+	return nil
+}
+
+// requiresNode reports whether from depends, directly or indirectly, on to
+func requiresNode(from, to *changeNode, creators map[string]*changeNode, visited map[*changeNode]bool) bool {
+	if from == nil || visited[from] {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	visited[from] = true
+	for _, key := range from.requires {
+		if requiresNode(creators[key], to, creators, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTableRebuild returns the rebuild definition of a table of the target
+// schema created by the same diff, with the views of source that reference it
+// and still exist in target. Its new views and triggers are created after it.
+func newTableRebuild(table *Table, source, target *Schema) *TableRebuild {
+	return &TableRebuild{Table: copyTable(table), Views: rebuildViews(table.Name, source, target)}
+}
@@ -3,21 +3,21 @@ package schema
 // RowPolicy represents a PostgreSQL row level security policy
 type RowPolicy struct {
 	// Database schema containing the table
-	Schema string
+	Schema string `json:"schema,omitempty"`
 	// Name of the table the policy applies to
-	TableName string
+	TableName string `json:"table_name"`
 	// Name of the row level security policy
-	PolicyName string
+	PolicyName string `json:"policy_name"`
 	// Can be: ALL, SELECT, INSERT, UPDATE, or DELETE
-	CommandType string
+	CommandType string `json:"command_type,omitempty"`
 	// List of roles this policy applies to
-	Roles []string
+	Roles []string `json:"roles,omitempty"`
 	// USING expression for filtering rows visible to operations
-	UsingExpr string
+	UsingExpr string `json:"using_expr,omitempty"`
 	// WITH CHECK expression for filtering rows that can be added
-	CheckExpr string
+	CheckExpr string `json:"check_expr,omitempty"`
 	// true for PERMISSIVE (default), false for RESTRICTIVE
-	Permissive bool
+	Permissive bool `json:"permissive,omitempty"`
 }
 
 // RowPolicyOption represents an option for creating a row policy
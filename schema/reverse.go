@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Reverse returns the changes that undo Diff(source, target), which are the
+// changes from target back to source. Dropped tables and columns are added
+// back with their full definition from source, and renames are renamed back.
+// The changes that recreate dropped objects or widen a narrowed column cannot
+// restore the lost data, and are marked with HazardIrreversible.
+func Reverse(source, target *Schema, options ...DiffOption) []Change {
+	options = append(slices.Clip(options), WithReversal())
+	return Diff(target, ReverseSchema(source, target), options...)
+}
+
+// WithReversal makes Diff treat the target schema as the schema before an
+// earlier change, so that the changes recreating the objects it dropped are
+// marked with HazardIrreversible
+func WithReversal() DiffOption {
+	return func(config *diffConfig) {
+		config.reversal = true
+	}
+}
+
+// ReverseSchema returns a copy of source in which the tables, columns and
+// indexes renamed by target are marked as renamed back, so that
+// Diff(target, ReverseSchema(source, target)) renames them instead of
+// dropping and recreating them
+func ReverseSchema(source, target *Schema) *Schema {
+	reversed := *source
+	reversed.Tables = make([]*Table, len(source.Tables))
+
+	for i, sourceTable := range source.Tables {
+		table := copyTable(sourceTable)
+		table.RenamedFrom = ""
+		for j, col := range table.Columns {
+			if col.RenamedFrom != "" {
+				c := *col
+				c.RenamedFrom = ""
+				table.Columns[j] = &c
+			}
+		}
+		for j, idx := range table.Indexes {
+			if idx.RenamedFrom != "" {
+				c := *idx
+				c.RenamedFrom = ""
+				table.Indexes[j] = &c
+			}
+		}
+
+		if targetTable := findTargetTable(source, target, sourceTable); targetTable != nil {
+			if targetTable.Name != sourceTable.Name {
+				table.RenamedFrom = targetTable.Name
+			}
+			for _, targetCol := range targetTable.Columns {
+				if targetCol.RenamedFrom == "" || findColumn(sourceTable, targetCol.Name) != nil {
+					continue
+				}
+				if j := slices.IndexFunc(table.Columns, func(c *Column) bool { return c.Name == targetCol.RenamedFrom }); j >= 0 {
+					c := *table.Columns[j]
+					c.RenamedFrom = targetCol.Name
+					table.Columns[j] = &c
+				}
+			}
+			for _, targetIdx := range targetTable.Indexes {
+				if targetIdx.RenamedFrom == "" || findIndex(sourceTable, targetIdx.Name) != nil {
+					continue
+				}
+				if j := slices.IndexFunc(table.Indexes, func(idx *Index) bool { return idx.Name == targetIdx.RenamedFrom }); j >= 0 {
+					c := *table.Indexes[j]
+					c.RenamedFrom = targetIdx.Name
+					table.Indexes[j] = &c
+				}
+			}
+		}
+
+		reversed.Tables[i] = table
+	}
+
+	return &reversed
+}
+
+// classifyIrreversible records the changes of a reversal that recreate
+// objects or values dropped by the change they undo
+func classifyIrreversible(c HazardRecorder, source, target *Schema) {
+	switch change := c.(type) {
+	case *CreateSchemaChange:
+		c.AddHazard(HazardIrreversible, fmt.Sprintf("the objects of schema %s are not restored", change.SchemaName))
+	case *CreateTableChange:
+		c.AddHazard(HazardIrreversible, fmt.Sprintf("the rows of table %s are not restored", qualifiedTableName(change.TableDef)))
+	case *AddColumnChange:
+		c.AddHazard(HazardIrreversible, fmt.Sprintf("the values of column %s.%s are not restored", change.TableName, change.Column.Name))
+	case *AlterColumnChange:
+		if col := sourceColumn(source, target, change.TableName, change.Column.Name); col != nil && isWideningType(col.Type, change.Column.Type) {
+			c.AddHazard(HazardIrreversible, fmt.Sprintf("the values of column %s.%s truncated to %s are not restored", change.TableName, change.Column.Name, col.Type.SQL()))
+		}
+	case *CreateSequenceChange:
+		c.AddHazard(HazardIrreversible, fmt.Sprintf("the current value of sequence %s is not restored", change.Sequence.Name))
+	}
+}
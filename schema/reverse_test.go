@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverse(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("name", &TextType{})
+		t.Column("email", &TextType{}, Nullable)
+		t.Index("users_name_idx", []string{"name"})
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("accounts", func(t *Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("full_name", &TextType{}, RenamedFrom("name"))
+		t.Column("bio", &TextType{}, Nullable)
+		t.Index("accounts_full_name_idx", []string{"full_name"}, IndexRenamedFrom("users_name_idx"))
+	}, TableRenamedFrom("users"))
+
+	changes := Reverse(source, target)
+	require.Len(t, changes, 6)
+	require.Equal(t, &RenameTableChange{OldName: "accounts", NewName: "users"}, withoutHazards(changes[:1])[0])
+	require.Equal(t, &RenameColumnChange{TableName: "users", OldName: "full_name", NewName: "name"}, withoutHazards(changes[1:2])[0])
+	require.IsType(t, &RenameIndexChange{}, changes[2])
+	require.Equal(t, "users_name_idx", changes[2].(*RenameIndexChange).NewName)
+	require.IsType(t, &DropColumnChange{}, changes[3])
+	require.Equal(t, "bio", changes[3].(*DropColumnChange).ColumnName)
+	require.Equal(t, &AddColumnChange{TableName: "users", Column: source.Tables[0].Columns[2]}, withoutHazards(changes[4:5])[0])
+	require.Equal(t, &CreateTableChange{TableDef: source.Tables[1]}, withoutHazards(changes[5:])[0])
+
+	// Reversing leaves both schemas as they were
+	require.Empty(t, source.Tables[0].RenamedFrom)
+	require.Empty(t, source.Tables[0].Columns[1].RenamedFrom)
+	require.Empty(t, source.Tables[0].Indexes[0].RenamedFrom)
+	require.Equal(t, "users", target.Tables[0].RenamedFrom)
+}
+
+func TestReverseIrreversible(t *testing.T) {
+	source := NewSchema()
+	source.CreateTable("users", func(t *Table) {
+		t.Column("name", &TextType{})
+		t.Column("email", &TextType{}, Nullable)
+	})
+	source.CreateTable("posts", func(t *Table) {
+		t.Column("title", &TextType{})
+	})
+
+	target := NewSchema()
+	target.CreateTable("users", func(t *Table) {
+		t.Column("name", &VarcharType{Length: 50})
+		t.Column("bio", &TextType{}, Nullable)
+	})
+
+	changes := Reverse(source, target)
+	require.Len(t, changes, 4)
+
+	require.IsType(t, &DropColumnChange{}, changes[0])
+	require.Equal(t, []HazardType{HazardDataLoss, HazardIrreversible, HazardBreakingChange}, hazardTypes(changes[0]))
+
+	require.IsType(t, &AlterColumnChange{}, changes[1])
+	require.Equal(t, []HazardType{HazardTableRewrite, HazardLongLock, HazardIrreversible}, hazardTypes(changes[1]))
+	require.Equal(t, "the values of column users.name truncated to varchar(50) are not restored", changes[1].Hazards()[2].Reason)
+
+	require.IsType(t, &AddColumnChange{}, changes[2])
+	require.Equal(t, []Hazard{{Type: HazardIrreversible, Reason: "the values of column users.email are not restored"}}, changes[2].Hazards())
+
+	require.IsType(t, &CreateTableChange{}, changes[3])
+	require.Equal(t, []Hazard{{Type: HazardIrreversible, Reason: "the rows of table posts are not restored"}}, changes[3].Hazards())
+}
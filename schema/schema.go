@@ -2,14 +2,16 @@ package schema
 
 // Schema represents a database schema
 type Schema struct {
-	Name        string // Name of the database schema (e.g., public)
-	Tables      []*Table
-	Extensions  []string     // PostgreSQL extensions to enable
-	Sequences   []*Sequence  // Database sequences
-	Functions   []*Function  // Database functions
-	Triggers    []*Trigger   // Database triggers
-	Views       []*View      // Database views
-	RowPolicies []*RowPolicy // PostgreSQL row policies
+	Name        string       `json:"name,omitempty"`       // Name of the database schema (e.g., public)
+	Namespaces  []string     `json:"namespaces,omitempty"` // Other database schemas the objects can live in (e.g., audit)
+	Tables      []*Table     `json:"tables,omitempty"`
+	Extensions  []string     `json:"extensions,omitempty"`   // PostgreSQL extensions to enable
+	Enums       []*Enum      `json:"enums,omitempty"`        // PostgreSQL enum types
+	Sequences   []*Sequence  `json:"sequences,omitempty"`    // Database sequences
+	Functions   []*Function  `json:"functions,omitempty"`    // Database functions
+	Triggers    []*Trigger   `json:"triggers,omitempty"`     // Database triggers
+	Views       []*View      `json:"views,omitempty"`        // Database views
+	RowPolicies []*RowPolicy `json:"row_policies,omitempty"` // PostgreSQL row policies
 }
 
 // NewSchema creates a new database schema definition
@@ -17,6 +19,7 @@ func NewSchema() *Schema {
 	return &Schema{
 		Tables:      []*Table{},
 		Extensions:  []string{},
+		Enums:       []*Enum{},
 		Sequences:   []*Sequence{},
 		Functions:   []*Function{},
 		Triggers:    []*Trigger{},
@@ -35,14 +38,28 @@ func WithSchemaName(name string) SchemaOption {
 	}
 }
 
+// TableOption is a function type for table options
+type TableOption func(*Table)
+
+// TableRenamedFrom marks a table as renamed from a previous name
+func TableRenamedFrom(name string) TableOption {
+	return func(t *Table) {
+		t.RenamedFrom = name
+	}
+}
+
 // CreateTable adds a new table to the schema with optional schema name
-func (s *Schema) CreateTable(name string, fn func(*Table)) *Table {
+func (s *Schema) CreateTable(name string, fn func(*Table), options ...TableOption) *Table {
 	table := &Table{
 		Name:    name,
 		Columns: []*Column{},
 		Indexes: []*Index{},
 	}
 
+	for _, option := range options {
+		option(table)
+	}
+
 	if fn != nil {
 		fn(table)
 	}
@@ -2,14 +2,14 @@ package schema
 
 // Sequence represents a database sequence
 type Sequence struct {
-	Schema    string // Schema containing the sequence
-	Name      string
-	Start     int64 // Start value
-	Increment int64 // Increment value
-	MinValue  int64 // Minimum value
-	MaxValue  int64 // Maximum value
-	Cache     int64 // Cache size
-	Cycle     bool  // Whether the sequence cycles when it reaches the limit
+	Schema    string `json:"schema,omitempty"` // Schema containing the sequence
+	Name      string `json:"name"`
+	Start     int64  `json:"start"`           // Start value
+	Increment int64  `json:"increment"`       // Increment value
+	MinValue  int64  `json:"min_value"`       // Minimum value
+	MaxValue  int64  `json:"max_value"`       // Maximum value
+	Cache     int64  `json:"cache"`           // Cache size
+	Cycle     bool   `json:"cycle,omitempty"` // Whether the sequence cycles when it reaches the limit
 }
 
 // SequenceOption represents an option for creating a sequence
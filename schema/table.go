@@ -2,12 +2,15 @@ package schema
 
 // Table represents a database table
 type Table struct {
-	Schema      string
-	Name        string
-	Columns     []*Column
-	Indexes     []*Index
-	PrimaryKey  *PrimaryKey
-	ForeignKeys []*ForeignKey
+	Schema      string              `json:"schema,omitempty"`
+	Name        string              `json:"name"`
+	RenamedFrom string              `json:"renamed_from,omitempty"` // Previous name of the table, used by Diff to rename it
+	Columns     []*Column           `json:"columns,omitempty"`
+	Indexes     []*Index            `json:"indexes,omitempty"`
+	PrimaryKey  *PrimaryKey         `json:"primary_key,omitempty"`
+	ForeignKeys []*ForeignKey       `json:"foreign_keys,omitempty"`
+	Checks      []*CheckConstraint  `json:"checks,omitempty"`
+	Uniques     []*UniqueConstraint `json:"uniques,omitempty"`
 }
 
 // Column adds a column to a table
@@ -66,6 +69,26 @@ func (t *Table) ForeignKey(name string, columns []string, refTable string, refCo
 	return fk
 }
 
+// Check adds a check constraint to a table
+func (t *Table) Check(name string, expression string) *CheckConstraint {
+	check := &CheckConstraint{
+		Name:       name,
+		Expression: expression,
+	}
+	t.Checks = append(t.Checks, check)
+	return check
+}
+
+// Unique adds a unique constraint to a table
+func (t *Table) Unique(name string, columns ...string) *UniqueConstraint {
+	unique := &UniqueConstraint{
+		Name:    name,
+		Columns: columns,
+	}
+	t.Uniques = append(t.Uniques, unique)
+	return unique
+}
+
 // String adds a varchar column (default length 255)
 func (t *Table) String(name string, options ...ColumnOption) *Column {
 	col := &Column{
@@ -223,6 +246,9 @@ type Column struct {
 	Comment string
 	// Whether the column auto-increments (like SERIAL or AUTO_INCREMENT)
 	AutoIncrement bool
+	// Previous name of the column, used by Diff to rename it instead of
+	// dropping it and adding a new one
+	RenamedFrom string
 }
 
 // TypeSQL returns the SQL representation of the column type
@@ -257,11 +283,55 @@ func Comment(comment string) ColumnOption {
 	}
 }
 
+// RenamedFrom marks a column as renamed from a previous name
+func RenamedFrom(name string) ColumnOption {
+	return func(c *Column) {
+		c.RenamedFrom = name
+	}
+}
+
 // Index represents a table index
 type Index struct {
-	Name    string
-	Columns []string
-	Unique  bool
+	Name        string            `json:"name"`
+	Columns     []string          `json:"columns,omitempty"` // Key columns, or expressions in parentheses such as "(lower(email))"
+	Unique      bool              `json:"unique,omitempty"`
+	Method      string            `json:"method,omitempty"`       // Index method, e.g. gin, gist, brin or hash (PostgreSQL), FULLTEXT or SPATIAL (MySQL); btree when empty
+	Where       string            `json:"where,omitempty"`        // Predicate of a partial index
+	Include     []string          `json:"include,omitempty"`      // Non-key columns stored in the index (PostgreSQL)
+	KeyOptions  []*IndexKeyOption `json:"key_options,omitempty"`  // Key columns with a non-default sort order, operator class or prefix length
+	Invalid     bool              `json:"invalid,omitempty"`      // Set on inspected indexes left unusable by a failed concurrent build, Diff rebuilds them
+	RenamedFrom string            `json:"renamed_from,omitempty"` // Previous name of the index, used by Diff to rename it
+}
+
+// IndexKeyOption holds the settings of an index key column
+type IndexKeyOption struct {
+	Column  string `json:"column"`             // Column or expression, as listed in Index.Columns
+	Desc    bool   `json:"desc,omitempty"`     // Sort in descending order
+	Nulls   string `json:"nulls,omitempty"`    // FIRST or LAST, when nulls are not sorted the default way for the order
+	OpClass string `json:"op_class,omitempty"` // Operator class (PostgreSQL)
+	Length  int    `json:"length,omitempty"`   // Prefix length (MySQL)
+}
+
+// KeyOption returns the settings of a key column, the defaults when it has none
+func (i *Index) KeyOption(column string) IndexKeyOption {
+	for _, option := range i.KeyOptions {
+		if option.Column == column {
+			return *option
+		}
+	}
+	return IndexKeyOption{Column: column}
+}
+
+// keyOption returns the settings of a key column for update
+func (i *Index) keyOption(column string) *IndexKeyOption {
+	for _, option := range i.KeyOptions {
+		if option.Column == column {
+			return option
+		}
+	}
+	option := &IndexKeyOption{Column: column}
+	i.KeyOptions = append(i.KeyOptions, option)
+	return option
 }
 
 // IndexOption is a function type for index options
@@ -272,8 +342,85 @@ func Unique(i *Index) {
 	i.Unique = true
 }
 
+// Using sets the index method, e.g. gin, gist, brin or hash
+func Using(method string) IndexOption {
+	return func(i *Index) {
+		i.Method = method
+	}
+}
+
+// Where makes the index a partial index of the rows matching a predicate
+func Where(predicate string) IndexOption {
+	return func(i *Index) {
+		i.Where = predicate
+	}
+}
+
+// Include adds non-key columns to the index
+func Include(columns ...string) IndexOption {
+	return func(i *Index) {
+		i.Include = columns
+	}
+}
+
+// Desc sorts a key column of the index in descending order
+func Desc(column string) IndexOption {
+	return func(i *Index) {
+		i.keyOption(column).Desc = true
+	}
+}
+
+// NullsFirst sorts the nulls of a key column before the other values
+func NullsFirst(column string) IndexOption {
+	return func(i *Index) {
+		i.keyOption(column).Nulls = "FIRST"
+	}
+}
+
+// NullsLast sorts the nulls of a key column after the other values
+func NullsLast(column string) IndexOption {
+	return func(i *Index) {
+		i.keyOption(column).Nulls = "LAST"
+	}
+}
+
+// OpClass sets the operator class of a key column
+func OpClass(column, opClass string) IndexOption {
+	return func(i *Index) {
+		i.keyOption(column).OpClass = opClass
+	}
+}
+
+// PrefixLength indexes only the first length characters of a key column
+func PrefixLength(column string, length int) IndexOption {
+	return func(i *Index) {
+		i.keyOption(column).Length = length
+	}
+}
+
+// IndexRenamedFrom marks an index as renamed from a previous name
+func IndexRenamedFrom(name string) IndexOption {
+	return func(i *Index) {
+		i.RenamedFrom = name
+	}
+}
+
 // PrimaryKey represents a table's primary key
 type PrimaryKey struct {
-	Name    string
-	Columns []string
+	Name    string   `json:"name,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+}
+
+// CheckConstraint represents a CHECK constraint of a table
+type CheckConstraint struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`          // Boolean SQL expression, without the CHECK keyword
+	NotValid   bool   `json:"not_valid,omitempty"` // Not validated against existing rows yet (PostgreSQL NOT VALID)
+}
+
+// UniqueConstraint represents a UNIQUE constraint of a table, as opposed to a
+// unique index
+type UniqueConstraint struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns,omitempty"`
 }
@@ -2,15 +2,15 @@ package schema
 
 // Trigger represents a database trigger
 type Trigger struct {
-	Schema    string // Schema containing the trigger
-	Name      string
-	Table     string   // Table the trigger is attached to
-	Events    []string // INSERT, UPDATE, DELETE
-	Timing    string   // BEFORE, AFTER, or INSTEAD OF
-	ForEach   string   // ROW or STATEMENT
-	When      string   // Optional condition
-	Function  string   // Function to call
-	Arguments []string // Arguments to pass to the function
+	Schema    string   `json:"schema,omitempty"` // Schema containing the trigger
+	Name      string   `json:"name"`
+	Table     string   `json:"table"`               // Table the trigger is attached to
+	Events    []string `json:"events,omitempty"`    // INSERT, UPDATE, DELETE
+	Timing    string   `json:"timing,omitempty"`    // BEFORE, AFTER, or INSTEAD OF
+	ForEach   string   `json:"for_each,omitempty"`  // ROW or STATEMENT
+	When      string   `json:"when,omitempty"`      // Optional condition
+	Function  string   `json:"function,omitempty"`  // Function to call
+	Arguments []string `json:"arguments,omitempty"` // Arguments to pass to the function
 }
 
 // TriggerOption represents an option for creating a trigger
@@ -2,11 +2,11 @@ package schema
 
 // View represents a database view
 type View struct {
-	Schema     string
-	Name       string
-	Definition string
-	Options    []string
-	Columns    []string
+	Schema     string   `json:"schema,omitempty"`
+	Name       string   `json:"name"`
+	Definition string   `json:"definition"`
+	Options    []string `json:"options,omitempty"`
+	Columns    []string `json:"columns,omitempty"`
 }
 
 // ViewOption represents an option for creating a view
@@ -1,5 +1,15 @@
 package sqlite
 
+import "github.com/swiftcarrot/dbx/schema"
+
+func init() {
+	schema.RegisterColumnType("sqlite.numeric", &NumericType{})
+	schema.RegisterColumnType("sqlite.real", &RealType{})
+	schema.RegisterColumnType("sqlite.integer", &IntegerType{})
+	schema.RegisterColumnType("sqlite.text", &TextType{})
+	schema.RegisterColumnType("sqlite.timestamp", &TimestampType{})
+}
+
 // NumericType represents a NUMERIC column type in SQLite
 type NumericType struct{}
 
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestInspectedSchemaRoundTrip(t *testing.T) {
+	db, err := testutil.GetSQLiteTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email VARCHAR(255) NOT NULL UNIQUE,
+			score REAL,
+			balance NUMERIC(10, 2) DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK (balance >= 0)
+		);
+		CREATE TABLE posts (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			body TEXT
+		);
+		CREATE INDEX posts_user_id_idx ON posts (user_id);
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`
+			DROP TABLE IF EXISTS posts;
+			DROP TABLE IF EXISTS users;
+		`)
+		require.NoError(t, err)
+	})
+
+	inspected, err := New().Inspect(db)
+	require.NoError(t, err)
+
+	for _, format := range []schema.Format{schema.FormatJSON, schema.FormatYAML} {
+		data, err := schema.Marshal(inspected, format)
+		require.NoError(t, err)
+
+		decoded, err := schema.Unmarshal(data, format)
+		require.NoError(t, err)
+		require.Equal(t, inspected, decoded, string(format))
+	}
+}
@@ -1,65 +1,103 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
+func init() {
+	dbx.Register("sqlite3", New())
+	dbx.Register("sqlite", New())
+}
+
 // SQLite implements the Dialect interface for SQLite databases
-type SQLite struct{}
+type SQLite struct {
+	filter *schema.Filter // Objects Inspect keeps
+}
+
+// Option configures a SQLite dialect
+type Option func(*SQLite)
+
+// WithFilter makes Inspect leave out the objects that filter does not keep,
+// such as tables owned by other tools
+func WithFilter(filter *schema.Filter) Option {
+	return func(s *SQLite) {
+		s.filter = filter
+	}
+}
 
 // New creates a new SQLite dialect
-func New() *SQLite {
-	return &SQLite{}
+func New(options ...Option) *SQLite {
+	s := &SQLite{}
+	for _, option := range options {
+		option(s)
+	}
+	return s
 }
 
 // Inspect queries the SQLite database and returns its schema
-func (s *SQLite) Inspect(db *sql.DB) (*schema.Schema, error) {
+func (s *SQLite) Inspect(db dbx.Querier) (*schema.Schema, error) {
+	return s.InspectContext(context.Background(), db)
+}
+
+// InspectContext queries the SQLite database and returns its schema, using ctx for every catalog query
+func (s *SQLite) InspectContext(ctx context.Context, db dbx.Querier) (*schema.Schema, error) {
 	// Create a new schema
 	schema := schema.NewSchema()
 
 	// Get tables
-	tables, err := s.InspectTables(db)
+	tables, err := s.InspectTablesContext(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tables: %w", err)
 	}
 
-	// For each table, get its columns, primary key, indexes, and foreign keys
+	// For each table, get its columns, primary key, indexes, foreign keys and constraints
 	for _, tableName := range tables {
 		table := schema.CreateTable(tableName, nil)
 
 		// Get columns
-		if err := s.InspectColumns(db, table); err != nil {
+		if err := s.InspectColumnsContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get columns for table %s: %w", tableName, err)
 		}
 
 		// Get primary key
-		if err := s.InspectPrimaryKey(db, table); err != nil {
+		if err := s.InspectPrimaryKeyContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get primary key for table %s: %w", tableName, err)
 		}
 
 		// Get indexes
-		if err := s.InspectIndexes(db, table); err != nil {
+		if err := s.InspectIndexesContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get indexes for table %s: %w", tableName, err)
 		}
 
 		// Get foreign keys
-		if err := s.InspectForeignKeys(db, table); err != nil {
+		if err := s.InspectForeignKeysContext(ctx, db, table); err != nil {
 			return nil, fmt.Errorf("failed to get foreign keys for table %s: %w", tableName, err)
 		}
+
+		// Get check constraints
+		if err := s.InspectChecksContext(ctx, db, table); err != nil {
+			return nil, fmt.Errorf("failed to get check constraints for table %s: %w", tableName, err)
+		}
+
+		// Get unique constraints
+		if err := s.InspectUniquesContext(ctx, db, table); err != nil {
+			return nil, fmt.Errorf("failed to get unique constraints for table %s: %w", tableName, err)
+		}
 	}
 
 	// Get views
-	if err := s.InspectViews(db, schema); err != nil {
+	if err := s.InspectViewsContext(ctx, db, schema); err != nil {
 		return nil, fmt.Errorf("failed to get views: %w", err)
 	}
 
 	// Get triggers
-	if err := s.InspectTriggers(db, schema); err != nil {
+	if err := s.InspectTriggersContext(ctx, db, schema); err != nil {
 		return nil, fmt.Errorf("failed to get triggers: %w", err)
 	}
 
-	return schema, nil
+	return s.filter.Apply(schema), nil
 }
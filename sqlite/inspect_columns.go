@@ -1,17 +1,24 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectColumns retrieves all columns for a table
-func (s *SQLite) InspectColumns(db *sql.DB, table *schema.Table) error {
-	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table.Name))
+func (s *SQLite) InspectColumns(db dbx.Querier, table *schema.Table) error {
+	return s.InspectColumnsContext(context.Background(), db, table)
+}
+
+// InspectColumnsContext retrieves all columns for a table, using ctx for the catalog queries
+func (s *SQLite) InspectColumnsContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table.Name))
 	if err != nil {
 		return err
 	}
@@ -34,46 +41,12 @@ func (s *SQLite) InspectColumns(db *sql.DB, table *schema.Table) error {
 			col.Default = dfltValue.String
 		}
 
-		// Parse the SQLite type string
-		parsedType, limit, typePrecision, typeScale, err := parseSQLiteType(typeStr)
+		col.Type, err = convertColumnType(typeStr)
 		if err != nil {
 			return fmt.Errorf("failed to parse SQLite type for %s: %w", col.Name, err)
 		}
 
-		// Create the appropriate schema.ColumnType
-		switch strings.ToLower(parsedType) {
-		case "text":
-			col.Type = &TextType{}
-		case "integer", "int":
-			col.Type = &IntegerType{}
-		case "bigint":
-			col.Type = &schema.BigIntType{}
-		case "smallint":
-			col.Type = &schema.SmallIntType{}
-		case "real", "float":
-			col.Type = &schema.FloatType{}
-		case "numeric", "decimal":
-			col.Type = &schema.DecimalType{
-				Precision: typePrecision,
-				Scale:     typeScale,
-			}
-		case "varchar", "character varying":
-			col.Type = &schema.VarcharType{
-				Length: limit,
-			}
-		case "boolean", "bool":
-			col.Type = &schema.BooleanType{}
-		case "date":
-			col.Type = &schema.DateType{}
-		case "time":
-			col.Type = &schema.TimeType{}
-		case "timestamp":
-			col.Type = &schema.TimestampType{}
-		default:
-			col.Type = &schema.TextType{}
-		}
-
-		col.AutoIncrement, err = isAutoIncrement(db, table.Name, col.Name)
+		col.AutoIncrement, err = isAutoIncrement(ctx, db, table.Name, col.Name)
 		if err != nil {
 			return fmt.Errorf("failed to check AUTOINCREMENT for %s: %w", col.Name, err)
 		}
@@ -86,6 +59,46 @@ func (s *SQLite) InspectColumns(db *sql.DB, table *schema.Table) error {
 	return rows.Err()
 }
 
+// convertColumnType returns the column type of a declared SQLite type
+func convertColumnType(typeStr string) (schema.ColumnType, error) {
+	parsedType, limit, typePrecision, typeScale, err := parseSQLiteType(typeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(parsedType) {
+	case "text":
+		return &TextType{}, nil
+	case "integer", "int":
+		return &IntegerType{}, nil
+	case "bigint":
+		return &schema.BigIntType{}, nil
+	case "smallint":
+		return &schema.SmallIntType{}, nil
+	case "real", "float":
+		return &schema.FloatType{}, nil
+	case "numeric", "decimal":
+		return &schema.DecimalType{
+			Precision: typePrecision,
+			Scale:     typeScale,
+		}, nil
+	case "varchar", "character varying":
+		return &schema.VarcharType{
+			Length: limit,
+		}, nil
+	case "boolean", "bool":
+		return &schema.BooleanType{}, nil
+	case "date":
+		return &schema.DateType{}, nil
+	case "time":
+		return &schema.TimeType{}, nil
+	case "timestamp":
+		return &schema.TimestampType{}, nil
+	default:
+		return &schema.TextType{}, nil
+	}
+}
+
 // parseSQLiteType extracts type, limit, precision, and scale from SQLite type string
 func parseSQLiteType(sqliteType string) (typeName string, limit, precision, scale int, err error) {
 	// Convert to uppercase and remove extra spaces
@@ -130,11 +143,11 @@ func parseSQLiteType(sqliteType string) (typeName string, limit, precision, scal
 }
 
 // isAutoIncrement checks if a column is defined with AUTOINCREMENT
-func isAutoIncrement(db *sql.DB, tableName, columnName string) (bool, error) {
+func isAutoIncrement(ctx context.Context, db dbx.Querier, tableName, columnName string) (bool, error) {
 	// Query the table's SQL creation statement
 	query := "SELECT sql FROM sqlite_master WHERE type='table' AND name=?"
 	var sqlStmt string
-	err := db.QueryRow(query, tableName).Scan(&sqlStmt)
+	err := db.QueryRowContext(ctx, query, tableName).Scan(&sqlStmt)
 	if err != nil {
 		return false, fmt.Errorf("failed to get table SQL: %w", err)
 	}
@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var (
+	constraintNamePattern = regexp.MustCompile(`(?is)^CONSTRAINT\s+("[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|\S+)\s+(.*)$`)
+	checkPattern          = regexp.MustCompile(`(?is)^CHECK\s*\((.*)\)$`)
+	uniquePattern         = regexp.MustCompile(`(?is)^UNIQUE\s*\((.*)\)`)
+)
+
+// InspectChecks retrieves the table-level check constraints of a table
+func (s *SQLite) InspectChecks(db dbx.Querier, table *schema.Table) error {
+	return s.InspectChecksContext(context.Background(), db, table)
+}
+
+// InspectChecksContext retrieves the table-level check constraints of a table, using ctx for the catalog queries.
+// SQLite does not record check constraints in its catalog, so they are parsed from the CREATE TABLE statement.
+// Unnamed constraints are named <table>_check<n>.
+func (s *SQLite) InspectChecksContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	definitions, err := s.tableDefinitions(ctx, db, table.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range definitions {
+		name, body := splitConstraintName(definition)
+		match := checkPattern.FindStringSubmatch(body)
+		if match == nil {
+			continue
+		}
+		if name == "" {
+			name = fmt.Sprintf("%s_check%d", table.Name, len(table.Checks)+1)
+		}
+		table.Check(name, strings.TrimSpace(match[1]))
+	}
+
+	return nil
+}
+
+// InspectUniques retrieves the unique constraints of a table
+func (s *SQLite) InspectUniques(db dbx.Querier, table *schema.Table) error {
+	return s.InspectUniquesContext(context.Background(), db, table)
+}
+
+// InspectUniquesContext retrieves the unique constraints of a table, using ctx for the catalog queries.
+// Constraint names are taken from the CREATE TABLE statement, unnamed constraints are named <table>_<columns>_key.
+func (s *SQLite) InspectUniquesContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
+	definitions, err := s.tableDefinitions(ctx, db, table.Name)
+	if err != nil {
+		return err
+	}
+
+	names := map[string]string{}
+	for _, definition := range definitions {
+		name, body := splitConstraintName(definition)
+		match := uniquePattern.FindStringSubmatch(body)
+		if match == nil || name == "" {
+			continue
+		}
+		var columns []string
+		for _, column := range splitDefinitions(match[1]) {
+			columns = append(columns, unquoteIdentifier(strings.Fields(column)[0]))
+		}
+		names[strings.Join(columns, ",")] = name
+	}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA index_list("+quoteIdentifier(table.Name)+")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var indexes []string
+	for rows.Next() {
+		var seq int
+		var name string
+		var unique bool
+		var origin, partial string
+
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			return err
+		}
+		if origin == "u" {
+			indexes = append(indexes, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// index_list returns the most recent index first
+	for i := len(indexes) - 1; i >= 0; i-- {
+		columns, err := s.indexColumns(ctx, db, indexes[i])
+		if err != nil {
+			return err
+		}
+		name, ok := names[strings.Join(columns, ",")]
+		if !ok {
+			name = fmt.Sprintf("%s_%s_key", table.Name, strings.Join(columns, "_"))
+		}
+		table.Unique(name, columns...)
+	}
+
+	return nil
+}
+
+// indexColumns returns the columns of an index in order
+func (s *SQLite) indexColumns(ctx context.Context, db dbx.Querier, name string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA index_info("+quoteIdentifier(name)+")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var seqno, cid int
+		var column string
+		if err := rows.Scan(&seqno, &cid, &column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// tableDefinitions returns the column and constraint definitions of a table's CREATE TABLE statement
+func (s *SQLite) tableDefinitions(ctx context.Context, db dbx.Querier, tableName string) ([]string, error) {
+	var sql string
+	query := "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if err := db.QueryRowContext(ctx, query, tableName).Scan(&sql); err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("unable to parse definition of table %s", tableName)
+	}
+
+	return splitDefinitions(sql[start+1 : end]), nil
+}
+
+// splitDefinitions splits a list at commas that are not nested in parentheses or quotes
+func splitDefinitions(list string) []string {
+	var definitions []string
+	var quote rune
+	depth, start := 0, 0
+
+	for i, r := range list {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			definitions = append(definitions, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+
+	if last := strings.TrimSpace(list[start:]); last != "" {
+		definitions = append(definitions, last)
+	}
+	return definitions
+}
+
+// splitConstraintName separates the CONSTRAINT name prefix from a definition
+func splitConstraintName(definition string) (string, string) {
+	match := constraintNamePattern.FindStringSubmatch(definition)
+	if match == nil {
+		return "", definition
+	}
+	return unquoteIdentifier(match[1]), strings.TrimSpace(match[2])
+}
+
+// unquoteIdentifier removes the quotes around an identifier
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 {
+		switch {
+		case name[0] == '"' && name[len(name)-1] == '"',
+			name[0] == '`' && name[len(name)-1] == '`',
+			name[0] == '[' && name[len(name)-1] == ']':
+			return name[1 : len(name)-1]
+		}
+	}
+	return name
+}
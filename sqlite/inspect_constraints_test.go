@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestInspectConstraints(t *testing.T) {
+	db, err := testutil.GetSQLiteTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE test_constraint_products (
+			id INTEGER PRIMARY KEY,
+			sku TEXT NOT NULL UNIQUE,
+			vendor TEXT NOT NULL,
+			price INTEGER NOT NULL,
+			discount INTEGER,
+			CONSTRAINT products_vendor_sku_key UNIQUE (vendor, sku),
+			CONSTRAINT products_price_check CHECK (price > 0),
+			CHECK (discount IS NULL OR (discount >= 0 AND discount < price))
+		);
+
+		CREATE INDEX idx_products_price ON test_constraint_products (price);
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS test_constraint_products`)
+		require.NoError(t, err)
+	})
+
+	s := New()
+	table := &schema.Table{
+		Name: "test_constraint_products",
+	}
+	require.NoError(t, s.InspectChecks(db, table))
+	require.NoError(t, s.InspectUniques(db, table))
+	require.NoError(t, s.InspectIndexes(db, table))
+
+	require.Equal(t, []*schema.CheckConstraint{
+		{Name: "products_price_check", Expression: "price > 0"},
+		{Name: "test_constraint_products_check2", Expression: "discount IS NULL OR (discount >= 0 AND discount < price)"},
+	}, table.Checks)
+	require.Equal(t, []*schema.UniqueConstraint{
+		{Name: "test_constraint_products_sku_key", Columns: []string{"sku"}},
+		{Name: "products_vendor_sku_key", Columns: []string{"vendor", "sku"}},
+	}, table.Uniques)
+	require.Equal(t, []*schema.Index{
+		{Name: "idx_products_price", Columns: []string{"price"}},
+	}, table.Indexes)
+}
+
+func TestSplitDefinitions(t *testing.T) {
+	require.Equal(t, []string{
+		"id INTEGER",
+		"name TEXT DEFAULT 'a,b'",
+		"CHECK (length(name) IN (1, 2))",
+	}, splitDefinitions("id INTEGER, name TEXT DEFAULT 'a,b',\n CHECK (length(name) IN (1, 2))"))
+}
@@ -1,16 +1,22 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectForeignKeys retrieves all foreign keys for a table
-func (s *SQLite) InspectForeignKeys(db *sql.DB, table *schema.Table) error {
+func (s *SQLite) InspectForeignKeys(db dbx.Querier, table *schema.Table) error {
+	return s.InspectForeignKeysContext(context.Background(), db, table)
+}
+
+// InspectForeignKeysContext retrieves all foreign keys for a table, using ctx for the catalog queries
+func (s *SQLite) InspectForeignKeysContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := "PRAGMA foreign_key_list(" + table.Name + ")"
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -1,75 +1,133 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
+var (
+	indexKeyPattern   = regexp.MustCompile(`(?is)^(.*?)(\s+COLLATE\s+\S+)?(\s+(ASC|DESC))?$`)
+	identifierPattern = regexp.MustCompile(`^("[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][A-Za-z0-9_]*)$`)
+	indexWherePattern = regexp.MustCompile(`(?is)^WHERE\s+(.*?);?$`)
+)
+
 // InspectIndexes retrieves all indexes for a table
-func (s *SQLite) InspectIndexes(db *sql.DB, table *schema.Table) error {
+func (s *SQLite) InspectIndexes(db dbx.Querier, table *schema.Table) error {
+	return s.InspectIndexesContext(context.Background(), db, table)
+}
+
+// InspectIndexesContext retrieves all indexes for a table, using ctx for the catalog queries.
+// Key expressions, sort order and partial index predicates are parsed from the CREATE INDEX statement.
+func (s *SQLite) InspectIndexesContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	// First get the list of indexes
-	query := "PRAGMA index_list(" + table.Name + ")"
+	query := "PRAGMA index_list(" + quoteIdentifier(table.Name) + ")"
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	var indexes []*schema.Index
 	for rows.Next() {
 		var seq int
 		var name string
 		var unique bool
-		var origin, partial string // origin and partial are not used directly
+		var origin, partial string // partial is not used directly
 
 		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
 			return err
 		}
 
-		// Skip SQLite internal indexes which typically start with "sqlite_"
-		if name == "sqlite_autoindex_"+table.Name+"_1" ||
-			name == "sqlite_autoindex_"+table.Name+"_2" {
-			// Skip primary key and unique constraint auto-indexes
+		// Skip the indexes SQLite creates for primary key and unique constraints
+		if origin != "c" {
 			continue
 		}
 
-		// For each index, get its columns
-		columnQuery := fmt.Sprintf("PRAGMA index_info(%s)", name)
-		columnRows, err := db.Query(columnQuery)
-		if err != nil {
+		indexes = append(indexes, &schema.Index{Name: name, Unique: unique})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		var sql string
+		query := "SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?"
+		if err := db.QueryRowContext(ctx, query, index.Name).Scan(&sql); err != nil {
+			return err
+		}
+		if err := parseIndex(sql, index); err != nil {
 			return err
 		}
 
-		var columns []string
-		for columnRows.Next() {
-			var seqno, cid int
-			var colName string
+		// Only add the index if it has columns
+		if len(index.Columns) > 0 {
+			table.Indexes = append(table.Indexes, index)
+		}
+	}
 
-			if err := columnRows.Scan(&seqno, &cid, &colName); err != nil {
-				columnRows.Close()
-				return err
-			}
+	return nil
+}
 
-			columns = append(columns, colName)
-		}
+// parseIndex reads the key columns, sort order and predicate of an index from its CREATE INDEX statement
+func parseIndex(sql string, index *schema.Index) error {
+	start := strings.Index(sql, "(")
+	end := closingParen(sql, start)
+	if start == -1 || end == -1 {
+		return fmt.Errorf("unable to parse definition of index %s", index.Name)
+	}
 
-		columnRows.Close()
-		if err := columnRows.Err(); err != nil {
-			return err
+	for _, key := range splitDefinitions(sql[start+1 : end]) {
+		match := indexKeyPattern.FindStringSubmatch(key)
+		column := strings.TrimSpace(match[1])
+		if identifierPattern.MatchString(column) {
+			column = unquoteIdentifier(column)
+		} else if !strings.HasPrefix(column, "(") {
+			column = "(" + column + ")"
 		}
+		index.Columns = append(index.Columns, column)
+		if strings.EqualFold(match[4], "DESC") {
+			schema.Desc(column)(index)
+		}
+	}
 
-		// Only add the index if it has columns
-		if len(columns) > 0 {
-			index := &schema.Index{
-				Name:    name,
-				Columns: columns,
-				Unique:  unique,
+	if match := indexWherePattern.FindStringSubmatch(strings.TrimSpace(sql[end+1:])); match != nil {
+		index.Where = strings.TrimSpace(match[1])
+	}
+
+	return nil
+}
+
+// closingParen returns the position of the parenthesis closing the one at open, or -1
+func closingParen(sql string, open int) int {
+	if open < 0 {
+		return -1
+	}
+	var quote rune
+	depth := 0
+	for i, r := range sql[open:] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				return open + i
 			}
-			table.Indexes = append(table.Indexes, index)
 		}
 	}
-
-	return rows.Err()
+	return -1
 }
@@ -55,3 +55,35 @@ func TestInspectIndexes(t *testing.T) {
 		},
 	}, table.Indexes)
 }
+
+func TestInspectIndexesWithOptions(t *testing.T) {
+	db, err := testutil.GetSQLiteTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE test_index_options (
+			id INTEGER PRIMARY KEY,
+			email TEXT NOT NULL,
+			created_at TEXT,
+			deleted_at TEXT
+		);
+
+		CREATE INDEX idx_options_active ON test_index_options (lower(email), "created_at" DESC) WHERE deleted_at IS NULL;
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS test_index_options`)
+		require.NoError(t, err)
+	})
+
+	table := &schema.Table{Name: "test_index_options"}
+	require.NoError(t, New().InspectIndexes(db, table))
+	require.Equal(t, []*schema.Index{
+		{
+			Name:       "idx_options_active",
+			Columns:    []string{"(lower(email))", "created_at"},
+			Where:      "deleted_at IS NULL",
+			KeyOptions: []*schema.IndexKeyOption{{Column: "created_at", Desc: true}},
+		},
+	}, table.Indexes)
+}
@@ -1,16 +1,22 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectPrimaryKey retrieves the primary key for a table
-func (s *SQLite) InspectPrimaryKey(db *sql.DB, table *schema.Table) error {
+func (s *SQLite) InspectPrimaryKey(db dbx.Querier, table *schema.Table) error {
+	return s.InspectPrimaryKeyContext(context.Background(), db, table)
+}
+
+// InspectPrimaryKeyContext retrieves the primary key for a table, using ctx for the catalog queries
+func (s *SQLite) InspectPrimaryKeyContext(ctx context.Context, db dbx.Querier, table *schema.Table) error {
 	query := "PRAGMA table_info(" + table.Name + ")"
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -1,18 +1,25 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
+
+	"github.com/swiftcarrot/dbx"
 )
 
 // InspectTables retrieves all table names from the database
-func (s *SQLite) InspectTables(db *sql.DB) ([]string, error) {
+func (s *SQLite) InspectTables(db dbx.Querier) ([]string, error) {
+	return s.InspectTablesContext(context.Background(), db)
+}
+
+// InspectTablesContext retrieves all table names from the database, using ctx for the catalog queries
+func (s *SQLite) InspectTablesContext(ctx context.Context, db dbx.Querier) ([]string, error) {
 	query := `
 		SELECT name FROM sqlite_master
 		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
 		ORDER BY name
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
 )
 
 func TestInspectTables(t *testing.T) {
@@ -34,3 +36,58 @@ func TestInspectTables(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []string{"test_table_1", "test_table_2"}, tables)
 }
+
+func TestInspectTablesInTransaction(t *testing.T) {
+	db, err := testutil.GetSQLiteTestConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE test_table_tx (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	s := New()
+	tables, err := s.InspectTablesContext(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"test_table_tx"}, tables)
+
+	require.NoError(t, tx.Rollback())
+
+	tables, err = s.InspectTables(db)
+	require.NoError(t, err)
+	require.Empty(t, tables)
+}
+
+func TestInspectWithFilter(t *testing.T) {
+	db, err := testutil.GetSQLiteTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY);
+		CREATE TABLE _tmp_users (id INTEGER PRIMARY KEY);
+		CREATE TABLE schema_migrations (version TEXT PRIMARY KEY);
+	`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`
+			DROP TABLE IF EXISTS users;
+			DROP TABLE IF EXISTS _tmp_users;
+			DROP TABLE IF EXISTS schema_migrations;
+		`)
+		require.NoError(t, err)
+	})
+
+	s := New(WithFilter(schema.NewFilter(
+		schema.IgnoreObjects("_tmp_*"),
+		schema.IgnoreObjects("schema_migrations", schema.ObjectTable),
+	)))
+	sch, err := s.Inspect(db)
+	require.NoError(t, err)
+	require.Len(t, sch.Tables, 1)
+	require.Equal(t, "users", sch.Tables[0].Name)
+}
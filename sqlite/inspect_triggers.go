@@ -1,22 +1,28 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
 	"regexp"
 	"strings"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectTriggers retrieves all triggers from the database
-func (s *SQLite) InspectTriggers(db *sql.DB, schm *schema.Schema) error {
+func (s *SQLite) InspectTriggers(db dbx.Querier, schm *schema.Schema) error {
+	return s.InspectTriggersContext(context.Background(), db, schm)
+}
+
+// InspectTriggersContext retrieves all triggers from the database, using ctx for the catalog queries
+func (s *SQLite) InspectTriggersContext(ctx context.Context, db dbx.Querier, schm *schema.Schema) error {
 	query := `
 		SELECT name, tbl_name, sql FROM sqlite_master
 		WHERE type = 'trigger'
 		ORDER BY name
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -1,20 +1,26 @@
 package sqlite
 
 import (
-	"database/sql"
+	"context"
 
+	"github.com/swiftcarrot/dbx"
 	"github.com/swiftcarrot/dbx/schema"
 )
 
 // InspectViews retrieves all views from the database
-func (s *SQLite) InspectViews(db *sql.DB, schm *schema.Schema) error {
+func (s *SQLite) InspectViews(db dbx.Querier, schm *schema.Schema) error {
+	return s.InspectViewsContext(context.Background(), db, schm)
+}
+
+// InspectViewsContext retrieves all views from the database, using ctx for the catalog queries
+func (s *SQLite) InspectViewsContext(ctx context.Context, db dbx.Querier, schm *schema.Schema) error {
 	query := `
 		SELECT name, sql FROM sqlite_master
 		WHERE type = 'view'
 		ORDER BY name
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -31,7 +37,7 @@ func (s *SQLite) InspectViews(db *sql.DB, schm *schema.Schema) error {
 
 		// Get the view columns by executing a query against the view
 		columnQuery := "PRAGMA table_info(" + name + ")"
-		columnRows, err := db.Query(columnQuery)
+		columnRows, err := db.QueryContext(ctx, columnQuery)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/lockutil"
+)
+
+var _ dbx.Locker = (*SQLite)(nil)
+
+// Lock acquires a lock file named after the database file and the lock name,
+// created exclusively and holding the host and pid of the owner. A lock file
+// left behind by a crashed process must be removed by hand. In-memory
+// databases are private to the process and are not locked.
+func (s *SQLite) Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error) {
+	var (
+		seq    int
+		dbName string
+		file   string
+	)
+	if err := db.QueryRowContext(ctx, "PRAGMA database_list").Scan(&seq, &dbName, &file); err != nil {
+		return nil, fmt.Errorf("failed to get database file: %w", err)
+	}
+	if file == "" {
+		return func() error { return nil }, nil
+	}
+
+	path := file + "." + name + ".lock"
+	err := lockutil.Poll(ctx, timeout, func() (bool, error) {
+		return createLockFile(path)
+	})
+	if err != nil {
+		if errors.Is(err, lockutil.ErrTimeout) {
+			holder, _ := os.ReadFile(path)
+			err = &dbx.LockTimeoutError{Name: name, Timeout: timeout, Holder: strings.TrimSpace(string(holder))}
+		}
+		return nil, err
+	}
+
+	return func() error {
+		return os.Remove(path)
+	}, nil
+}
+
+// createLockFile creates the lock file, reporting false if it already exists
+func createLockFile(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	host, _ := os.Hostname()
+	_, err = fmt.Fprintf(f, "pid %d on %s\n", os.Getpid(), host)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return false, err
+	}
+	return true, nil
+}
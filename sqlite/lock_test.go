@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+)
+
+func TestLock(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lock.db"))
+	require.NoError(t, err)
+
+	s := New()
+	unlock, err := s.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+
+	host, _ := os.Hostname()
+	_, err = s.Lock(context.Background(), db, "schema_migrations", 10*time.Millisecond)
+	require.Equal(t, &dbx.LockTimeoutError{
+		Name:    "schema_migrations",
+		Timeout: 10 * time.Millisecond,
+		Holder:  fmt.Sprintf("pid %d on %s", os.Getpid(), host),
+	}, err)
+
+	require.NoError(t, unlock())
+
+	unlock, err = s.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
+
+func TestLockCanceled(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lock.db"))
+	require.NoError(t, err)
+
+	s := New()
+	unlock, err := s.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, unlock())
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	t.Cleanup(cancel)
+
+	_, err = s.Lock(ctx, db, "schema_migrations", 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLockInMemory(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	s := New()
+	unlock, err := s.Lock(context.Background(), db, "schema_migrations", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
@@ -0,0 +1,454 @@
+package sqlite
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/ddl"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var _ dbx.Parser = (*SQLite)(nil)
+
+var parseOptions = ddl.Options{Brackets: true, TriggerBodies: true}
+
+// ParseSQL parses the statements of a SQL file, such as the output of the
+// sqlite3 .schema command, into a schema as Inspect would return it. Tables,
+// indexes, constraints, views and triggers are read. Pragmas and transactions
+// are skipped, the columns of a view are only read from its column list and
+// other statements are reported in a *dbx.ParseError.
+func (s *SQLite) ParseSQL(sql string) (*schema.Schema, error) {
+	p := &ddlParser{schema: schema.NewSchema()}
+	err := ddl.Parse(sql, parseOptions, p.statement)
+	return s.filter.Apply(p.schema), err
+}
+
+// ddlParser builds a schema from the statements of a SQL file
+type ddlParser struct {
+	schema *schema.Schema
+}
+
+func (p *ddlParser) statement(q *ddl.Parser) error {
+	switch {
+	case q.Is("PRAGMA"), q.Is("BEGIN"), q.Is("COMMIT"), q.Is("END"):
+		// Connection settings and transactions are not part of the schema
+		q.Rest()
+		return nil
+	case q.Accept("CREATE"):
+		q.AcceptAny("TEMP", "TEMPORARY")
+		switch {
+		case q.Accept("TABLE"):
+			return p.createTable(q)
+		case q.Is("INDEX"), q.Is("UNIQUE", "INDEX"):
+			return p.createIndex(q)
+		case q.Accept("VIEW"):
+			return p.createView(q)
+		case q.Accept("TRIGGER"):
+			return p.createTrigger(q)
+		}
+	}
+	return q.Unsupported()
+}
+
+func (p *ddlParser) findTable(name string) (*schema.Table, error) {
+	for _, table := range p.schema.Tables {
+		if strings.EqualFold(table.Name, name) {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("table %s does not exist", name)
+}
+
+// objectName reads the name of a table, index, view or trigger, which may be
+// qualified with its database
+func objectName(q *ddl.Parser) (string, error) {
+	q.Accept("IF", "NOT", "EXISTS")
+	_, name, err := q.Name()
+	return name, err
+}
+
+func (p *ddlParser) createTable(q *ddl.Parser) error {
+	name, err := objectName(q)
+	if err != nil {
+		return err
+	}
+	if q.Is("AS") {
+		return fmt.Errorf("CREATE TABLE AS is not supported")
+	}
+	items, err := q.Items()
+	if err != nil {
+		return err
+	}
+	for !q.Done() {
+		if _, ok := q.AcceptAny("WITHOUT", "ROWID", "STRICT", ","); !ok {
+			return fmt.Errorf("unexpected %q", q.Peek().Text)
+		}
+	}
+	// Inspect leaves out the tables of SQLite itself, such as sqlite_sequence
+	if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+		return nil
+	}
+	if _, err := p.findTable(name); err == nil {
+		return fmt.Errorf("table %s already exists", name)
+	}
+
+	table := p.schema.CreateTable(name, nil)
+	for _, item := range items {
+		r := ddl.NewParser(item, parseOptions)
+		if isTableConstraint(r) {
+			err = tableConstraint(r, table)
+		} else {
+			err = column(r, table)
+		}
+		if err == nil && !r.Done() {
+			err = fmt.Errorf("unexpected %q", r.Peek().Text)
+		}
+		if err != nil {
+			p.schema.Tables = p.schema.Tables[:len(p.schema.Tables)-1]
+			return err
+		}
+	}
+	// SQLite lists the foreign keys of a table last to first, keep them in
+	// the order Inspect returns them
+	slices.Reverse(table.ForeignKeys)
+	return nil
+}
+
+func isTableConstraint(q *ddl.Parser) bool {
+	return q.Is("CONSTRAINT") || q.Is("PRIMARY", "KEY") || q.Is("UNIQUE") || q.Is("CHECK") || q.Is("FOREIGN", "KEY")
+}
+
+// columnStops are the keywords that end the type of a column definition
+var columnStops = []string{
+	"CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK", "DEFAULT", "COLLATE", "REFERENCES", "GENERATED", "AS",
+}
+
+func column(q *ddl.Parser, table *schema.Table) error {
+	name, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	columnType, err := convertColumnType(q.Until(columnStops...))
+	if err != nil {
+		return err
+	}
+	column := table.Column(name, columnType, schema.Nullable)
+
+	for !q.Done() {
+		if q.Accept("CONSTRAINT") {
+			if _, err := q.Ident(); err != nil {
+				return err
+			}
+		}
+		switch {
+		case q.Accept("PRIMARY", "KEY"):
+			q.AcceptAny("ASC", "DESC")
+			err = conflictClause(q)
+			table.SetPrimaryKey(table.Name+"_pkey", []string{name})
+			column.AutoIncrement = q.Accept("AUTOINCREMENT")
+		case q.Accept("NOT", "NULL"):
+			column.Nullable = false
+			err = conflictClause(q)
+		case q.Accept("NULL"):
+			column.Nullable = true
+		case q.Accept("UNIQUE"):
+			err = conflictClause(q)
+			table.Unique(fmt.Sprintf("%s_%s_key", table.Name, name), name)
+		case q.Accept("CHECK"):
+			var expression string
+			if expression, err = q.Group(); err == nil {
+				table.Check(fmt.Sprintf("%s_check%d", table.Name, len(table.Checks)+1), strings.TrimSpace(expression))
+			}
+		case q.Accept("DEFAULT"):
+			column.Default, err = defaultValue(q)
+		case q.Accept("COLLATE"):
+			_, err = q.Ident()
+		case q.Accept("REFERENCES"):
+			err = references(q, table, []string{name})
+		case q.Is("GENERATED"), q.Is("AS"):
+			return fmt.Errorf("generated columns are not supported")
+		default:
+			return fmt.Errorf("unexpected %q", q.Peek().Text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflictClause reads the ON CONFLICT clause of a constraint, which is not
+// part of the schema
+func conflictClause(q *ddl.Parser) error {
+	if !q.Accept("ON", "CONFLICT") {
+		return nil
+	}
+	if _, ok := q.AcceptAny("ROLLBACK", "ABORT", "FAIL", "IGNORE", "REPLACE"); !ok {
+		return fmt.Errorf("expected conflict resolution, found %q", q.Peek().Text)
+	}
+	return nil
+}
+
+// defaultValue reads the default of a column as written, which is how
+// SQLite reports it to Inspect
+func defaultValue(q *ddl.Parser) (string, error) {
+	if q.Is("(") {
+		expression, err := q.Group()
+		if err != nil {
+			return "", err
+		}
+		return expression, nil
+	}
+	sign := ""
+	if q.Is("-") || q.Is("+") {
+		sign = q.Next().Text
+	}
+	token := q.Next()
+	if token.Kind == ddl.EOF {
+		return "", fmt.Errorf("expected default value")
+	}
+	return sign + token.Text, nil
+}
+
+// tableConstraint reads a primary key, unique, check or foreign key
+// constraint of a table
+func tableConstraint(q *ddl.Parser, table *schema.Table) error {
+	name := ""
+	if q.Accept("CONSTRAINT") {
+		var err error
+		if name, err = q.Ident(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case q.Accept("PRIMARY", "KEY"):
+		columns, err := keyColumns(q)
+		if err != nil {
+			return err
+		}
+		table.SetPrimaryKey(table.Name+"_pkey", columns)
+		return conflictClause(q)
+	case q.Accept("UNIQUE"):
+		columns, err := keyColumns(q)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = fmt.Sprintf("%s_%s_key", table.Name, strings.Join(columns, "_"))
+		}
+		table.Unique(name, columns...)
+		return conflictClause(q)
+	case q.Accept("CHECK"):
+		expression, err := q.Group()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = fmt.Sprintf("%s_check%d", table.Name, len(table.Checks)+1)
+		}
+		table.Check(name, strings.TrimSpace(expression))
+		return nil
+	case q.Accept("FOREIGN", "KEY"):
+		columns, err := q.Names()
+		if err != nil {
+			return err
+		}
+		if err := q.Expect("REFERENCES"); err != nil {
+			return err
+		}
+		return references(q, table, columns)
+	}
+	return fmt.Errorf("unsupported table constraint %q", q.Peek().Text)
+}
+
+// keyColumns reads the columns of a primary key or unique constraint, which
+// may have a collation and sort order
+func keyColumns(q *ddl.Parser) ([]string, error) {
+	items, err := q.Items()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(items))
+	for _, item := range items {
+		r := ddl.NewParser(item, parseOptions)
+		column, err := r.Ident()
+		if err != nil {
+			return nil, err
+		}
+		if r.Accept("COLLATE") {
+			if _, err := r.Ident(); err != nil {
+				return nil, err
+			}
+		}
+		r.AcceptAny("ASC", "DESC")
+		if !r.Done() {
+			return nil, fmt.Errorf("unexpected %q in key", r.Peek().Text)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// references reads the referenced table and columns and the actions of a
+// foreign key. SQLite does not name foreign keys and reports NO ACTION when
+// an action is not given.
+func references(q *ddl.Parser, table *schema.Table, columns []string) error {
+	refTable, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	var refColumns []string
+	if q.Is("(") {
+		if refColumns, err = q.Names(); err != nil {
+			return err
+		}
+	}
+	fk := &schema.ForeignKey{
+		Columns:    columns,
+		RefTable:   refTable,
+		RefColumns: refColumns,
+		OnDelete:   "NO ACTION",
+		OnUpdate:   "NO ACTION",
+	}
+	for !q.Done() {
+		switch {
+		case q.Accept("ON", "DELETE"):
+			fk.OnDelete, err = referentialAction(q)
+		case q.Accept("ON", "UPDATE"):
+			fk.OnUpdate, err = referentialAction(q)
+		case q.Accept("MATCH"):
+			_, err = q.Ident()
+		case q.Accept("NOT", "DEFERRABLE"), q.Accept("DEFERRABLE"):
+			q.Accept("INITIALLY", "DEFERRED")
+			q.Accept("INITIALLY", "IMMEDIATE")
+		default:
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	table.ForeignKeys = append(table.ForeignKeys, fk)
+	return nil
+}
+
+func referentialAction(q *ddl.Parser) (string, error) {
+	for _, action := range [][]string{{"CASCADE"}, {"RESTRICT"}, {"SET", "NULL"}, {"SET", "DEFAULT"}, {"NO", "ACTION"}} {
+		if q.Accept(action...) {
+			return strings.Join(action, " "), nil
+		}
+	}
+	return "", fmt.Errorf("expected referential action, found %q", q.Peek().Text)
+}
+
+func (p *ddlParser) createIndex(q *ddl.Parser) error {
+	unique := q.Accept("UNIQUE")
+	if err := q.Expect("INDEX"); err != nil {
+		return err
+	}
+	name, err := objectName(q)
+	if err != nil {
+		return err
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	tableName, err := q.Ident()
+	if err != nil {
+		return err
+	}
+	table, err := p.findTable(tableName)
+	if err != nil {
+		return err
+	}
+	index := &schema.Index{Name: name, Unique: unique}
+	if err := parseIndex(q.Rest(), index); err != nil {
+		return err
+	}
+	table.Indexes = append(table.Indexes, index)
+	return nil
+}
+
+func (p *ddlParser) createView(q *ddl.Parser) error {
+	name, err := objectName(q)
+	if err != nil {
+		return err
+	}
+	view := &schema.View{Name: name}
+	if q.Is("(") {
+		if view.Columns, err = q.Names(); err != nil {
+			return err
+		}
+	}
+	if err := q.Expect("AS"); err != nil {
+		return err
+	}
+	view.Definition = q.Rest()
+	p.schema.Views = append(p.schema.Views, view)
+	return nil
+}
+
+func (p *ddlParser) createTrigger(q *ddl.Parser) error {
+	name, err := objectName(q)
+	if err != nil {
+		return err
+	}
+	trigger := &schema.Trigger{Name: name, ForEach: "STATEMENT", Arguments: []string{}}
+	if q.Accept("INSTEAD", "OF") {
+		trigger.Timing = "INSTEAD OF"
+	} else {
+		trigger.Timing, _ = q.AcceptAny("BEFORE", "AFTER")
+	}
+	event, ok := q.AcceptAny("INSERT", "UPDATE", "DELETE")
+	if !ok {
+		return fmt.Errorf("expected trigger event, found %q", q.Peek().Text)
+	}
+	trigger.Events = []string{event}
+	if event == "UPDATE" && q.Accept("OF") {
+		for {
+			if _, err := q.Ident(); err != nil {
+				return err
+			}
+			if !q.Accept(",") {
+				break
+			}
+		}
+	}
+	if err := q.Expect("ON"); err != nil {
+		return err
+	}
+	if trigger.Table, err = q.Ident(); err != nil {
+		return err
+	}
+	if q.Accept("FOR", "EACH", "ROW") {
+		trigger.ForEach = "ROW"
+	}
+	if q.Accept("WHEN") {
+		trigger.When = unwrap(q.Until("BEGIN"))
+	}
+	if !q.Is("BEGIN") {
+		return fmt.Errorf("expected BEGIN, found %q", q.Peek().Text)
+	}
+	// SQLite triggers run the statements of their body instead of calling a
+	// function, Inspect does not read them
+	q.Rest()
+	p.schema.Triggers = append(p.schema.Triggers, trigger)
+	return nil
+}
+
+// unwrap removes the parentheses around a whole expression
+func unwrap(expression string) string {
+	q := ddl.NewParser(expression, parseOptions)
+	if !q.Is("(") {
+		return expression
+	}
+	inner, err := q.Group()
+	if err != nil || !q.Done() {
+		return expression
+	}
+	return inner
+}
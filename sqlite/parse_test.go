@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+const parseTestSQL = `CREATE TABLE parse_users (
+  id INTEGER PRIMARY KEY,
+  email VARCHAR(255) NOT NULL UNIQUE,
+  name TEXT DEFAULT 'anonymous',
+  score REAL DEFAULT -1,
+  price DECIMAL(10,2),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT positive_score CHECK (score > 0),
+  CHECK (price >= 0)
+);
+CREATE TABLE parse_posts (
+  id INTEGER NOT NULL,
+  user_id INTEGER REFERENCES parse_users(id) ON DELETE CASCADE,
+  title TEXT,
+  PRIMARY KEY (id)
+);
+CREATE INDEX parse_posts_title ON parse_posts (lower(title), id DESC) WHERE title IS NOT NULL;
+CREATE TRIGGER parse_posts_touch AFTER UPDATE ON parse_posts FOR EACH ROW WHEN (NEW.title IS NULL) BEGIN
+  UPDATE parse_posts SET title = 'untitled' WHERE id = NEW.id;
+END;`
+
+func TestParseSQL(t *testing.T) {
+	db, err := testutil.GetSQLiteTestConn()
+	require.NoError(t, err)
+
+	_, err = db.Exec(parseTestSQL)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`
+			DROP TRIGGER IF EXISTS parse_posts_touch;
+			DROP TABLE IF EXISTS parse_posts;
+			DROP TABLE IF EXISTS parse_users;
+		`)
+		require.NoError(t, err)
+	})
+
+	s, err := New().ParseSQL(parseTestSQL)
+	require.NoError(t, err)
+
+	inspected, err := New().Inspect(db)
+	require.NoError(t, err)
+	require.Empty(t, schema.Diff(inspected, s))
+
+	require.Equal(t, []*schema.Trigger{
+		{
+			Name:      "parse_posts_touch",
+			Table:     "parse_posts",
+			Events:    []string{"UPDATE"},
+			Timing:    "AFTER",
+			ForEach:   "ROW",
+			When:      "NEW.title IS NULL",
+			Arguments: []string{},
+		},
+	}, s.Triggers)
+}
+
+func TestParseSQLUnsupported(t *testing.T) {
+	s, err := New().ParseSQL(`PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+CREATE TABLE sqlite_sequence(name,seq);
+CREATE VIRTUAL TABLE docs USING fts5(body);
+CREATE TABLE totals (a INTEGER, b INTEGER, c INTEGER GENERATED ALWAYS AS (a + b));
+COMMIT;
+`)
+
+	var parseErr *dbx.ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, []dbx.UnsupportedStatement{
+		{Line: 5, Statement: "CREATE VIRTUAL TABLE docs USING fts5(body)", Reason: "unsupported statement CREATE VIRTUAL"},
+		{Line: 6, Statement: "CREATE TABLE totals (a INTEGER, b INTEGER, c INTEGER GENERATED ALWAYS AS (a + b))", Reason: "generated columns are not supported"},
+	}, parseErr.Unsupported)
+
+	require.Len(t, s.Tables, 1)
+	require.Equal(t, &schema.Column{Name: "id", Type: &IntegerType{}, Nullable: true, AutoIncrement: true}, s.Tables[0].Columns[0])
+}
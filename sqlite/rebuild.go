@@ -0,0 +1,176 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+var (
+	_ dbx.ConnPreparer     = (*SQLite)(nil)
+	_ dbx.HazardClassifier = (*SQLite)(nil)
+)
+
+// rebuildTable generates the table rebuild procedure recommended by SQLite for
+// schema changes that ALTER TABLE cannot make: create a new table with the
+// target definition, copy the data, drop the old table, rename the new one and
+// recreate the indexes, triggers and views that depend on it.
+// PRAGMA foreign_keys has no effect inside a transaction, so foreign key
+// enforcement is turned off by PrepareConn before the statements run,
+// otherwise dropping the old table deletes or fails on the rows referencing it.
+func (s *SQLite) rebuildTable(rebuild *schema.TableRebuild) (string, error) {
+	if rebuild.Superseded {
+		return "", nil
+	}
+
+	table := rebuild.Table
+	if table == nil {
+		return "", fmt.Errorf("table definition is nil")
+	}
+
+	newTable := *table
+	newTable.Name = "_dbx_new_" + table.Name
+	createSQL, err := s.createTable(schema.CreateTableChange{TableDef: &newTable})
+	if err != nil {
+		return "", err
+	}
+
+	columns := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = quoteIdentifier(col.Name)
+	}
+	columnList := strings.Join(columns, ", ")
+
+	var statements []string
+	for _, view := range rebuild.Views {
+		statements = append(statements, fmt.Sprintf("DROP VIEW %s;", quoteIdentifier(view.Name)))
+	}
+	statements = append(statements,
+		createSQL,
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;",
+			quoteIdentifier(newTable.Name), columnList, columnList, quoteIdentifier(table.Name)),
+		fmt.Sprintf("DROP TABLE %s;", quoteIdentifier(table.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteIdentifier(newTable.Name), quoteIdentifier(table.Name)),
+	)
+
+	for _, idx := range table.Indexes {
+		indexSQL, err := s.addIndex(schema.AddIndexChange{TableName: table.Name, Index: idx})
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, indexSQL)
+	}
+
+	for _, trigger := range rebuild.Triggers {
+		// Inspected SQLite triggers do not keep their body, so they cannot be recreated
+		if strings.TrimSpace(trigger.Function) == "" {
+			return "", fmt.Errorf("cannot rebuild table %s: trigger %s has no body to recreate it from", table.Name, trigger.Name)
+		}
+		triggerSQL, err := s.createTrigger(schema.CreateTriggerChange{Trigger: trigger})
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, triggerSQL)
+	}
+
+	for _, view := range rebuild.Views {
+		viewSQL, err := s.createView(schema.CreateViewChange{View: view})
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, viewSQL)
+	}
+
+	return strings.Join(statements, "\n"), nil
+}
+
+// ClassifyHazards records that a change applied by rebuilding its table copies
+// every row of the table
+func (s *SQLite) ClassifyHazards(change schema.Change) {
+	c, ok := change.(schema.HazardRecorder)
+	if !ok {
+		return
+	}
+	rebuild := schema.RebuildOf(change)
+	if rebuild == nil || rebuild.Superseded || rebuild.Table == nil || c.HasHazard(schema.HazardTableRewrite) {
+		return
+	}
+	c.AddHazard(schema.HazardTableRewrite, fmt.Sprintf("rebuilding table %s copies every row", rebuild.Table.Name))
+}
+
+// PrepareConn turns foreign key enforcement off on conn when changes rebuild a
+// table. When it was on, the returned check runs PRAGMA foreign_key_check on
+// the rebuilt tables and restore turns enforcement back on.
+func (s *SQLite) PrepareConn(ctx context.Context, conn dbx.Querier, changes []schema.Change) (func(context.Context, dbx.Querier) error, func() error, error) {
+	check := func(context.Context, dbx.Querier) error { return nil }
+	restore := func() error { return nil }
+
+	var tables []string
+	for _, change := range changes {
+		if rebuild := schema.RebuildOf(change); rebuild != nil && !rebuild.Superseded && rebuild.Table != nil {
+			tables = append(tables, rebuild.Table.Name)
+		}
+	}
+	if len(tables) == 0 {
+		return check, restore, nil
+	}
+
+	var enabled bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		return nil, nil, fmt.Errorf("failed to read foreign key enforcement: %w", err)
+	}
+	if !enabled {
+		return check, restore, nil
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys=OFF"); err != nil {
+		return nil, nil, fmt.Errorf("failed to turn off foreign key enforcement: %w", err)
+	}
+
+	check = func(ctx context.Context, db dbx.Querier) error {
+		for _, table := range tables {
+			if err := checkForeignKeys(ctx, db, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	restore = func() error {
+		if _, err := conn.ExecContext(context.Background(), "PRAGMA foreign_keys=ON"); err != nil {
+			return fmt.Errorf("failed to turn on foreign key enforcement: %w", err)
+		}
+		return nil
+	}
+	return check, restore, nil
+}
+
+// checkForeignKeys returns an error when rows of table reference rows that do not exist
+func checkForeignKeys(ctx context.Context, db dbx.Querier, table string) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA foreign_key_check(%s)", quoteIdentifier(table)))
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys of table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	violations := 0
+	var parent string
+	for rows.Next() {
+		var child string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&child, &rowid, &parent, &fkid); err != nil {
+			return fmt.Errorf("failed to check foreign keys of table %s: %w", table, err)
+		}
+		violations++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check foreign keys of table %s: %w", table, err)
+	}
+	if violations > 0 {
+		return fmt.Errorf("rebuilt table %s has %d rows referencing missing rows of table %s", table, violations, parent)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swiftcarrot/dbx"
+	"github.com/swiftcarrot/dbx/internal/testutil"
+	"github.com/swiftcarrot/dbx/schema"
+)
+
+func TestRebuildTable(t *testing.T) {
+	sqlite := New()
+	dropColumn := schema.DropColumnChange{
+		TableName:  "users",
+		ColumnName: "nickname",
+		Rebuild: &schema.TableRebuild{
+			Table: &schema.Table{
+				Name: "users",
+				Columns: []*schema.Column{
+					{Name: "id", Type: &IntegerType{}},
+					{Name: "email", Type: &TextType{}},
+				},
+				Indexes:    []*schema.Index{{Name: "users_email_idx", Columns: []string{"email"}, Unique: true}},
+				PrimaryKey: &schema.PrimaryKey{Name: "users_pkey", Columns: []string{"id"}},
+			},
+			Triggers: []*schema.Trigger{
+				{
+					Name:     "users_audit",
+					Table:    "users",
+					Events:   []string{"INSERT"},
+					Timing:   "AFTER",
+					ForEach:  "ROW",
+					Function: "audit_user",
+				},
+			},
+			Views: []*schema.View{
+				{Name: "user_emails", Definition: `SELECT email FROM users`},
+			},
+		},
+	}
+	sql, err := sqlite.GenerateSQL(dropColumn)
+	require.NoError(t, err)
+	expected := `DROP VIEW "user_emails";
+CREATE TABLE "_dbx_new_users" (
+  "id" INTEGER NOT NULL,
+  "email" TEXT NOT NULL,
+  CONSTRAINT "users_pkey" PRIMARY KEY ("id")
+);
+INSERT INTO "_dbx_new_users" ("id", "email") SELECT "id", "email" FROM "users";
+DROP TABLE "users";
+ALTER TABLE "_dbx_new_users" RENAME TO "users";
+CREATE UNIQUE INDEX "users_email_idx" ON "users" ("email");
+CREATE TRIGGER "users_audit"
+AFTER INSERT ON "users"
+FOR EACH ROW
+BEGIN
+  SELECT audit_user;
+END;
+CREATE VIEW "user_emails" AS SELECT email FROM users;`
+	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
+}
+
+func TestRebuildTableInspectedTrigger(t *testing.T) {
+	sqlite := New()
+	alterColumn := schema.AlterColumnChange{
+		TableName: "users",
+		Column:    &schema.Column{Name: "id", Type: &IntegerType{}},
+		Rebuild: &schema.TableRebuild{
+			Table: &schema.Table{
+				Name:    "users",
+				Columns: []*schema.Column{{Name: "id", Type: &IntegerType{}}},
+			},
+			Triggers: []*schema.Trigger{{Name: "users_audit", Table: "users"}},
+		},
+	}
+	_, err := sqlite.GenerateSQL(alterColumn)
+	require.EqualError(t, err, "cannot rebuild table users: trigger users_audit has no body to recreate it from")
+}
+
+func TestRebuildTableApply(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "rebuild.db"))
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE teams (id INTEGER PRIMARY KEY);
+		CREATE TABLE users (
+			id INTEGER NOT NULL,
+			team_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			nickname TEXT,
+			CONSTRAINT users_pkey PRIMARY KEY (id)
+		);
+		CREATE INDEX users_name_idx ON users (name);
+		CREATE VIEW user_names AS SELECT id, name FROM users;
+		INSERT INTO teams (id) VALUES (1);
+		INSERT INTO users (id, team_id, name, nickname) VALUES (1, 1, 'alice', 'al'), (2, 1, 'bob', NULL);
+	`)
+	require.NoError(t, err)
+
+	s := New()
+	source, err := s.Inspect(db)
+	require.NoError(t, err)
+
+	target, err := s.Inspect(db)
+	require.NoError(t, err)
+	users := target.Tables[1]
+	users.Columns = users.Columns[:3]
+	users.Columns[2] = &schema.Column{Name: "name", Type: &TextType{}, Nullable: true}
+	users.ForeignKey("users_team_id_fkey", []string{"team_id"}, "teams", []string{"id"})
+
+	for _, change := range schema.Diff(source, target) {
+		stmt, err := s.GenerateSQL(change)
+		require.NoError(t, err)
+		_, err = db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	result, err := s.Inspect(db)
+	require.NoError(t, err)
+	require.Equal(t, []*schema.Column{
+		{Name: "id", Type: &IntegerType{}},
+		{Name: "team_id", Type: &IntegerType{}},
+		{Name: "name", Type: &TextType{}, Nullable: true},
+	}, result.Tables[1].Columns)
+	require.Equal(t, []*schema.Index{{Name: "users_name_idx", Columns: []string{"name"}}}, result.Tables[1].Indexes)
+	require.Len(t, result.Tables[1].ForeignKeys, 1)
+	require.Equal(t, "user_names", result.Views[0].Name)
+
+	var names string
+	require.NoError(t, db.QueryRow(`SELECT group_concat(name) FROM user_names ORDER BY id`).Scan(&names))
+	require.Equal(t, "alice,bob", names)
+}
+
+func TestRebuildTableOncePerTable(t *testing.T) {
+	source := schema.NewSchema()
+	source.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("nickname", &TextType{}, schema.Nullable)
+	})
+	target := schema.NewSchema()
+	target.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Check("users_id_check", "id > 0")
+		t.Unique("users_id_key", "id")
+	})
+
+	s := New()
+	var statements []string
+	for _, change := range schema.Diff(source, target) {
+		stmt, err := s.GenerateSQL(change)
+		require.NoError(t, err)
+		statements = append(statements, stmt)
+	}
+	require.Len(t, statements, 3)
+	require.Equal(t, []string{"", ""}, statements[:2])
+	require.Contains(t, statements[2], `CONSTRAINT "users_id_check" CHECK (id > 0)`)
+	require.Contains(t, statements[2], `CONSTRAINT "users_id_key" UNIQUE ("id")`)
+	require.Contains(t, statements[2], `INSERT INTO "_dbx_new_users" ("id") SELECT "id" FROM "users";`)
+}
+
+func TestRebuildTableAddedView(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "rebuild.db"))
+	require.NoError(t, err)
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER NOT NULL, name TEXT NOT NULL, old TEXT)`)
+	require.NoError(t, err)
+
+	s := New()
+	source, err := s.Inspect(db)
+	require.NoError(t, err)
+
+	target, err := s.Inspect(db)
+	require.NoError(t, err)
+	target.Tables[0].Columns = target.Tables[0].Columns[:2]
+	target.CreateView("user_names", "SELECT name FROM users")
+
+	for _, change := range schema.Diff(source, target) {
+		stmt, err := s.GenerateSQL(change)
+		require.NoError(t, err)
+		_, err = db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	result, err := s.Inspect(db)
+	require.NoError(t, err)
+	require.Len(t, result.Tables[0].Columns, 2)
+	require.Len(t, result.Views, 1)
+	require.Equal(t, "user_names", result.Views[0].Name)
+}
+
+func TestRebuildNewTableView(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "rebuild.db"))
+	require.NoError(t, err)
+
+	target := schema.NewSchema()
+	target.CreateTable("a", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("b_id", &IntegerType{}, schema.Nullable)
+		t.SetPrimaryKey("", []string{"id"})
+		t.ForeignKey("a_b_id_fkey", []string{"b_id"}, "b", []string{"id"})
+	})
+	target.CreateTable("b", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("a_id", &IntegerType{}, schema.Nullable)
+		t.SetPrimaryKey("", []string{"id"})
+		t.ForeignKey("b_a_id_fkey", []string{"a_id"}, "a", []string{"id"})
+	})
+	target.CreateView("a_ids", "SELECT id FROM a")
+	target.CreateView("b_ids", "SELECT id FROM b")
+
+	s := New()
+	for _, change := range schema.Diff(schema.NewSchema(), target) {
+		stmt, err := s.GenerateSQL(change)
+		require.NoError(t, err)
+		_, err = db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	result, err := s.Inspect(db)
+	require.NoError(t, err)
+	require.Len(t, result.Views, 2)
+}
+
+func TestClassifyHazards(t *testing.T) {
+	source := schema.NewSchema()
+	source.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("nickname", &TextType{}, schema.Nullable)
+	})
+	target := schema.NewSchema()
+	target.CreateTable("users", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("email", &TextType{}, schema.Nullable)
+		t.Check("users_id_check", "id > 0")
+	})
+
+	plan, err := dbx.NewPlan(New(), source, target)
+	require.NoError(t, err)
+	require.Len(t, plan.Steps, 3)
+	require.False(t, plan.Steps[0].Change.(schema.HazardRecorder).HasHazard(schema.HazardTableRewrite))
+	require.False(t, plan.Steps[1].Change.(schema.HazardRecorder).HasHazard(schema.HazardTableRewrite))
+	require.Contains(t, plan.Steps[2].Change.Hazards(), schema.Hazard{
+		Type:   schema.HazardTableRewrite,
+		Reason: "rebuilding table users copies every row",
+	})
+}
+
+func TestPrepareConn(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "prepare.db")+"?_foreign_keys=1")
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE users (id INTEGER PRIMARY KEY, team_id INTEGER REFERENCES teams (id) ON DELETE CASCADE);
+		INSERT INTO teams (id, name) VALUES (1, 'a');
+		INSERT INTO users (id, team_id) VALUES (1, 1);
+	`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := New()
+	source, err := s.Inspect(db)
+	require.NoError(t, err)
+	target, err := s.Inspect(db)
+	require.NoError(t, err)
+	target.Tables[0].Check("teams_name_check", "name <> ''")
+	changes := schema.Diff(source, target)
+
+	check, restore, err := s.PrepareConn(ctx, conn, changes)
+	require.NoError(t, err)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	for _, change := range changes {
+		stmt, err := s.GenerateSQL(change)
+		require.NoError(t, err)
+		_, err = tx.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, check(ctx, tx))
+	require.NoError(t, tx.Commit())
+	require.NoError(t, restore())
+
+	var users, enabled int
+	require.NoError(t, conn.QueryRowContext(ctx, `SELECT count(*) FROM users`).Scan(&users))
+	require.Equal(t, 1, users)
+	require.NoError(t, conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&enabled))
+	require.Equal(t, 1, enabled)
+}
+
+func TestPrepareConnForeignKeyViolation(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "prepare.db")+"?_foreign_keys=1")
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE teams (id INTEGER PRIMARY KEY);
+		CREATE TABLE users (id INTEGER PRIMARY KEY, team_id INTEGER);
+		INSERT INTO users (id, team_id) VALUES (1, 2);
+	`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := New()
+	source, err := s.Inspect(db)
+	require.NoError(t, err)
+	target, err := s.Inspect(db)
+	require.NoError(t, err)
+	target.Tables[1].ForeignKey("users_team_id_fkey", []string{"team_id"}, "teams", []string{"id"})
+	changes := schema.Diff(source, target)
+
+	check, restore, err := s.PrepareConn(ctx, conn, changes)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, restore()) }()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	for _, change := range changes {
+		stmt, err := s.GenerateSQL(change)
+		require.NoError(t, err)
+		_, err = tx.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.EqualError(t, check(ctx, tx), "rebuilt table users has 1 rows referencing missing rows of table teams")
+}
+
+func TestPrepareConnForeignKeysOff(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "prepare.db"))
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	changes := []schema.Change{&schema.AddCheckChange{
+		TableName: "users",
+		Check:     &schema.CheckConstraint{Name: "users_name_check", Expression: "name <> ''"},
+		Rebuild:   &schema.TableRebuild{Table: &schema.Table{Name: "users"}},
+	}}
+	_, restore, err := New().PrepareConn(ctx, conn, changes)
+	require.NoError(t, err)
+	require.NoError(t, restore())
+
+	var enabled int
+	require.NoError(t, conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&enabled))
+	require.Equal(t, 0, enabled)
+}
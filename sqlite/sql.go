@@ -9,17 +9,21 @@ import (
 
 // GenerateSQL generates SQL statements for SQLite database changes
 func (s *SQLite) GenerateSQL(change schema.Change) (string, error) {
-	switch c := change.(type) {
+	switch c := schema.Indirect(change).(type) {
 	case schema.CreateTableChange:
 		return s.createTable(c)
 	case schema.DropTableChange:
 		return s.dropTable(c)
+	case schema.RenameTableChange:
+		return s.renameTable(c)
 	case schema.AddColumnChange:
 		return s.addColumn(c)
 	case schema.DropColumnChange:
 		return s.dropColumn(c)
 	case schema.AlterColumnChange:
 		return s.alterColumn(c)
+	case schema.RenameColumnChange:
+		return s.renameColumn(c)
 	case schema.AddPrimaryKeyChange:
 		return s.addPrimaryKey(c)
 	case schema.DropPrimaryKeyChange:
@@ -28,10 +32,22 @@ func (s *SQLite) GenerateSQL(change schema.Change) (string, error) {
 		return s.addIndex(c)
 	case schema.DropIndexChange:
 		return s.dropIndex(c)
+	case schema.RenameIndexChange:
+		return s.renameIndex(c)
 	case schema.AddForeignKeyChange:
 		return s.addForeignKey(c)
 	case schema.DropForeignKeyChange:
 		return s.dropForeignKey(c)
+	case schema.AddCheckChange:
+		return s.addCheck(c)
+	case schema.DropCheckChange:
+		return s.dropCheck(c)
+	case schema.AddUniqueConstraintChange:
+		return s.addUnique(c)
+	case schema.DropUniqueConstraintChange:
+		return s.dropUnique(c)
+	case schema.ValidateConstraintChange:
+		return "", fmt.Errorf("SQLite does not support NOT VALID constraints")
 	case schema.CreateViewChange:
 		return s.createView(c)
 	case schema.AlterViewChange:
@@ -56,75 +72,61 @@ func (s *SQLite) createTable(change schema.CreateTableChange) (string, error) {
 		return "", fmt.Errorf("table definition is nil")
 	}
 
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", quoteIdentifier(table.Name)))
+	var definitions []string
 
 	// Add columns
-	for i, col := range table.Columns {
-		b.WriteString(fmt.Sprintf("  %s %s", quoteIdentifier(col.Name), col.TypeSQL()))
+	for _, col := range table.Columns {
+		definition := fmt.Sprintf("  %s %s", quoteIdentifier(col.Name), col.TypeSQL())
 
 		if !col.Nullable {
-			b.WriteString(" NOT NULL")
+			definition += " NOT NULL"
 		}
 
 		if col.Default != "" {
-			b.WriteString(fmt.Sprintf(" DEFAULT %s", col.Default))
+			definition += fmt.Sprintf(" DEFAULT %s", col.Default)
 		}
 
-		if i < len(table.Columns)-1 || table.PrimaryKey != nil || len(table.ForeignKeys) > 0 {
-			b.WriteString(",\n")
-		}
+		definitions = append(definitions, definition)
 	}
 
 	// Add primary key
 	if table.PrimaryKey != nil && len(table.PrimaryKey.Columns) > 0 {
-		b.WriteString(fmt.Sprintf("  CONSTRAINT %s PRIMARY KEY (", quoteIdentifier(table.PrimaryKey.Name)))
-		for i, col := range table.PrimaryKey.Columns {
-			if i > 0 {
-				b.WriteString(", ")
-			}
-			b.WriteString(quoteIdentifier(col))
-		}
-		b.WriteString(")")
-
-		if len(table.ForeignKeys) > 0 {
-			b.WriteString(",\n")
-		}
+		definitions = append(definitions, fmt.Sprintf("  CONSTRAINT %s PRIMARY KEY (%s)",
+			quoteIdentifier(table.PrimaryKey.Name),
+			quoteIdentifiers(table.PrimaryKey.Columns)))
 	}
 
 	// Add foreign keys
-	for i, fk := range table.ForeignKeys {
-		b.WriteString(fmt.Sprintf("  CONSTRAINT %s FOREIGN KEY (", quoteIdentifier(fk.Name)))
-		for j, col := range fk.Columns {
-			if j > 0 {
-				b.WriteString(", ")
-			}
-			b.WriteString(quoteIdentifier(col))
-		}
-		b.WriteString(fmt.Sprintf(") REFERENCES %s (", quoteIdentifier(fk.RefTable)))
-		for j, col := range fk.RefColumns {
-			if j > 0 {
-				b.WriteString(", ")
-			}
-			b.WriteString(quoteIdentifier(col))
-		}
-		b.WriteString(")")
+	for _, fk := range table.ForeignKeys {
+		definition := fmt.Sprintf("  CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quoteIdentifier(fk.Name),
+			quoteIdentifiers(fk.Columns),
+			quoteIdentifier(fk.RefTable),
+			quoteIdentifiers(fk.RefColumns))
 
 		if fk.OnDelete != "" {
-			b.WriteString(fmt.Sprintf(" ON DELETE %s", fk.OnDelete))
+			definition += fmt.Sprintf(" ON DELETE %s", fk.OnDelete)
 		}
 		if fk.OnUpdate != "" {
-			b.WriteString(fmt.Sprintf(" ON UPDATE %s", fk.OnUpdate))
+			definition += fmt.Sprintf(" ON UPDATE %s", fk.OnUpdate)
 		}
 
-		if i < len(table.ForeignKeys)-1 {
-			b.WriteString(",\n")
-		}
+		definitions = append(definitions, definition)
 	}
 
-	b.WriteString("\n);")
+	// Add unique and check constraints
+	for _, unique := range table.Uniques {
+		definitions = append(definitions, fmt.Sprintf("  CONSTRAINT %s UNIQUE (%s)",
+			quoteIdentifier(unique.Name),
+			quoteIdentifiers(unique.Columns)))
+	}
+	for _, check := range table.Checks {
+		definitions = append(definitions, fmt.Sprintf("  CONSTRAINT %s CHECK (%s)",
+			quoteIdentifier(check.Name),
+			check.Expression))
+	}
 
-	return b.String(), nil
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", quoteIdentifier(table.Name), strings.Join(definitions, ",\n")), nil
 }
 
 // dropTable generates SQL for dropping a table
@@ -132,6 +134,11 @@ func (s *SQLite) dropTable(change schema.DropTableChange) (string, error) {
 	return fmt.Sprintf("DROP TABLE %s;", quoteIdentifier(change.TableName)), nil
 }
 
+// renameTable generates SQL for renaming a table
+func (s *SQLite) renameTable(change schema.RenameTableChange) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteIdentifier(change.OldName), quoteIdentifier(change.NewName)), nil
+}
+
 // addColumn generates SQL for adding a column to a table
 func (s *SQLite) addColumn(change schema.AddColumnChange) (string, error) {
 	col := change.Column
@@ -158,27 +165,47 @@ func (s *SQLite) addColumn(change schema.AddColumnChange) (string, error) {
 }
 
 // dropColumn generates SQL for dropping a column from a table
-// Note: SQLite does not support DROP COLUMN directly, it requires recreating the table
+// Note: SQLite does not support DROP COLUMN directly, the table is rebuilt instead
 func (s *SQLite) dropColumn(change schema.DropColumnChange) (string, error) {
-	return "", fmt.Errorf("SQLite does not support DROP COLUMN directly; you need to recreate the table")
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support DROP COLUMN directly; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
+}
+
+// renameColumn generates SQL for renaming a column
+func (s *SQLite) renameColumn(change schema.RenameColumnChange) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;",
+		quoteIdentifier(change.TableName),
+		quoteIdentifier(change.OldName),
+		quoteIdentifier(change.NewName)), nil
 }
 
 // alterColumn generates SQL for altering a column
-// Note: SQLite does not support ALTER COLUMN directly, it requires recreating the table
+// Note: SQLite does not support ALTER COLUMN, the table is rebuilt instead
 func (s *SQLite) alterColumn(change schema.AlterColumnChange) (string, error) {
-	return "", fmt.Errorf("SQLite does not support ALTER COLUMN directly; you need to recreate the table")
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support ALTER COLUMN directly; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
 }
 
 // addPrimaryKey generates SQL for adding a primary key
-// Note: SQLite does not support adding primary keys to existing tables
+// Note: SQLite does not support adding primary keys to existing tables, the table is rebuilt instead
 func (s *SQLite) addPrimaryKey(change schema.AddPrimaryKeyChange) (string, error) {
-	return "", fmt.Errorf("SQLite does not support adding primary keys to existing tables; you need to recreate the table")
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support adding primary keys to existing tables; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
 }
 
 // dropPrimaryKey generates SQL for dropping a primary key
-// Note: SQLite does not support dropping primary keys
+// Note: SQLite does not support dropping primary keys, the table is rebuilt instead
 func (s *SQLite) dropPrimaryKey(change schema.DropPrimaryKeyChange) (string, error) {
-	return "", fmt.Errorf("SQLite does not support dropping primary keys; you need to recreate the table")
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support dropping primary keys; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
 }
 
 // addIndex generates SQL for adding an index
@@ -188,6 +215,13 @@ func (s *SQLite) addIndex(change schema.AddIndexChange) (string, error) {
 		return "", fmt.Errorf("index definition is nil")
 	}
 
+	if idx.Method != "" && !strings.EqualFold(idx.Method, "btree") {
+		return "", fmt.Errorf("index method %s not supported in SQLite", idx.Method)
+	}
+	if len(idx.Include) > 0 {
+		return "", fmt.Errorf("included index columns not supported in SQLite")
+	}
+
 	var b strings.Builder
 	if idx.Unique {
 		b.WriteString("CREATE UNIQUE INDEX ")
@@ -203,9 +237,25 @@ func (s *SQLite) addIndex(change schema.AddIndexChange) (string, error) {
 		if i > 0 {
 			b.WriteString(", ")
 		}
-		b.WriteString(quoteIdentifier(col))
+		option := idx.KeyOption(col)
+		if option.Nulls != "" || option.OpClass != "" || option.Length > 0 {
+			return "", fmt.Errorf("nulls ordering, operator classes and prefix lengths not supported in SQLite indexes")
+		}
+		if strings.HasPrefix(col, "(") {
+			b.WriteString(col)
+		} else {
+			b.WriteString(quoteIdentifier(col))
+		}
+		if option.Desc {
+			b.WriteString(" DESC")
+		}
+	}
+	b.WriteString(")")
+
+	if idx.Where != "" {
+		b.WriteString(" WHERE " + idx.Where)
 	}
-	b.WriteString(");")
+	b.WriteString(";")
 
 	return b.String(), nil
 }
@@ -215,16 +265,71 @@ func (s *SQLite) dropIndex(change schema.DropIndexChange) (string, error) {
 	return fmt.Sprintf("DROP INDEX %s;", quoteIdentifier(change.IndexName)), nil
 }
 
+// renameIndex generates SQL for renaming an index
+// Note: SQLite cannot rename indexes, the index is recreated under the new name instead
+func (s *SQLite) renameIndex(change schema.RenameIndexChange) (string, error) {
+	if change.Index == nil {
+		return "", fmt.Errorf("SQLite does not support renaming indexes; the change needs the Index definition to recreate it")
+	}
+	create, err := s.addIndex(schema.AddIndexChange{TableName: change.TableName, Index: change.Index})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("DROP INDEX %s;\n%s", quoteIdentifier(change.OldName), create), nil
+}
+
 // addForeignKey generates SQL for adding a foreign key
-// Note: SQLite only supports foreign keys when creating tables
+// Note: SQLite only supports foreign keys when creating tables, the table is rebuilt instead
 func (s *SQLite) addForeignKey(change schema.AddForeignKeyChange) (string, error) {
-	return "", fmt.Errorf("SQLite does not support adding foreign keys to existing tables; you need to recreate the table")
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support adding foreign keys to existing tables; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
 }
 
 // dropForeignKey generates SQL for dropping a foreign key
-// Note: SQLite does not support dropping foreign keys
+// Note: SQLite does not support dropping foreign keys, the table is rebuilt instead
 func (s *SQLite) dropForeignKey(change schema.DropForeignKeyChange) (string, error) {
-	return "", fmt.Errorf("SQLite does not support dropping foreign keys; you need to recreate the table")
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support dropping foreign keys; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
+}
+
+// addCheck generates SQL for adding a check constraint
+// Note: SQLite only supports check constraints when creating tables, the table is rebuilt instead
+func (s *SQLite) addCheck(change schema.AddCheckChange) (string, error) {
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support adding check constraints to existing tables; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
+}
+
+// dropCheck generates SQL for dropping a check constraint
+// Note: SQLite does not support dropping check constraints, the table is rebuilt instead
+func (s *SQLite) dropCheck(change schema.DropCheckChange) (string, error) {
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support dropping check constraints; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
+}
+
+// addUnique generates SQL for adding a unique constraint
+// Note: SQLite only supports unique constraints when creating tables, the table is rebuilt instead
+func (s *SQLite) addUnique(change schema.AddUniqueConstraintChange) (string, error) {
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support adding unique constraints to existing tables; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
+}
+
+// dropUnique generates SQL for dropping a unique constraint
+// Note: SQLite does not support dropping unique constraints, the table is rebuilt instead
+func (s *SQLite) dropUnique(change schema.DropUniqueConstraintChange) (string, error) {
+	if change.Rebuild == nil {
+		return "", fmt.Errorf("SQLite does not support dropping unique constraints; the change needs a Rebuild definition to recreate the table")
+	}
+	return s.rebuildTable(change.Rebuild)
 }
 
 // createView generates SQL for creating a view
@@ -417,6 +522,15 @@ func quoteIdentifier(s string) string {
 	return strings.Join(parts, ".")
 }
 
+// quoteIdentifiers quotes identifiers as a comma separated list
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func quoteLiteral(s string) string {
 	// Check if it's already quoted
 	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
@@ -427,3 +541,8 @@ func quoteLiteral(s string) string {
 	s = strings.ReplaceAll(s, "'", "''")
 	return "'" + s + "'"
 }
+
+// Placeholder returns the bind parameter placeholder for the nth argument of a query
+func (s *SQLite) Placeholder(n int) string {
+	return "?"
+}
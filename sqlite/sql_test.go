@@ -42,6 +42,40 @@ func TestCreateTable(t *testing.T) {
 	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
 }
 
+func TestCreateTableWithConstraints(t *testing.T) {
+	sqlite := New()
+	s := schema.NewSchema()
+	table := s.CreateTable("products", func(t *schema.Table) {
+		t.Column("id", &IntegerType{})
+		t.Column("sku", &TextType{})
+		t.Column("price", &IntegerType{})
+		t.SetPrimaryKey("products_pkey", []string{"id"})
+		t.Unique("products_sku_key", "sku")
+		t.Check("products_price_check", "price > 0")
+	})
+
+	sql, err := sqlite.GenerateSQL(schema.CreateTableChange{TableDef: table})
+	require.NoError(t, err)
+	expected := `CREATE TABLE "products" (
+  "id" INTEGER NOT NULL,
+  "sku" TEXT NOT NULL,
+  "price" INTEGER NOT NULL,
+  CONSTRAINT "products_pkey" PRIMARY KEY ("id"),
+  CONSTRAINT "products_sku_key" UNIQUE ("sku"),
+  CONSTRAINT "products_price_check" CHECK (price > 0)
+);`
+	require.Equal(t, testutil.FormatSQL(expected), testutil.FormatSQL(sql))
+}
+
+func TestAddCheckWithoutRebuild(t *testing.T) {
+	sqlite := New()
+	_, err := sqlite.GenerateSQL(schema.AddCheckChange{
+		TableName: "products",
+		Check:     &schema.CheckConstraint{Name: "products_price_check", Expression: "price > 0"},
+	})
+	require.EqualError(t, err, "SQLite does not support adding check constraints to existing tables; the change needs a Rebuild definition to recreate the table")
+}
+
 func TestDropTable(t *testing.T) {
 	sqlite := New()
 	dropTable := schema.DropTableChange{
@@ -52,6 +86,20 @@ func TestDropTable(t *testing.T) {
 	require.Equal(t, `DROP TABLE "users";`, sql)
 }
 
+func TestRenameTable(t *testing.T) {
+	sqlite := New()
+	sql, err := sqlite.GenerateSQL(schema.RenameTableChange{OldName: "users", NewName: "accounts"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "users" RENAME TO "accounts";`, sql)
+}
+
+func TestRenameColumn(t *testing.T) {
+	sqlite := New()
+	sql, err := sqlite.GenerateSQL(schema.RenameColumnChange{TableName: "users", OldName: "name", NewName: "full_name"})
+	require.NoError(t, err)
+	require.Equal(t, `ALTER TABLE "users" RENAME COLUMN "name" TO "full_name";`, sql)
+}
+
 func TestAddColumn(t *testing.T) {
 	sqlite := New()
 	column := &schema.Column{
@@ -112,6 +160,32 @@ func TestAddIndex(t *testing.T) {
 	require.Equal(t, `CREATE INDEX "idx_users_name_email" ON "users" ("name", "email");`, sql)
 }
 
+func TestAddIndexWithOptions(t *testing.T) {
+	sqlite := New()
+
+	sql, err := sqlite.GenerateSQL(schema.AddIndexChange{
+		TableName: "users",
+		Index: &schema.Index{
+			Name:       "idx_users_active_email",
+			Columns:    []string{"(lower(email))", "created_at"},
+			Where:      "deleted_at IS NULL",
+			KeyOptions: []*schema.IndexKeyOption{{Column: "created_at", Desc: true}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `CREATE INDEX "idx_users_active_email" ON "users" ((lower(email)), "created_at" DESC) WHERE deleted_at IS NULL;`, sql)
+
+	_, err = sqlite.GenerateSQL(schema.AddIndexChange{
+		TableName: "users",
+		Index: &schema.Index{
+			Name:    "idx_users_tags",
+			Columns: []string{"tags"},
+			Method:  "gin",
+		},
+	})
+	require.EqualError(t, err, "index method gin not supported in SQLite")
+}
+
 func TestDropIndex(t *testing.T) {
 	sqlite := New()
 	dropIdx := schema.DropIndexChange{
@@ -122,6 +196,27 @@ func TestDropIndex(t *testing.T) {
 	require.Equal(t, `DROP INDEX "idx_users_email";`, sql)
 }
 
+func TestRenameIndex(t *testing.T) {
+	sqlite := New()
+	renameIdx := schema.RenameIndexChange{
+		TableName: "users",
+		OldName:   "idx_email",
+		NewName:   "users_email_idx",
+		Index: &schema.Index{
+			Name:    "users_email_idx",
+			Columns: []string{"email"},
+			Unique:  true,
+		},
+	}
+	sql, err := sqlite.GenerateSQL(renameIdx)
+	require.NoError(t, err)
+	require.Equal(t, `DROP INDEX "idx_email";
+CREATE UNIQUE INDEX "users_email_idx" ON "users" ("email");`, sql)
+
+	_, err = sqlite.GenerateSQL(schema.RenameIndexChange{TableName: "users", OldName: "idx_email", NewName: "users_email_idx"})
+	require.EqualError(t, err, "SQLite does not support renaming indexes; the change needs the Index definition to recreate it")
+}
+
 func TestCreateView(t *testing.T) {
 	sqlite := New()
 